@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Remote endpoint authentication - bearer tokens with scopes. A token is sent
+// as "Authorization: Bearer <token>" or, for browser WebSockets that cannot
+// set headers, as ?token=<token>.
+//
+//	read     - game state, surroundings, subscriptions and resources
+//	play     - gameplay commands and holding the control lease (implies read)
+//	cheat    - cheat_* commands (implies read)
+//	operator - preempting another agent's control lease and changing the goal queue (implies play)
+//
+// Every token needs at least one scope, so every token can read state.
+// ============================================================================
+
+const (
+	scopeRead     = "read"
+	scopePlay     = "play"
+	scopeCheat    = "cheat"
+	scopeOperator = "operator"
+)
+
+// TokenConfig is one accepted bearer token
+type TokenConfig struct {
+	Name   string   `yaml:"name"`
+	Token  string   `yaml:"token"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// has reports whether the token grants scope
+func (t *TokenConfig) has(scope string) bool {
+	for _, s := range t.Scopes {
+		switch {
+		case s == scope:
+			return true
+		case scope == scopeRead && (s == scopePlay || s == scopeCheat || s == scopeOperator):
+			return true
+		case scope == scopePlay && s == scopeOperator:
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeError is returned for commands outside the caller's token scopes
+type ScopeError struct {
+	Token  string
+	Scope  string
+	Target string // tool name or mod action
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("token %q lacks the %q scope required for %s", e.Token, e.Scope, e.Target)
+}
+
+// loadTokens returns the configured tokens plus those in the tokens file
+func loadTokens(auth AuthConfig) ([]TokenConfig, error) {
+	tokens := append([]TokenConfig(nil), auth.Tokens...)
+	if auth.TokensFile != "" {
+		data, err := os.ReadFile(auth.TokensFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tokens file: %w", err)
+		}
+		var file struct {
+			Tokens []TokenConfig `yaml:"tokens"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse tokens file %s: %w", auth.TokensFile, err)
+		}
+		tokens = append(tokens, file.Tokens...)
+	}
+
+	for i, t := range tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("token %d (%q) is empty", i+1, t.Name)
+		}
+		if t.Name == "" {
+			tokens[i].Name = fmt.Sprintf("token-%d", i+1)
+		}
+		if len(t.Scopes) == 0 {
+			return nil, fmt.Errorf("token %q has no scopes", tokens[i].Name)
+		}
+		for _, s := range t.Scopes {
+			if s != scopeRead && s != scopePlay && s != scopeCheat && s != scopeOperator {
+				return nil, fmt.Errorf("token %q has unknown scope %q (want read, play, cheat or operator)", tokens[i].Name, s)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// actionScope is the scope a mod action needs
+func actionScope(action string) string {
+	if strings.HasPrefix(action, "cheat_") {
+		return scopeCheat
+	}
+	return scopePlay
+}
+
+// toolScope is the scope a tool needs: cheat if it sends any cheat action,
+// play if it sends any other action, read if it only reads state
+func toolScope(d ToolDescriptor) string {
+	scope := scopeRead
+	for _, action := range d.Actions {
+		if s := actionScope(action); s == scopeCheat {
+			return scopeCheat
+		} else if s == scopePlay {
+			scope = scopePlay
+		}
+	}
+	return scope
+}
+
+type tokenKey struct{}
+
+// withToken binds the authenticated token of a remote caller to ctx
+func withToken(ctx context.Context, token *TokenConfig) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// tokenFrom returns the token bound to ctx, or nil for callers without one
+func tokenFrom(ctx context.Context) *TokenConfig {
+	token, _ := ctx.Value(tokenKey{}).(*TokenConfig)
+	return token
+}
+
+// authorize checks that the caller bound to ctx may use scope on target.
+// Callers without a token (local modes, or a server without tokens) are trusted.
+func authorize(ctx context.Context, scope, target string) error {
+	token, ok := ctx.Value(tokenKey{}).(*TokenConfig)
+	if !ok || token.has(scope) {
+		return nil
+	}
+	return &ScopeError{Token: token.Name, Scope: scope, Target: target}
+}
+
+// requireToken rejects requests without a valid bearer token and binds the
+// token to the request context. With no tokens configured it is a no-op;
+// runServerMode only allows that on a loopback host or behind mTLS.
+func requireToken(tokens []TokenConfig, next http.HandlerFunc) http.HandlerFunc {
+	if len(tokens) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		presented := r.URL.Query().Get("token")
+		if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+			presented = strings.TrimPrefix(h, "Bearer ")
+		}
+
+		var match *TokenConfig
+		for i := range tokens {
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(tokens[i].Token)) == 1 {
+				match = &tokens[i]
+			}
+		}
+		if presented == "" || match == nil {
+			log.Printf("[AUTH] Rejected %s %s from %s: missing or unknown token", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stardew-mcp"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(withToken(r.Context(), match)))
+	}
+}
+
+// isLoopbackHost reports whether host only accepts connections from this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
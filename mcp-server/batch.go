@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Command batches - an ordered list of actions the mod runs back to back in
+// one round trip, with no other command in between. A step may list response
+// data values it must produce to count as a success, e.g. {"arrived": true}.
+// By default a batch stops at the first failed step and reports the rest as
+// skipped. Mods without the "batch" feature get the steps one at a time.
+// Tile-changing cheats in a batch go into the undo journal like single
+// commands; a batch with several is undone as one.
+// ============================================================================
+
+const featureBatch = "batch"
+
+// BatchStep is one action of a batch
+type BatchStep struct {
+	Action string                 `json:"action"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Expect map[string]interface{} `json:"expect,omitempty"`
+}
+
+// BatchResult is the outcome of one batch step
+type BatchResult struct {
+	Action  string      `json:"action"`
+	Success bool        `json:"success"`
+	Skipped bool        `json:"skipped,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// BatchResponse is the mod's answer to a batch, with one result per step
+type BatchResponse struct {
+	Success bool
+	Message string
+	Results []BatchResult
+}
+
+// Failed returns the first step that ran and failed, or nil
+func (b *BatchResponse) Failed() *BatchResult {
+	for i := range b.Results {
+		if !b.Results[i].Success && !b.Results[i].Skipped {
+			return &b.Results[i]
+		}
+	}
+	return nil
+}
+
+// Last returns the result of the final step
+func (b *BatchResponse) Last() *BatchResult {
+	if len(b.Results) == 0 {
+		return nil
+	}
+	return &b.Results[len(b.Results)-1]
+}
+
+// SendBatch runs steps as one batch and waits for every step's result. The
+// batch takes a single scheduler slot, and without a deadline on ctx it may
+// take as long as its steps' timeouts combined.
+func (c *GameClient) SendBatch(ctx context.Context, steps []BatchStep, stopOnFailure bool) (*BatchResponse, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("batch has no steps")
+	}
+	c.mu.RLock()
+	connected, caps := c.connected, c.caps
+	c.mu.RUnlock()
+	if !connected {
+		return nil, ErrDisconnected
+	}
+	for _, step := range steps {
+		if !caps.Supports(step.Action) {
+			return nil, &UnsupportedActionError{Action: step.Action, Mod: caps}
+		}
+	}
+	if !caps.HasFeature(featureBatch) {
+		return c.sendSteps(ctx, steps, stopOnFailure)
+	}
+	if err := c.lease.Check(controllerFrom(ctx).id); err != nil {
+		return nil, err
+	}
+
+	release, err := c.scheduler.Acquire(ctx, priorityFrom(ctx), "batch")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var timeout time.Duration
+		for _, step := range steps {
+			timeout += config.Server.Connection.CommandTimeoutFor(step.Action)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Tile-changing cheats in the batch are journaled together as one
+	// command, diffed across the whole batch; see journal.go
+	var journaled []string
+	for _, step := range steps {
+		if shouldJournal(ctx, step.Action, step.Params) {
+			journaled = append(journaled, step.Action)
+		}
+	}
+	var before *GameState
+	if len(journaled) > 0 {
+		if before, err = c.freshState(ctx); err != nil {
+			log.Printf("[JOURNAL] Not journaling batch of %s: %v", strings.Join(journaled, ", "), err)
+		}
+	}
+
+	resp, err := c.roundTrip(ctx, WebSocketMessage{
+		Type: "batch",
+		Params: map[string]interface{}{
+			"steps":         steps,
+			"stopOnFailure": stopOnFailure,
+		},
+	}, "batch")
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &BatchResponse{Success: resp.Success, Message: resp.Message}
+	if resp.Data != nil {
+		var data struct {
+			Results []BatchResult `json:"results"`
+		}
+		raw, _ := json.Marshal(resp.Data)
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse batch results: %w", err)
+		}
+		batch.Results = data.Results
+	}
+	if before != nil && batch.ranJournaled(steps) {
+		batch.Message += c.journalCommand("batch: "+strings.Join(journaled, ", "), before)
+	}
+	return batch, nil
+}
+
+// ranJournaled reports whether a journaled step of the batch succeeded
+func (b *BatchResponse) ranJournaled(steps []BatchStep) bool {
+	for i, result := range b.Results {
+		if i < len(steps) && result.Success && journaledActions[steps[i].Action] {
+			return true
+		}
+	}
+	return false
+}
+
+// sendSteps runs a batch one command at a time for mods without batch
+// support. Other commands may run between its steps.
+func (c *GameClient) sendSteps(ctx context.Context, steps []BatchStep, stopOnFailure bool) (*BatchResponse, error) {
+	batch := &BatchResponse{Results: make([]BatchResult, 0, len(steps))}
+	failed, stopped := 0, false
+	for i, step := range steps {
+		if stopped {
+			batch.Results = append(batch.Results, BatchResult{Action: step.Action, Skipped: true, Message: "Skipped after a failed step"})
+			continue
+		}
+
+		resp, err := c.SendCommandContext(ctx, step.Action, step.Params)
+		if err != nil {
+			return nil, fmt.Errorf("batch step %d (%s): %w", i+1, step.Action, err)
+		}
+		result := BatchResult{Action: step.Action, Success: resp.Success, Message: resp.Message, Data: resp.Data}
+		if result.Success {
+			if reason := unmetExpectation(step.Expect, resp.Data); reason != "" {
+				result.Success = false
+				result.Message = fmt.Sprintf("%s (%s)", resp.Message, reason)
+			}
+		}
+		batch.Results = append(batch.Results, result)
+		if !result.Success {
+			failed++
+			stopped = stopOnFailure
+			if stopped {
+				batch.Message = fmt.Sprintf("Batch stopped at step %d of %d: %s failed", i+1, len(steps), step.Action)
+			}
+		}
+	}
+
+	batch.Success = failed == 0
+	if !stopped {
+		batch.Message = fmt.Sprintf("Batch completed: %d of %d steps succeeded", len(steps)-failed, len(steps))
+	}
+	return batch, nil
+}
+
+// unmetExpectation describes the first expected value missing from a step's
+// response data, comparing JSON encodings like the mod; "" if all match
+func unmetExpectation(expect map[string]interface{}, data interface{}) string {
+	values, _ := data.(map[string]interface{})
+	for key, want := range expect {
+		wantJSON, _ := json.Marshal(want)
+		got, ok := values[key]
+		if !ok {
+			return fmt.Sprintf("expected %s=%s, got no %s", key, wantJSON, key)
+		}
+		if gotJSON, _ := json.Marshal(got); string(gotJSON) != string(wantJSON) {
+			return fmt.Sprintf("expected %s=%s, got %s", key, wantJSON, gotJSON)
+		}
+	}
+	return ""
+}
+
+// parseBatchSteps decodes the steps of a remote agent's batch message
+func parseBatchSteps(params map[string]interface{}) ([]BatchStep, bool, error) {
+	var steps []BatchStep
+	raw, _ := json.Marshal(params["steps"])
+	if err := json.Unmarshal(raw, &steps); err != nil || len(steps) == 0 {
+		return nil, false, fmt.Errorf("batch needs a non-empty steps array")
+	}
+	for i, step := range steps {
+		if step.Action == "" {
+			return nil, false, fmt.Errorf("batch step %d has no action", i+1)
+		}
+	}
+	stopOnFailure := true
+	if v, ok := params["stopOnFailure"].(bool); ok {
+		stopOnFailure = v
+	}
+	return steps, stopOnFailure, nil
+}
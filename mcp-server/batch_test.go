@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"stardew-mcp/fakegame"
+)
+
+func countHoeDirt(state fakegame.State) int {
+	n := 0
+	for _, f := range state.Surroundings.NearbyTerrainFeatures {
+		if f.Type == "hoe_dirt" {
+			n++
+		}
+	}
+	return n
+}
+
+// TestBatchJournal checks that cheats sent in a batch can be undone like
+// single commands
+func TestBatchJournal(t *testing.T) {
+	fake := fakegame.NewServer(fakegame.Options{StateInterval: -1})
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Cleanup(fake.Close)
+
+	c := NewGameClient()
+	if err := c.Connect("ws" + strings.TrimPrefix(srv.URL, "http") + "/game"); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	ctx := withGameClient(context.Background(), c)
+	hoed := countHoeDirt(fake.State())
+
+	batch, err := c.SendBatch(ctx, []BatchStep{
+		{Action: "cheat_mode_enable"},
+		{Action: "cheat_hoe_all", Params: map[string]interface{}{"radius": 3}},
+	}, true)
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if !batch.Success || !strings.Contains(batch.Message, "journaled as command") {
+		t.Fatalf("batch = %+v, want a journaled success", batch)
+	}
+	if countHoeDirt(fake.State()) == hoed {
+		t.Fatal("cheat_hoe_all hoed nothing")
+	}
+
+	entries := c.journal.Entries()
+	if len(entries) != 1 || entries[0].Action != "batch: cheat_hoe_all" {
+		t.Fatalf("journal = %+v, want one batch entry", entries)
+	}
+
+	if _, err := undoEntry(ctx, ""); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got := countHoeDirt(fake.State()); got != hoed {
+		t.Errorf("%d hoed tile(s) after undo, want %d", got, hoed)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Capability negotiation - on connect the server sends
+//
+//	{"id":"...","type":"hello","params":{"protocolVersion":1}}
+//
+// and the mod answers with its protocol version, mod version, supported
+// actions and optional features. Mods that predate the handshake answer with
+// an error and are treated as protocol 0.
+// ============================================================================
+
+// protocolVersion is the newest mod protocol this server understands
+const protocolVersion = 1
+
+// Capabilities is what the connected mod reported in its hello
+type Capabilities struct {
+	ProtocolVersion int      `json:"protocolVersion"`
+	ModVersion      string   `json:"modVersion"`
+	Actions         []string `json:"actions"`
+	Features        []string `json:"features,omitempty"`
+}
+
+// Known reports whether the mod listed its actions. Until then (and for
+// protocol 0 mods) every action is assumed to be supported.
+func (c Capabilities) Known() bool {
+	return c.Actions != nil
+}
+
+// Supports reports whether the mod can execute action
+func (c Capabilities) Supports(action string) bool {
+	if !c.Known() {
+		return true
+	}
+	for _, a := range c.Actions {
+		if strings.EqualFold(a, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsAll reports whether the mod can execute every one of actions
+func (c Capabilities) SupportsAll(actions []string) bool {
+	for _, action := range actions {
+		if !c.Supports(action) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasFeature reports whether the mod advertised an optional protocol feature
+func (c Capabilities) HasFeature(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the mod build for logs and errors
+func (c Capabilities) String() string {
+	if c.ProtocolVersion == 0 {
+		return "StardewMCP mod (protocol 0, no capability handshake)"
+	}
+	return fmt.Sprintf("StardewMCP mod %s (protocol %d)", c.ModVersion, c.ProtocolVersion)
+}
+
+// UnsupportedActionError is returned for commands the connected mod cannot execute
+type UnsupportedActionError struct {
+	Action string
+	Mod    Capabilities
+}
+
+func (e *UnsupportedActionError) Error() string {
+	return fmt.Sprintf("action %q is not supported by the connected %s; update the mod to use it", e.Action, e.Mod)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestLegacyModRefusesHello checks that a mod without the handshake is
+// detected by its error reply, whatever the error says
+func TestLegacyModRefusesHello(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteJSON(deltaState(0, 600))
+		for {
+			var msg WebSocketMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type == "hello" {
+				conn.WriteJSON(map[string]interface{}{"type": "error", "message": "unsupported request HELLO"})
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewGameClient()
+	if err := c.Connect("ws" + strings.TrimPrefix(srv.URL, "http")); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if caps := c.Capabilities(); caps.Known() {
+		t.Errorf("capabilities = %s, want a legacy mod", caps)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config mirrors config.yaml. Values are resolved with the precedence
+// flags > environment (STARDEW_MCP_*) > config file > defaults.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Remote   RemoteConfig   `yaml:"remote"`
+	Agent    AgentConfig    `yaml:"agent"`
+	OpenClaw OpenClawConfig `yaml:"openclaw"`
+}
+
+// ServerConfig holds the game connection. Instances names additional games
+// by URL; game_url is always the instance called "default". TLSCA is a PEM
+// bundle trusted for wss:// game URLs in addition to the system roots.
+type ServerConfig struct {
+	GameURL    string            `yaml:"game_url"`
+	Instances  map[string]string `yaml:"instances"`
+	TLSCA      string            `yaml:"tls_ca"`
+	AutoStart  bool              `yaml:"auto_start"`
+	Connection ConnectionConfig  `yaml:"connection"`
+}
+
+// ConnectionConfig holds game connection timings in seconds.
+// Reconnects start at ReconnectDelay and double up to ReconnectMaxDelay.
+// CommandTimeouts overrides CommandTimeout for individual actions.
+// StateDeltas asks the mod for JSON-patch deltas instead of full snapshots.
+// MaxInFlight is how many commands may wait for the mod at once; the rest
+// queue by priority.
+type ConnectionConfig struct {
+	ReconnectDelay    float64            `yaml:"reconnect_delay"`
+	ReconnectMaxDelay float64            `yaml:"reconnect_max_delay"`
+	PingInterval      float64            `yaml:"ping_interval"`
+	CommandTimeout    float64            `yaml:"command_timeout"`
+	CommandTimeouts   map[string]float64 `yaml:"command_timeouts"`
+	StateDeltas       bool               `yaml:"state_deltas"`
+	MaxInFlight       int                `yaml:"max_in_flight"`
+}
+
+// RemoteConfig is the -server endpoint. TLSCert and TLSKey switch it to
+// HTTPS/wss; TLSClientCA additionally requires agents to present a client
+// certificate signed by that CA.
+type RemoteConfig struct {
+	Host        string     `yaml:"host"`
+	Port        int        `yaml:"port"`
+	TLSCert     string     `yaml:"tls_cert"`
+	TLSKey      string     `yaml:"tls_key"`
+	TLSClientCA string     `yaml:"tls_client_ca"`
+	Auth        AuthConfig `yaml:"auth"`
+	// RunAgent also runs the autonomous agent, steered through the goal queue
+	RunAgent bool `yaml:"run_agent"`
+}
+
+// AuthConfig lists the bearer tokens accepted by the remote endpoint, inline
+// and/or in a separate YAML file with the same tokens list. No tokens means
+// no authentication.
+type AuthConfig struct {
+	Tokens     []TokenConfig `yaml:"tokens"`
+	TokensFile string        `yaml:"tokens_file"`
+}
+
+type AgentConfig struct {
+	DefaultGoal string `yaml:"default_goal"`
+	// DefaultGoalDone lists the conditions that end the default goal, e.g. "money >= 5000"
+	DefaultGoalDone []string `yaml:"default_goal_done"`
+	// GoalQueueFile persists the goal queue across restarts ("" keeps it in memory)
+	GoalQueueFile string         `yaml:"goal_queue_file"`
+	LLMTimeout    float64        `yaml:"llm_timeout"`
+	CheatMode     bool           `yaml:"cheat_mode"`
+	LLM           LLMConfig      `yaml:"llm"`
+	Behavior      BehaviorConfig `yaml:"behavior"`
+}
+
+// Goal returns the default goal with its done conditions parsed
+func (c AgentConfig) Goal() (Goal, error) {
+	return NewGoal(c.DefaultGoal, c.DefaultGoalDone)
+}
+
+// LLMConfig selects the model behind the autonomous agent. Backend is copilot
+// (GitHub Copilot SDK), openai (an OpenAI-compatible chat completions API at
+// BaseURL, hosted or local) or scripted (replays the turns in Script).
+type LLMConfig struct {
+	Backend string `yaml:"backend"`
+	Model   string `yaml:"model"`
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	Script  string `yaml:"script"`
+}
+
+// BehaviorConfig tunes the autonomous loop. Times use the game clock (2200 = 10PM, 2500 = 1AM).
+type BehaviorConfig struct {
+	LoopInterval      float64 `yaml:"loop_interval"`
+	EmergencyEnergy   float64 `yaml:"emergency_energy"`
+	LowEnergy         float64 `yaml:"low_energy"`
+	SleepThreshold    int     `yaml:"sleep_threshold"`
+	BedtimeThreshold  int     `yaml:"bedtime_threshold"`
+	CriticalThreshold int     `yaml:"critical_threshold"`
+	// Survival supervisor: eats, flees and goes to bed on its own
+	Supervisor          bool `yaml:"supervisor"`
+	LowHealth           int  `yaml:"low_health"`
+	MonsterDistance     int  `yaml:"monster_distance"`
+	SupervisorCheatWarp bool `yaml:"supervisor_cheat_warp"`
+}
+
+type OpenClawConfig struct {
+	GatewayURL    string `yaml:"gateway_url"`
+	Token         string `yaml:"token"`
+	AgentName     string `yaml:"agent_name"`
+	AutoReconnect bool   `yaml:"auto_reconnect"`
+	TLSCA         string `yaml:"tls_ca"`
+}
+
+const defaultGoal = `USE CHEAT MODE to setup the farm:
+1. cheat_mode_enable first
+3. cheat_clear_debris, cheat_cut_trees, cheat_mine_rocks
+4. cheat_hoe_all to till soil
+5. cheat_plant_seeds season appropriate seeds"
+6. cheat_grow_crops then cheat_harvest_all`
+
+// config is the active configuration; main replaces it after loading
+var config = DefaultConfig()
+
+// DefaultConfig returns the built-in settings used when nothing else is configured
+func DefaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			GameURL:   "ws://localhost:8765/game",
+			AutoStart: true,
+			Connection: ConnectionConfig{
+				ReconnectDelay:    1,
+				ReconnectMaxDelay: 30,
+				PingInterval:      15,
+				CommandTimeout:    15,
+				// The mod answers these only once the action finishes
+				CommandTimeouts: map[string]float64{
+					"move_to":                  60,
+					"use_tool_repeat":          60,
+					"hold_tool":                30,
+					"cheat_hoe_custom_pattern": 60,
+					"cheat_unlock_all":         60,
+				},
+				StateDeltas: true,
+				MaxInFlight: 1,
+			},
+		},
+		Remote: RemoteConfig{
+			Host: "127.0.0.1",
+			Port: 8765,
+		},
+		Agent: AgentConfig{
+			DefaultGoal: defaultGoal,
+			LLMTimeout:  120,
+			LLM: LLMConfig{
+				Backend: "copilot",
+				Model:   "gpt-4.1",
+				BaseURL: "https://api.openai.com/v1",
+			},
+			Behavior: BehaviorConfig{
+				LoopInterval:      0.25,
+				EmergencyEnergy:   10,
+				LowEnergy:         30,
+				SleepThreshold:    2200,
+				BedtimeThreshold:  2400,
+				CriticalThreshold: 2500,
+				Supervisor:        true,
+				LowHealth:         30,
+				MonsterDistance:   3,
+			},
+		},
+		OpenClaw: OpenClawConfig{
+			GatewayURL:    "ws://127.0.0.1:18789",
+			AgentName:     "stardew-mcp",
+			AutoReconnect: true,
+		},
+	}
+}
+
+// LoadConfig reads the YAML file over the defaults and then applies environment
+// overrides. A missing file is only an error when the path was given explicitly.
+func LoadConfig(path string, explicit bool) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+			}
+			warnRemovedKeys(path, data)
+		case os.IsNotExist(err) && !explicit:
+			// No config file - defaults and environment only
+		default:
+			return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// removedSettings are config keys and environment variables that are no
+// longer read, with what happens instead
+var removedSettings = []struct{ key, env, instead string }{
+	{"server.log_level", "STARDEW_MCP_LOG_LEVEL", "everything is logged"},
+	{"remote.cors_enabled", "STARDEW_MCP_CORS_ENABLED", "remote clients are accepted from any origin; use remote.auth tokens to restrict them"},
+	{"agent.behavior.max_retries", "STARDEW_MCP_MAX_RETRIES", "the agent retries a failed model call after 5 seconds"},
+}
+
+// warnRemovedKeys logs every removed key still set in a config file, which
+// the YAML decoder would otherwise skip silently
+func warnRemovedKeys(path string, data []byte) {
+	var doc map[string]interface{}
+	if yaml.Unmarshal(data, &doc) != nil {
+		return
+	}
+	for _, s := range removedSettings {
+		node := interface{}(doc)
+		for _, part := range strings.Split(s.key, ".") {
+			m, _ := node.(map[string]interface{})
+			node = m[part]
+		}
+		if node != nil {
+			log.Printf("[CONFIG] WARNING: %s in %s is no longer used and is ignored; %s", s.key, path, s.instead)
+		}
+	}
+}
+
+// applyEnv overrides settings from STARDEW_MCP_* environment variables
+func (c *Config) applyEnv() error {
+	// Documented gateway token variable, kept for compatibility
+	envString("OPENCLAW_GATEWAY_TOKEN", &c.OpenClaw.Token)
+	for _, s := range removedSettings {
+		if os.Getenv(s.env) != "" {
+			log.Printf("[CONFIG] WARNING: %s is no longer used and is ignored; %s", s.env, s.instead)
+		}
+	}
+
+	envString("STARDEW_MCP_GAME_URL", &c.Server.GameURL)
+	envString("STARDEW_MCP_GAME_TLS_CA", &c.Server.TLSCA)
+	envString("STARDEW_MCP_REMOTE_HOST", &c.Remote.Host)
+	envString("STARDEW_MCP_TOKENS_FILE", &c.Remote.Auth.TokensFile)
+	envString("STARDEW_MCP_TLS_CERT", &c.Remote.TLSCert)
+	envString("STARDEW_MCP_TLS_KEY", &c.Remote.TLSKey)
+	envString("STARDEW_MCP_TLS_CLIENT_CA", &c.Remote.TLSClientCA)
+	envString("STARDEW_MCP_GOAL", &c.Agent.DefaultGoal)
+	if v := os.Getenv("STARDEW_MCP_GOAL_DONE"); v != "" {
+		c.Agent.DefaultGoalDone = splitGoalConditions(v)
+	}
+	envString("STARDEW_MCP_GOAL_QUEUE_FILE", &c.Agent.GoalQueueFile)
+	envString("OPENAI_API_KEY", &c.Agent.LLM.APIKey)
+	envString("STARDEW_MCP_LLM_BACKEND", &c.Agent.LLM.Backend)
+	envString("STARDEW_MCP_LLM_MODEL", &c.Agent.LLM.Model)
+	envString("STARDEW_MCP_LLM_BASE_URL", &c.Agent.LLM.BaseURL)
+	envString("STARDEW_MCP_LLM_API_KEY", &c.Agent.LLM.APIKey)
+	envString("STARDEW_MCP_LLM_SCRIPT", &c.Agent.LLM.Script)
+	envString("STARDEW_MCP_OPENCLAW_URL", &c.OpenClaw.GatewayURL)
+	envString("STARDEW_MCP_OPENCLAW_TOKEN", &c.OpenClaw.Token)
+	envString("STARDEW_MCP_OPENCLAW_AGENT_NAME", &c.OpenClaw.AgentName)
+	envString("STARDEW_MCP_OPENCLAW_TLS_CA", &c.OpenClaw.TLSCA)
+
+	for _, err := range []error{
+		envBool("STARDEW_MCP_AUTO_START", &c.Server.AutoStart),
+		envFloat("STARDEW_MCP_RECONNECT_DELAY", &c.Server.Connection.ReconnectDelay),
+		envFloat("STARDEW_MCP_RECONNECT_MAX_DELAY", &c.Server.Connection.ReconnectMaxDelay),
+		envFloat("STARDEW_MCP_PING_INTERVAL", &c.Server.Connection.PingInterval),
+		envFloat("STARDEW_MCP_COMMAND_TIMEOUT", &c.Server.Connection.CommandTimeout),
+		envBool("STARDEW_MCP_STATE_DELTAS", &c.Server.Connection.StateDeltas),
+		envInt("STARDEW_MCP_MAX_IN_FLIGHT", &c.Server.Connection.MaxInFlight),
+		envInt("STARDEW_MCP_REMOTE_PORT", &c.Remote.Port),
+		envBool("STARDEW_MCP_REMOTE_RUN_AGENT", &c.Remote.RunAgent),
+		envFloat("STARDEW_MCP_LLM_TIMEOUT", &c.Agent.LLMTimeout),
+		envBool("STARDEW_MCP_CHEAT_MODE", &c.Agent.CheatMode),
+		envFloat("STARDEW_MCP_LOOP_INTERVAL", &c.Agent.Behavior.LoopInterval),
+		envFloat("STARDEW_MCP_EMERGENCY_ENERGY", &c.Agent.Behavior.EmergencyEnergy),
+		envFloat("STARDEW_MCP_LOW_ENERGY", &c.Agent.Behavior.LowEnergy),
+		envInt("STARDEW_MCP_SLEEP_THRESHOLD", &c.Agent.Behavior.SleepThreshold),
+		envInt("STARDEW_MCP_BEDTIME_THRESHOLD", &c.Agent.Behavior.BedtimeThreshold),
+		envInt("STARDEW_MCP_CRITICAL_THRESHOLD", &c.Agent.Behavior.CriticalThreshold),
+		envBool("STARDEW_MCP_SUPERVISOR", &c.Agent.Behavior.Supervisor),
+		envInt("STARDEW_MCP_LOW_HEALTH", &c.Agent.Behavior.LowHealth),
+		envInt("STARDEW_MCP_MONSTER_DISTANCE", &c.Agent.Behavior.MonsterDistance),
+		envBool("STARDEW_MCP_SUPERVISOR_CHEAT_WARP", &c.Agent.Behavior.SupervisorCheatWarp),
+		envBool("STARDEW_MCP_OPENCLAW_AUTO_RECONNECT", &c.OpenClaw.AutoReconnect),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c ConnectionConfig) ReconnectDelayDuration() time.Duration { return seconds(c.ReconnectDelay) }
+func (c ConnectionConfig) PingIntervalDuration() time.Duration   { return seconds(c.PingInterval) }
+func (c ConnectionConfig) CommandTimeoutDuration() time.Duration { return seconds(c.CommandTimeout) }
+func (c AgentConfig) LLMTimeoutDuration() time.Duration          { return seconds(c.LLMTimeout) }
+func (c BehaviorConfig) LoopIntervalDuration() time.Duration     { return seconds(c.LoopInterval) }
+
+func (c ConnectionConfig) ReconnectMaxDelayDuration() time.Duration {
+	return seconds(c.ReconnectMaxDelay)
+}
+
+// InstanceURLs returns the URL of every game instance, including the default one
+func (c ServerConfig) InstanceURLs() map[string]string {
+	urls := map[string]string{defaultInstance: c.GameURL}
+	for name, url := range c.Instances {
+		if name != defaultInstance && url != "" {
+			urls[name] = url
+		}
+	}
+	return urls
+}
+
+// CommandTimeoutFor returns how long to wait for the mod to answer action
+func (c ConnectionConfig) CommandTimeoutFor(action string) time.Duration {
+	if t, ok := c.CommandTimeouts[action]; ok && t > 0 {
+		return seconds(t)
+	}
+	return c.CommandTimeoutDuration()
+}
+
+// seconds converts a fractional number of seconds to a Duration
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+func envString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func envBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s=%q: %w", key, v, err)
+	}
+	*dst = b
+	return nil
+}
+
+func envInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s=%q: %w", key, v, err)
+	}
+	*dst = n
+	return nil
+}
+
+func envFloat(key string, dst *float64) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s=%q: %w", key, v, err)
+	}
+	*dst = f
+	return nil
+}
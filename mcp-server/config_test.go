@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigWarnsOnRemovedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`server:
+  log_level: "debug"
+remote:
+  cors_enabled: true
+agent:
+  cheat_mode: true
+  behavior:
+    loop_interval: 5
+`), 0644)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	cfg, err := LoadConfig(path, true)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !cfg.Agent.CheatMode || cfg.Agent.Behavior.LoopInterval != 5 {
+		t.Errorf("file values not kept: cheat_mode %v loop_interval %v", cfg.Agent.CheatMode, cfg.Agent.Behavior.LoopInterval)
+	}
+	for _, key := range []string{"server.log_level", "remote.cors_enabled"} {
+		if !strings.Contains(logs.String(), key) {
+			t.Errorf("no warning for %s in:\n%s", key, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "max_retries") {
+		t.Errorf("warned about a key the file does not set:\n%s", logs.String())
+	}
+}
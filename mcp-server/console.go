@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ============================================================================
+// Operator console (-tui) - runs the autonomous agent behind a full-screen
+// terminal UI: player stats, the 21x21 map the model sees, the current plan,
+// the goal queue and a scrolling feed of tool calls and results. The prompt
+// queues goals and pauses or resumes the agent. The log goes to
+// consoleLogFile since the console owns the terminal.
+// ============================================================================
+
+const (
+	consoleLogFile  = "stardew-mcp.log"
+	consoleMaxFeed  = 500 // feed entries kept for scrolling back
+	consoleLeftSize = 28  // inner width of the stats and map column
+)
+
+var (
+	consoleBorderColor = lipgloss.Color("62")
+	consoleTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
+	consoleDimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	consoleErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	consolePaneStyle   = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(consoleBorderColor).
+				Padding(0, 1)
+
+	// Feed entries are colored by kind
+	consoleFeedStyles = map[string]lipgloss.Style{
+		"tool":       lipgloss.NewStyle().Foreground(lipgloss.Color("86")),
+		"result":     lipgloss.NewStyle().Foreground(lipgloss.Color("250")),
+		"error":      lipgloss.NewStyle().Foreground(lipgloss.Color("203")),
+		"thought":    lipgloss.NewStyle().Foreground(lipgloss.Color("226")),
+		"plan":       lipgloss.NewStyle().Foreground(lipgloss.Color("82")),
+		"goal":       lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Bold(true),
+		"supervisor": lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true),
+	}
+)
+
+const consoleHelp = "<goal> [| done; conditions] · /next <goal> · /move <id> <pos> · /cancel <id> · /pause · /resume · /quit"
+
+// Messages from the game, the agent and the goal queue
+type (
+	consoleStateMsg *GameState
+	consoleEventMsg AgentEvent
+	consoleQueueMsg GoalQueueSnapshot
+)
+
+type consoleModel struct {
+	states <-chan *GameState
+	events <-chan AgentEvent
+
+	state  *GameState
+	plan   string
+	queue  GoalQueueSnapshot
+	feed   []AgentEvent
+	status string // outcome of the last prompt command
+
+	feedView viewport.Model
+	input    textinput.Model
+	width    int
+	height   int
+}
+
+// runConsole connects to the game, starts the agent on the goal queue and
+// shows the console until the operator quits
+func runConsole(goal Goal) error {
+	f, err := os.OpenFile(consoleLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+	log.SetOutput(f)
+
+	states, unsubscribeState := gameClient.SubscribeState()
+	defer unsubscribeState()
+	events, unsubscribeFeed := agentFeed.Subscribe()
+	defer unsubscribeFeed()
+
+	go func() {
+		gamePool.ConnectAll()
+		log.Println("Connected to Stardew Valley!")
+		startAgent(goal)
+	}()
+
+	_, err = tea.NewProgram(newConsoleModel(states, events), tea.WithAltScreen()).Run()
+	return err
+}
+
+func newConsoleModel(states <-chan *GameState, events <-chan AgentEvent) consoleModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "Type a goal to queue it, or /help"
+	input.CharLimit = 500
+	input.Focus()
+
+	return consoleModel{
+		states:   states,
+		events:   events,
+		queue:    goalQueue.Snapshot(),
+		status:   "Connecting to " + config.Server.GameURL + "... (log: " + consoleLogFile + ")",
+		feedView: viewport.New(0, 0),
+		input:    input,
+	}
+}
+
+func (m consoleModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.waitForState(), m.waitForEvent(), waitForQueue())
+}
+
+func (m consoleModel) waitForState() tea.Cmd {
+	return func() tea.Msg {
+		return consoleStateMsg(<-m.states)
+	}
+}
+
+func (m consoleModel) waitForEvent() tea.Cmd {
+	return func() tea.Msg {
+		return consoleEventMsg(<-m.events)
+	}
+}
+
+// waitForQueue reports the goal queue after its next change
+func waitForQueue() tea.Cmd {
+	changed := goalQueue.Changed()
+	return func() tea.Msg {
+		<-changed
+		return consoleQueueMsg(goalQueue.Snapshot())
+	}
+}
+
+func (m consoleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case consoleStateMsg:
+		if m.state == nil {
+			m.status = "Connected. " + consoleHelp
+		}
+		m.state = msg
+		return m, m.waitForState()
+
+	case consoleEventMsg:
+		if msg.Kind == "plan" {
+			m.plan = msg.Text
+		}
+		m.feed = append(m.feed, AgentEvent(msg))
+		if len(m.feed) > consoleMaxFeed {
+			m.feed = m.feed[len(m.feed)-consoleMaxFeed:]
+		}
+		m.renderFeed()
+		return m, m.waitForEvent()
+
+	case consoleQueueMsg:
+		m.queue = GoalQueueSnapshot(msg)
+		return m, waitForQueue()
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyEsc:
+			m.input.SetValue("")
+			return m, nil
+		case tea.KeyPgUp, tea.KeyPgDown:
+			var cmd tea.Cmd
+			m.feedView, cmd = m.feedView.Update(msg)
+			return m, cmd
+		case tea.KeyEnter:
+			line := strings.TrimSpace(m.input.Value())
+			m.input.SetValue("")
+			if line == "/quit" {
+				return m, tea.Quit
+			}
+			if line != "" {
+				m.status = consoleCommand(line)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// consoleCommand runs one line of the prompt and returns what happened
+func consoleCommand(line string) string {
+	ctx := withController(context.Background(), "console", "the operator console")
+	fields := strings.Fields(line)
+
+	var op, text string
+	var params GoalQueueParams
+	switch fields[0] {
+	case "/help":
+		return consoleHelp
+	case "/pause", "/resume":
+		op = strings.TrimPrefix(fields[0], "/")
+	case "/cancel":
+		if len(fields) != 2 {
+			return "Usage: /cancel <id>"
+		}
+		op = "cancel"
+		params.ID, _ = strconv.Atoi(fields[1])
+	case "/move":
+		if len(fields) != 3 {
+			return "Usage: /move <id> <position>"
+		}
+		op = "move"
+		params.ID, _ = strconv.Atoi(fields[1])
+		params.Position, _ = strconv.Atoi(fields[2])
+	case "/next":
+		op, text, params.Position = "enqueue", strings.TrimSpace(strings.TrimPrefix(line, "/next")), 1
+	default:
+		if strings.HasPrefix(line, "/") {
+			return "Unknown command " + fields[0] + ". " + consoleHelp
+		}
+		op, text = "enqueue", line
+	}
+
+	// "text | cond; cond" queues a goal with done conditions
+	if op == "enqueue" {
+		goal, done, _ := strings.Cut(text, "|")
+		params.Text = strings.TrimSpace(goal)
+		params.Done = splitGoalConditions(done)
+	}
+
+	result, err := goalQueueOp(ctx, op, params)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if g, ok := result.(QueuedGoal); ok {
+		return fmt.Sprintf("Queued goal %d", g.ID)
+	}
+	switch op {
+	case "pause":
+		return "Paused; the agent stops after its current step"
+	case "resume":
+		return "Resumed"
+	case "cancel":
+		return fmt.Sprintf("Cancelled goal %d", params.ID)
+	default:
+		return fmt.Sprintf("Moved goal %d to position %d", params.ID, params.Position)
+	}
+}
+
+// Pane sizes: the stats and map column on the left, plan, goals and feed on
+// the right, the prompt and status line at the bottom
+const (
+	consolePlanLines  = 3
+	consoleGoalLines  = 5
+	consoleInputLines = 4 // bordered prompt and the status line
+)
+
+func (m *consoleModel) rightWidth() int {
+	return max(m.width-(consoleLeftSize+4)-4, 20)
+}
+
+func (m *consoleModel) resize() {
+	feedHeight := m.height - consoleInputLines - (consolePlanLines + 3) - (consoleGoalLines + 3) - 3
+	m.feedView.Width = m.rightWidth()
+	m.feedView.Height = max(feedHeight, 3)
+	m.input.Width = max(m.width-8, 10)
+	m.renderFeed()
+}
+
+// renderFeed lays out the feed for the current width, following new entries
+// unless the operator scrolled back
+func (m *consoleModel) renderFeed() {
+	width := m.feedView.Width
+	if width <= 0 {
+		return
+	}
+	follow := m.feedView.AtBottom()
+	lines := make([]string, 0, len(m.feed))
+	for _, e := range m.feed {
+		text := e.Text
+		if e.Kind == "result" && len(text) > 300 {
+			text = text[:300] + "..."
+		}
+		line := e.Time.Format("15:04:05") + " " + consoleFeedMarker(e.Kind) + " " + text
+		lines = append(lines, consoleFeedStyles[e.Kind].Width(width).Render(line))
+	}
+	m.feedView.SetContent(strings.Join(lines, "\n"))
+	if follow {
+		m.feedView.GotoBottom()
+	}
+}
+
+func consoleFeedMarker(kind string) string {
+	switch kind {
+	case "tool":
+		return "->"
+	case "result":
+		return "<-"
+	case "error":
+		return "!!"
+	case "thought":
+		return "**"
+	default:
+		return "=="
+	}
+}
+
+func (m consoleModel) View() string {
+	if m.width == 0 {
+		return "Starting..."
+	}
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		consolePane("Player", m.statsView(), consoleLeftSize, 0),
+		consolePane("Map", m.mapView(), consoleLeftSize, 21),
+	)
+
+	width := m.rightWidth()
+	queueTitle := "Goals"
+	if m.queue.Paused {
+		queueTitle += " (paused)"
+	}
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		consolePane("Plan", clipLines(lipgloss.NewStyle().Width(width).Render(m.plan), consolePlanLines), width, consolePlanLines),
+		consolePane(queueTitle, m.queueView(width), width, consoleGoalLines),
+		consolePane("Feed (PgUp/PgDn)", m.feedView.View(), width, m.feedView.Height),
+	)
+
+	prompt := consolePaneStyle.Width(m.width - 2).Render(m.input.View())
+	status := m.status
+	if strings.HasPrefix(status, "Error") {
+		status = consoleErrorStyle.Render(status)
+	} else {
+		status = consoleDimStyle.Render(status)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, left, right),
+		prompt,
+		lipgloss.NewStyle().MaxWidth(m.width).Render(clipLines(status, 1)),
+	)
+}
+
+// consolePane draws a titled, bordered pane; height 0 fits the content
+func consolePane(title, content string, width, height int) string {
+	body := consoleTitleStyle.Render(title) + "\n" + content
+	style := consolePaneStyle.Width(width + 2)
+	if height > 0 {
+		style = style.Height(height + 1)
+		body = clipLines(body, height+1)
+	}
+	return style.Render(body)
+}
+
+func (m consoleModel) statsView() string {
+	s := m.state
+	if s == nil {
+		return consoleDimStyle.Render("Waiting for the game...")
+	}
+	rows := [][2]string{
+		{"Farmer", s.Player.Name},
+		{"Date", fmt.Sprintf("%s %d, Y%d", s.Time.Season, s.Time.Day, s.Time.Year)},
+		{"Time", s.Time.TimeString + " " + s.World.Weather},
+		{"Place", fmt.Sprintf("%s (%d,%d)", s.Player.Location, s.Player.X, s.Player.Y)},
+		{"Energy", consoleBar(s.Player.Energy, float64(s.Player.MaxEnergy))},
+		{"Health", consoleBar(float64(s.Player.Health), float64(s.Player.MaxHealth))},
+		{"Money", fmt.Sprintf("%dg", s.Player.Money)},
+		{"Tool", s.Player.CurrentTool},
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = consoleDimStyle.Render(fmt.Sprintf("%-7s", row[0])) + row[1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// consoleBar draws value out of total as a 10-cell bar with the numbers
+func consoleBar(value, total float64) string {
+	filled := 0
+	if total > 0 {
+		filled = min(max(int(value/total*10+0.5), 0), 10)
+	}
+	return strings.Repeat("#", filled) + strings.Repeat(".", 10-filled) + fmt.Sprintf(" %.0f/%.0f", value, total)
+}
+
+func (m consoleModel) mapView() string {
+	if m.state == nil || m.state.Surroundings.AsciiMap == "" {
+		return consoleDimStyle.Render("No map yet")
+	}
+	return strings.TrimRight(centerMap(m.state.Surroundings.AsciiMap), "\n")
+}
+
+func (m consoleModel) queueView(width int) string {
+	if len(m.queue.Goals) == 0 {
+		return consoleDimStyle.Render("Queue empty - type a goal below")
+	}
+	lines := make([]string, 0, consoleGoalLines)
+	for i, g := range m.queue.Goals {
+		if i == consoleGoalLines-1 && len(m.queue.Goals) > consoleGoalLines {
+			lines = append(lines, consoleDimStyle.Render(fmt.Sprintf("... %d more", len(m.queue.Goals)-i)))
+			break
+		}
+		line := fmt.Sprintf("%d. [%d] %s", i+1, g.ID, g.Text)
+		if len(g.Done) > 0 {
+			line += consoleDimStyle.Render(" (" + strings.Join(g.Done, "; ") + ")")
+		}
+		lines = append(lines, clipLines(lipgloss.NewStyle().Width(width).Render(line), 1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clipLines keeps the first n lines of s
+func clipLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
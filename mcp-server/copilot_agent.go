@@ -1,14 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
 	"sync"
 	"time"
-
-	copilot "github.com/github/copilot-sdk/go"
 )
 
 // Embedded game knowledge - no external file dependency
@@ -61,6 +60,12 @@ const gameKnowledge = `# Stardew Valley AI Agent: High-Intelligence Protocol
 - **IsMoving Error?**: Movement is now BLOCKING. If a move tool finishes, you are at your destination. Do not issue 10 move commands in a row; wait for each.
 - **Cleaning Goals**: Don't just swing randomly. Find a target, move to it, clear it, move to the next.
 
+## GOAL STATUS
+
+- Call **report_goal_status** with status "complete" when the goal is done, "blocked" when you cannot make progress, or "in_progress" to check the goal's DONE WHEN conditions.
+- Goals with DONE WHEN conditions only count as complete once the game state meets every condition; the tool tells you which ones still fail.
+- Once a goal is complete you move on to the next goal in the queue (**get_goal_queue**). Only add, move or cancel goals when the player asks you to.
+
 ## SURVIVAL & NIGHT
 
 - **2:00 AM** is a hard game-over. You MUST be in bed by **1:00 AM**.
@@ -97,6 +102,8 @@ Cheat mode provides instant, god-mode capabilities. **You must call cheat_mode_e
 - **cheat_dig_artifacts**: Instantly dig ALL artifact spots, collect artifacts/clay/geodes
 - **cheat_plant_seeds**: Instantly plant seeds on ALL empty hoed tiles (requires seedId parameter)
 - **cheat_fertilize_all**: Apply fertilizer to ALL hoed tiles (optional fertilizerId parameter)
+- Cut trees, mined rocks and lost crops cannot be brought back. Pass dryRun=true to cheat_cut_trees, cheat_mine_rocks, cheat_clear_tiles or cheat_hoe_custom_pattern to see the affected tiles on your map first.
+- **undo_last** / **undo**: Revert a tile-changing cheat near you (un-hoe dirt, re-hoe cleared dirt, return removed placed items to inventory). Results list what could not be reverted; **get_undo_journal** shows the command ids
 
 ### Mining Automation
 - **cheat_instant_mine**: Mine ALL ore nodes in current mine level, drops go to inventory
@@ -258,590 +265,159 @@ Grid: .#####.\n#.....#\n#.#.#.#\n#.....#\n#.###.#\n#.....#\n.#####.
 - 745: Ancient Seeds (Spring, Summer, Fall)
 `
 
-// StardewAgent manages the autonomous AI session using GitHub Copilot SDK
+// StardewAgent manages the autonomous AI session on a pluggable LLM backend
 type StardewAgent struct {
-	client      *copilot.Client
-	session     *copilot.Session
-	currentPlan string
-	toolMutex   sync.Mutex // Prevents concurrent tool execution
+	backend    LLMBackend
+	model      string
+	client     *GameClient // game the agent plays
+	queue      *GoalQueue  // goals the agent works through
+	session    LLMSession
+	toolMutex  sync.Mutex    // Prevents concurrent tool execution
+	supervisor *Supervisor   // Handles survival emergencies; nil when disabled
+	done       chan struct{} // closed once the loop and supervisor stopped
+
+	goalMu       sync.Mutex // guards the goal fields and currentPlan
+	goalID       int        // id of goal in the goal queue
+	goal         Goal
+	goalReported bool // the model reported a goal without conditions complete
+	currentPlan  string
 }
 
-// NewStardewAgent creates a new Stardew agent using Copilot SDK
-func NewStardewAgent() (*StardewAgent, error) {
-	log.Printf("[AGENT] Creating GitHub Copilot SDK agent")
-
-	// Create client with default options
-	client := copilot.NewClient(nil)
-	if err := client.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start copilot client: %w", err)
+// NewStardewAgent creates a new Stardew agent on the backend and model selected
+// by cfg that plays client and works through queue
+func NewStardewAgent(cfg LLMConfig, client *GameClient, queue *GoalQueue) (*StardewAgent, error) {
+	backend, err := NewLLMBackend(cfg)
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("[AGENT] Using %s LLM backend with model %s", backend.Name(), cfg.Model)
 
 	return &StardewAgent{
-		client: client,
+		backend: backend,
+		model:   cfg.Model,
+		client:  client,
+		queue:   queue,
 	}, nil
 }
 
-func (a *StardewAgent) StartSession(initialGoal string) error {
-	log.Printf("[AGENT AGENT] Session started with goal: %s", initialGoal)
-
-	// Define tools inline (matches original implementation pattern)
-	moveToTool := copilot.DefineTool("move_to", "Move to a WALKABLE tile. This tool BLOCKS until arrival.",
-		func(params MoveToParams, inv copilot.ToolInvocation) (string, error) {
-			return a.handleMoveTo(params.X, params.Y)
-		})
-
-	getSurroundingsTool := copilot.DefineTool("get_surroundings", "Refresh vision to see 61x61 area coordinates.",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			state := gameClient.GetState()
-			if state == nil {
-				return "Disconnected", nil
-			}
-			return a.formatGameStateContext(state), nil
-		})
-
-	interactTool := copilot.DefineTool("interact", "Interact with tile in front",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("interact", nil)
-			return resp.Message, nil
-		})
-
-	useToolTool := copilot.DefineTool("use_tool", "Use tool once",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("use_tool", nil)
-			return resp.Message, nil
-		})
-
-	useToolRepeatTool := copilot.DefineTool("use_tool_repeat", "Execute tool multiple times",
-		func(params CountParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("use_tool_repeat", map[string]interface{}{"count": params.Count})
-			return resp.Message, nil
-		})
-
-	faceDirectionTool := copilot.DefineTool("face_direction", "Turn character to face direction",
-		func(params DirectionParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("face_direction", map[string]interface{}{"direction": params.Direction})
-			return resp.Message, nil
-		})
-
-	selectItemTool := copilot.DefineTool("select_item", "Find and equip item by name",
-		func(params NameParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("select_item", map[string]interface{}{"name": params.Name})
-			return resp.Message, nil
-		})
-
-	switchToolTool := copilot.DefineTool("switch_tool", "Equip inventory slot",
-		func(params SlotParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("switch_tool", map[string]interface{}{"slot": params.Slot})
-			return resp.Message, nil
-		})
-
-	eatItemTool := copilot.DefineTool("eat_item", "Eat food from inventory",
-		func(params SlotParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("eat_item", map[string]interface{}{"slot": params.Slot})
-			return resp.Message, nil
-		})
-
-	enterDoorTool := copilot.DefineTool("enter_door", "Enter door/warp point in front of player",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("enter_door", nil)
-			return resp.Message, nil
-		})
-
-	findBestTargetTool := copilot.DefineTool("find_best_target", "Find nearest target of specified type with walkable approach tile",
-		func(params TargetTypeParams, inv copilot.ToolInvocation) (string, error) {
-			state := gameClient.GetState()
-			if state == nil {
-				return "Game disconnected", nil
-			}
-			return a.findBestTarget(state, params.TargetType), nil
-		})
-
-	clearTargetTool := copilot.DefineTool("clear_target", "Find and clear the nearest target automatically (does select_item + move_to + face + use_tool in one call)",
-		func(params TargetTypeParams, inv copilot.ToolInvocation) (string, error) {
-			return a.clearTarget(params.TargetType)
-		})
-
-	// ========== CHEAT MODE TOOLS ==========
-	// These tools require cheat_mode_enable to be called first
-
-	cheatEnableTool := copilot.DefineTool("cheat_mode_enable", "Enable cheat mode. Required before using other cheat commands.",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_mode_enable")
-			resp, _ := gameClient.SendCommand("cheat_mode_enable", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatDisableTool := copilot.DefineTool("cheat_mode_disable", "Disable cheat mode",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_mode_disable")
-			resp, _ := gameClient.SendCommand("cheat_mode_disable", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatWarpTool := copilot.DefineTool("cheat_warp", "Instantly teleport to any location (Farm, Town, Mountain, Beach, Forest, Mine, etc.)",
-		func(params CheatWarpParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_warp -> %s", params.Location)
-			p := map[string]interface{}{"location": params.Location}
-			if params.X != 0 {
-				p["x"] = params.X
-			}
-			if params.Y != 0 {
-				p["y"] = params.Y
-			}
-			resp, _ := gameClient.SendCommand("cheat_warp", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatSetMoneyTool := copilot.DefineTool("cheat_set_money", "Set player's gold amount",
-		func(params CheatSetMoneyParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_set_money", map[string]interface{}{"amount": params.Amount})
-			return resp.Message, nil
-		})
-
-	cheatAddItemTool := copilot.DefineTool("cheat_add_item", "Add any item to inventory by ID (e.g., '(O)465' for seeds)",
-		func(params CheatAddItemParams, inv copilot.ToolInvocation) (string, error) {
-			p := map[string]interface{}{"itemId": params.ItemID}
-			if params.Count > 0 {
-				p["count"] = params.Count
-			}
-			if params.Quality > 0 {
-				p["quality"] = params.Quality
-			}
-			resp, _ := gameClient.SendCommand("cheat_add_item", p)
-			return resp.Message, nil
-		})
-
-	cheatSetEnergyTool := copilot.DefineTool("cheat_set_energy", "Restore stamina to max (or specific amount)",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_set_energy", nil)
-			return resp.Message, nil
-		})
-
-	cheatSetHealthTool := copilot.DefineTool("cheat_set_health", "Restore health to max (or specific amount)",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_set_health", nil)
-			return resp.Message, nil
-		})
-
-	cheatSetFriendshipTool := copilot.DefineTool("cheat_set_friendship", "Instantly set friendship with any NPC (hearts or points)",
-		func(params CheatSetFriendshipParams, inv copilot.ToolInvocation) (string, error) {
-			p := map[string]interface{}{"npcName": params.NPCName}
-			if params.Hearts > 0 {
-				p["hearts"] = params.Hearts
-			} else if params.Points > 0 {
-				p["points"] = params.Points
-			} else {
-				p["hearts"] = 10 // default to max
-			}
-			resp, _ := gameClient.SendCommand("cheat_set_friendship", p)
-			return resp.Message, nil
-		})
-
-	cheatMaxFriendshipsTool := copilot.DefineTool("cheat_max_all_friendships", "Max out friendship with ALL NPCs at once",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_max_all_friendships", nil)
-			return resp.Message, nil
-		})
-
-	cheatHarvestAllTool := copilot.DefineTool("cheat_harvest_all", "Instantly harvest all ready crops in current location",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_harvest_all")
-			resp, _ := gameClient.SendCommand("cheat_harvest_all", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatWaterAllTool := copilot.DefineTool("cheat_water_all", "Instantly water all soil in current location",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_water_all")
-			resp, _ := gameClient.SendCommand("cheat_water_all", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatGrowCropsTool := copilot.DefineTool("cheat_grow_crops", "Instantly grow all crops to harvest-ready",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_grow_crops")
-			resp, _ := gameClient.SendCommand("cheat_grow_crops", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatClearDebrisTool := copilot.DefineTool("cheat_clear_debris", "Remove all weeds, stones, twigs, grass in current location",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_clear_debris")
-			resp, _ := gameClient.SendCommand("cheat_clear_debris", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatMineWarpTool := copilot.DefineTool("cheat_mine_warp", "Warp directly to specific mine level (1-120 Mines, 121+ Skull Cavern)",
-		func(params CheatMineWarpParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_mine_warp -> level %d", params.Level)
-			resp, _ := gameClient.SendCommand("cheat_mine_warp", map[string]interface{}{"level": params.Level})
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatSpawnOresTool := copilot.DefineTool("cheat_spawn_ores", "Add ores directly to inventory (copper, iron, gold, iridium, coal)",
-		func(params CheatSpawnOresParams, inv copilot.ToolInvocation) (string, error) {
-			p := map[string]interface{}{"oreType": params.OreType}
-			if params.Count > 0 {
-				p["count"] = params.Count
-			}
-			resp, _ := gameClient.SendCommand("cheat_spawn_ores", p)
-			return resp.Message, nil
-		})
-
-	cheatCollectForageTool := copilot.DefineTool("cheat_collect_all_forage", "Instantly collect all forage items in current location",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_collect_all_forage", nil)
-			return resp.Message, nil
-		})
-
-	cheatInstantMineTool := copilot.DefineTool("cheat_instant_mine", "Mine ALL ore nodes in current mine level instantly",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_instant_mine", nil)
-			return resp.Message, nil
-		})
-
-	cheatTimeSetTool := copilot.DefineTool("cheat_time_set", "Set the game time (600=6AM, 1200=noon, 1800=6PM, 2400=midnight)",
-		func(params CheatTimeSetParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_time_set", map[string]interface{}{"time": params.Time})
-			return resp.Message, nil
-		})
-
-	cheatTimeFreezeTool := copilot.DefineTool("cheat_time_freeze", "Toggle time freeze on/off",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			resp, _ := gameClient.SendCommand("cheat_time_freeze", nil)
-			return resp.Message, nil
-		})
-
-	cheatInfiniteEnergyTool := copilot.DefineTool("cheat_infinite_energy", "Toggle infinite stamina on/off",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_infinite_energy")
-			resp, _ := gameClient.SendCommand("cheat_infinite_energy", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatUnlockRecipesTool := copilot.DefineTool("cheat_unlock_recipes", "Unlock ALL crafting and cooking recipes",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_unlock_recipes")
-			resp, _ := gameClient.SendCommand("cheat_unlock_recipes", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatPetAnimalsTool := copilot.DefineTool("cheat_pet_all_animals", "Pet ALL farm animals instantly",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_pet_all_animals")
-			resp, _ := gameClient.SendCommand("cheat_pet_all_animals", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatCompleteQuestTool := copilot.DefineTool("cheat_complete_quest", "Complete active quests instantly",
-		func(params CheatCompleteQuestParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_complete_quest")
-			p := map[string]interface{}{}
-			if params.QuestID != "" {
-				p["questId"] = params.QuestID
-			}
-			resp, _ := gameClient.SendCommand("cheat_complete_quest", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatGiveGiftTool := copilot.DefineTool("cheat_give_gift", "Give a gift to an NPC instantly (for friendship)",
-		func(params CheatGiveGiftParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_give_gift -> %s", params.NPCName)
-			resp, _ := gameClient.SendCommand("cheat_give_gift", map[string]interface{}{
-				"npcName": params.NPCName,
-				"itemId":  params.ItemID,
-			})
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	// ========== NEW FARMING CHEAT TOOLS ==========
-
-	cheatHoeAllTool := copilot.DefineTool("cheat_hoe_all", "Instantly hoe/till all diggable tiles in current location",
-		func(params CheatHoeAllParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_hoe_all")
-			p := map[string]interface{}{}
-			if params.Radius > 0 {
-				p["radius"] = params.Radius
-			}
-			resp, _ := gameClient.SendCommand("cheat_hoe_all", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatCutTreesTool := copilot.DefineTool("cheat_cut_trees", "Instantly cut/chop ALL trees in current location, collect wood/hardwood",
-		func(params CheatCutTreesParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_cut_trees")
-			p := map[string]interface{}{}
-			if !params.IncludeStumps {
-				p["includeStumps"] = "false"
-			}
-			resp, _ := gameClient.SendCommand("cheat_cut_trees", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatMineRocksTool := copilot.DefineTool("cheat_mine_rocks", "Instantly mine ALL rocks/stones/boulders in current location, collect ores",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_mine_rocks")
-			resp, _ := gameClient.SendCommand("cheat_mine_rocks", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatDigArtifactsTool := copilot.DefineTool("cheat_dig_artifacts", "Instantly dig up ALL artifact spots in current location",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_dig_artifacts")
-			resp, _ := gameClient.SendCommand("cheat_dig_artifacts", nil)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatPlantSeedsTool := copilot.DefineTool("cheat_plant_seeds", "Instantly plant seeds on ALL empty hoed tiles",
-		func(params CheatPlantSeedsParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_plant_seeds -> %s", params.SeedID)
-			resp, _ := gameClient.SendCommand("cheat_plant_seeds", map[string]interface{}{
-				"seedId": params.SeedID,
-			})
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatFertilizeAllTool := copilot.DefineTool("cheat_fertilize_all", "Apply fertilizer to ALL hoed tiles",
-		func(params CheatFertilizeAllParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_fertilize_all")
-			p := map[string]interface{}{}
-			if params.FertilizerID != "" {
-				p["fertilizerId"] = params.FertilizerID
-			}
-			resp, _ := gameClient.SendCommand("cheat_fertilize_all", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	// Inventory & upgrade cheat tools
-	cheatUpgradeBackpackTool := copilot.DefineTool("cheat_upgrade_backpack", "Upgrade backpack to larger size (12, 24, or 36 slots). Default: 36 (max)",
-		func(params CheatUpgradeBackpackParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_upgrade_backpack -> size=%d", params.Size)
-			p := map[string]interface{}{}
-			if params.Size > 0 {
-				p["size"] = params.Size
-			}
-			resp, _ := gameClient.SendCommand("cheat_upgrade_backpack", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatUpgradeToolTool := copilot.DefineTool("cheat_upgrade_tool", "Upgrade a specific tool to higher level. Levels: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium",
-		func(params CheatUpgradeToolParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_upgrade_tool -> %s level=%d", params.Tool, params.Level)
-			p := map[string]interface{}{
-				"tool": params.Tool,
-			}
-			if params.Level >= 0 {
-				p["level"] = params.Level
-			}
-			resp, _ := gameClient.SendCommand("cheat_upgrade_tool", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatUpgradeAllToolsTool := copilot.DefineTool("cheat_upgrade_all_tools", "Upgrade ALL tools to specified level. Default: 4 (Iridium)",
-		func(params CheatUpgradeAllToolsParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_upgrade_all_tools -> level=%d", params.Level)
-			p := map[string]interface{}{}
-			if params.Level >= 0 {
-				p["level"] = params.Level
-			}
-			resp, _ := gameClient.SendCommand("cheat_upgrade_all_tools", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatUnlockAllTool := copilot.DefineTool("cheat_unlock_all", "UNLOCK EVERYTHING: Max backpack, all tools to iridium, all recipes, all skills to level 10, all special items",
-		func(params NoParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_unlock_all")
-			resp, _ := gameClient.SendCommand("cheat_unlock_all", map[string]interface{}{})
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	// ========== TARGETED/SELECTIVE CHEAT TOOLS (for precise control like drawing shapes) ==========
-
-	cheatHoeTilesTool := copilot.DefineTool("cheat_hoe_tiles", "Hoe SPECIFIC tiles by coordinates. Perfect for drawing shapes/patterns. Use tiles='x,y;x,y' format or single x,y params.",
-		func(params CheatHoeTilesParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_hoe_tiles tiles=%s x=%d y=%d", params.Tiles, params.X, params.Y)
-			p := map[string]interface{}{}
-			if params.Tiles != "" {
-				p["tiles"] = params.Tiles
-			}
-			if params.X != 0 || params.Y != 0 {
-				p["x"] = params.X
-				p["y"] = params.Y
-			}
-			resp, _ := gameClient.SendCommand("cheat_hoe_tiles", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	cheatClearTilesTool := copilot.DefineTool("cheat_clear_tiles", "Clear SPECIFIC tiles (objects, terrain, hoed dirt). Use tiles='x,y;x,y' format or single x,y params.",
-		func(params CheatClearTilesParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_clear_tiles tiles=%s x=%d y=%d", params.Tiles, params.X, params.Y)
-			p := map[string]interface{}{}
-			if params.Tiles != "" {
-				p["tiles"] = params.Tiles
-			}
-			if params.X != 0 || params.Y != 0 {
-				p["x"] = params.X
-				p["y"] = params.Y
-			}
-			// Only include these if explicitly set to false
-			if !params.ClearObjects {
-				p["clearObjects"] = "false"
-			}
-			if !params.ClearFeatures {
-				p["clearFeatures"] = "false"
-			}
-			if !params.ClearDirt {
-				p["clearDirt"] = "false"
-			}
-			resp, _ := gameClient.SendCommand("cheat_clear_tiles", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
-
-	// cheatTillPatternTool removed - AI should design its own patterns using cheatHoeCustomPatternTool
-	// The preset patterns were too rigid; letting the AI think about tiles produces better results
-	_ = copilot.DefineTool("cheat_till_pattern_UNUSED", "UNUSED",
-		func(params CheatTillPatternParams, inv copilot.ToolInvocation) (string, error) {
-			return "This tool is disabled", nil
-		})
-
-	cheatHoeCustomPatternTool := copilot.DefineTool("cheat_hoe_custom_pattern",
-		`Draw ANY shape/pattern by hoeing specific tiles. YOU must design the pattern!
-
-HOW TO USE:
-1. Think about what shape you want (heart, star, letter, etc.)
-2. Design it as an ASCII grid where '#' = hoe this tile, '.' = skip
-3. Pass the grid string with \n for newlines
-
-EXAMPLE - Heart shape:
-grid=".##.##.\n#######\n#######\n.#####.\n..###..\n...#..."
-
-EXAMPLE - Letter A:
-grid="..#..\n.#.#.\n#####\n#...#\n#...#"
+// agentContext identifies the in-process agent as a controller for the control
+// lease. Its commands are background work that explicit commands preempt.
+func agentContext(ctx context.Context) context.Context {
+	ctx = withController(ctx, "agent", "the in-process agent")
+	return withPriority(ctx, PriorityBackground)
+}
 
-EXAMPLE - Star:
-grid="..#..\n..#..\n#####\n.#.#.\n#...#"
-
-The pattern will be centered at your position (or x,y if specified).
-Surrounding area is auto-cleared so pattern is visible.`,
-		func(params CheatHoeCustomPatternParams, inv copilot.ToolInvocation) (string, error) {
-			log.Printf("[TOOL CALL] cheat_hoe_custom_pattern x=%d y=%d grid=%q offsets=%q clearArea=%v",
-				params.X, params.Y, params.Grid, params.OffsetString, params.ClearArea)
-			p := map[string]interface{}{}
-			if params.X != 0 {
-				p["x"] = params.X
-			}
-			if params.Y != 0 {
-				p["y"] = params.Y
-			}
-			if params.Grid != "" {
-				p["grid"] = params.Grid
-			}
-			if params.OffsetString != "" {
-				p["offsetString"] = params.OffsetString
-			}
-			if params.ClearRadius > 0 {
-				p["clearRadius"] = params.ClearRadius
-			}
-			// clearArea defaults to true, only send if explicitly false
-			if !params.ClearArea {
-				p["clearArea"] = "false"
-			}
-			resp, _ := gameClient.SendCommand("cheat_hoe_custom_pattern", p)
-			log.Printf("[TOOL RESULT] %s", resp.Message)
-			return resp.Message, nil
-		})
+// StartSession starts the autonomous loop, which works through the agent's
+// goal queue until ctx is cancelled
+func (a *StardewAgent) StartSession(ctx context.Context) error {
+	log.Printf("[AGENT AGENT] Session started with %d queued goal(s)", a.queue.Len())
 
 	// Create session with tools (using embedded knowledge)
-	session, err := a.client.CreateSession(&copilot.SessionConfig{
-		Model: "gpt-4.1",
-		SystemMessage: &copilot.SystemMessageConfig{
-			Content: gameKnowledge,
-		},
-		Tools: []copilot.Tool{
-			// Standard gameplay tools
-			moveToTool, getSurroundingsTool, interactTool, useToolTool,
-			useToolRepeatTool, faceDirectionTool, selectItemTool, switchToolTool,
-			eatItemTool, enterDoorTool, findBestTargetTool, clearTargetTool,
-			// Cheat mode tools
-			cheatEnableTool, cheatDisableTool, cheatWarpTool, cheatSetMoneyTool,
-			cheatAddItemTool, cheatSetEnergyTool, cheatSetHealthTool,
-			cheatSetFriendshipTool, cheatMaxFriendshipsTool,
-			cheatHarvestAllTool, cheatWaterAllTool, cheatGrowCropsTool, cheatClearDebrisTool,
-			cheatMineWarpTool, cheatSpawnOresTool, cheatCollectForageTool, cheatInstantMineTool,
-			cheatTimeSetTool, cheatTimeFreezeTool, cheatInfiniteEnergyTool,
-			cheatUnlockRecipesTool, cheatPetAnimalsTool, cheatCompleteQuestTool, cheatGiveGiftTool,
-			// New farming cheat tools
-			cheatHoeAllTool, cheatCutTreesTool, cheatMineRocksTool, cheatDigArtifactsTool,
-			cheatPlantSeedsTool, cheatFertilizeAllTool,
-			// Inventory & upgrade cheat tools
-			cheatUpgradeBackpackTool, cheatUpgradeToolTool, cheatUpgradeAllToolsTool, cheatUnlockAllTool,
-			// Targeted/selective cheat tools (for precise control like drawing shapes)
-			cheatHoeTilesTool, cheatClearTilesTool, cheatHoeCustomPatternTool,
-			// Note: cheatTillPatternTool removed - AI should design its own patterns using cheatHoeCustomPatternTool
-		},
+	session, err := a.backend.CreateSession(SessionOptions{
+		Model:        a.model,
+		SystemPrompt: gameKnowledge,
+		Tools:        a.llmTools(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 	a.session = session
 
-	go a.runAutonomousLoop(initialGoal)
+	if config.Agent.CheatMode {
+		if resp, err := a.client.SendCommandContext(agentContext(ctx), "cheat_mode_enable", nil); err != nil {
+			log.Printf("[AGENT] Failed to enable cheat mode: %v", err)
+		} else {
+			log.Printf("[AGENT] Cheat mode: %s", resp.Message)
+		}
+	}
+
+	a.done = make(chan struct{})
+	var wg sync.WaitGroup
+	if config.Agent.Behavior.Supervisor {
+		a.supervisor = NewSupervisor(a, a.client)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.supervisor.Run(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runAutonomousLoop(ctx)
+	}()
+	go func() {
+		wg.Wait()
+		close(a.done)
+	}()
 	return nil
 }
 
-func (a *StardewAgent) runAutonomousLoop(goal string) {
-	a.currentPlan = "Initializing..."
+// Done is closed once a started session stopped
+func (a *StardewAgent) Done() <-chan struct{} {
+	return a.done
+}
+
+// pause waits for d unless ctx is done first
+func pause(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+func (a *StardewAgent) runAutonomousLoop(ctx context.Context) {
+	defer a.session.Close()
+	a.setPlan("Initializing...")
 	consecutiveErrors := 0
-	goalCompleted := false
 	iteration := 0
 
 	log.Printf("[AGENT LOOP] Starting autonomous loop...")
 
-	for {
+	for ctx.Err() == nil {
 		iteration++
 
-		// Stop if goal was completed
-		if goalCompleted {
-			log.Printf("[AGENT LOOP] Goal completed! Stopping autonomous loop.")
-			time.Sleep(30 * time.Second) // Wait before potentially starting new goal
-			goalCompleted = false        // Reset for next iteration
+		// The supervisor has control during emergencies; resume once it is done
+		if a.supervisor != nil && a.supervisor.Active() != "" {
+			log.Printf("[AGENT LOOP] Paused while the survival supervisor handles: %s", a.supervisor.Active())
+			if a.supervisor.WaitIdle(ctx) != nil {
+				continue
+			}
+		}
+
+		// Work on the head of the goal queue; wait while it is empty or paused
+		queueChanged := a.queue.Changed()
+		queued, ok := a.queue.Head()
+		if !ok {
+			log.Printf("[AGENT LOOP] No goal to work on (queue empty or paused), waiting...")
+			select {
+			case <-queueChanged:
+			case <-ctx.Done():
+			}
+			continue
+		}
+		if queued.ID != a.goalQueueID() {
+			a.setGoal(queued.ID, queued.Goal())
+			log.Printf("[AGENT LOOP] Working on goal %d: %s", queued.ID, queued.Text)
+			agentFeed.Publish("goal", "Working on goal %d: %s", queued.ID, queued.Text)
+			if len(queued.Done) > 0 {
+				log.Printf("[AGENT LOOP] Goal is done when: %s", strings.Join(queued.Done, "; "))
+			}
 		}
 
 		log.Printf("[AGENT LOOP] Iteration %d - Getting game state...", iteration)
 
-		state := gameClient.GetState()
+		state := a.client.GetState()
 		if state == nil {
 			log.Printf("[AGENT LOOP] Game state is nil, waiting...")
-			time.Sleep(2 * time.Second)
+			pause(ctx, 2*time.Second)
 			consecutiveErrors++
 			if consecutiveErrors > 10 {
 				log.Printf("[AGENT AGENT] Too many connection errors, pausing...")
-				time.Sleep(10 * time.Second)
+				pause(ctx, 10*time.Second)
 			}
 			continue
 		}
@@ -851,34 +427,51 @@ func (a *StardewAgent) runAutonomousLoop(goal string) {
 			state.Player.Location, int(state.Player.X), int(state.Player.Y),
 			state.Player.Energy, state.Player.CanMove, state.Player.IsMoving)
 
+		// Advance once the goal's conditions hold, or the model reported a goal without conditions complete
+		goal := a.Goal()
+		status := goal.Evaluate(state)
+		if status.Met || a.goalReportedComplete() {
+			if status.Met {
+				log.Printf("[AGENT LOOP] Goal complete: %s", status.Progress())
+			}
+			log.Printf("[AGENT LOOP] Goal %d completed! Moving on to the next goal.", queued.ID)
+			agentFeed.Publish("goal", "Goal %d complete", queued.ID)
+			a.queue.Complete(queued.ID)
+			continue
+		}
+
 		// Determine active goal
-		activeGoal := goal
+		activeGoal := goal.Text
+		if goal.Checkable() {
+			activeGoal += "\nDONE WHEN: " + status.Progress()
+		}
 		urgency := ""
+		behavior := config.Agent.Behavior
 
-		if state.Time.TimeOfDay >= 2500 {
+		if state.Time.TimeOfDay >= behavior.CriticalThreshold {
 			activeGoal = "EMERGENCY: Go to bed NOW! Time is " + state.Time.TimeString
 			urgency = "CRITICAL"
-		} else if state.Time.TimeOfDay >= 2400 {
+		} else if state.Time.TimeOfDay >= behavior.BedtimeThreshold {
 			activeGoal = "URGENT: Find your bed and sleep. It's " + state.Time.TimeString
 			urgency = "URGENT"
-		} else if state.Time.TimeOfDay >= 2200 {
+		} else if state.Time.TimeOfDay >= behavior.SleepThreshold {
 			urgency = "Getting late"
 		}
 
-		if state.Player.Energy < 10 {
+		if state.Player.Energy < behavior.EmergencyEnergy {
 			activeGoal = "LOW ENERGY: Eat food from inventory OR go to bed immediately!"
 			urgency = "LOW ENERGY"
-		} else if state.Player.Energy < 30 {
+		} else if state.Player.Energy < behavior.LowEnergy {
 			urgency = "Low energy"
 		}
 
 		// Skip if player is busy
 		if state.Player.IsMoving {
-			time.Sleep(100 * time.Millisecond)
+			pause(ctx, 100*time.Millisecond)
 			continue
 		}
 		if !state.Player.CanMove {
-			time.Sleep(100 * time.Millisecond)
+			pause(ctx, 100*time.Millisecond)
 			continue
 		}
 
@@ -913,7 +506,7 @@ CRITICAL EXECUTION ORDER - These tools have dependencies and MUST be called SEQU
 6. cheat_harvest_all (MUST run AFTER grow_crops - needs mature crops)
 
 DO NOT call plant_seeds, grow_crops, or harvest_all in parallel - they depend on each other!
-After ALL tools complete successfully, respond with "GOAL COMPLETE".`,
+After ALL tools complete successfully, call report_goal_status with status "complete".`,
 			state.Player.Location, int(state.Player.X), int(state.Player.Y),
 			state.Time.Season, state.Time.TimeString, state.Player.Energy, state.Player.MaxEnergy,
 			urgency,
@@ -926,42 +519,37 @@ After ALL tools complete successfully, respond with "GOAL COMPLETE".`,
 		}
 
 		// Send message and wait for response
-		log.Printf("[AGENT LOOP] Sending prompt (%d chars) to Copilot...", len(prompt))
-		response, err := a.session.SendAndWait(copilot.MessageOptions{
-			Prompt: prompt,
-		}, 120*time.Second) // 120 second timeout for complex cheat operations
+		log.Printf("[AGENT LOOP] Sending prompt (%d chars) to %s...", len(prompt), a.backend.Name())
+		// complex cheat operations need a generous timeout
+		sendCtx, cancel := context.WithTimeout(ctx, config.Agent.LLMTimeoutDuration())
+		toolCalls := 0
+		response, err := a.session.Send(sendCtx, prompt, func(call ToolCall) {
+			toolCalls++
+		})
+		cancel()
 		if err != nil {
-			log.Printf("[AGENT AGENT] SendAndWait error: %v", err)
-			time.Sleep(5 * time.Second)
+			if ctx.Err() != nil {
+				continue
+			}
+			log.Printf("[AGENT AGENT] Send error: %v", err)
+			pause(ctx, 5*time.Second)
 			continue
 		}
-		log.Printf("[AGENT LOOP] Got response from Copilot")
+		log.Printf("[AGENT LOOP] Got response from %s after %d tool call(s)", a.backend.Name(), toolCalls)
 
-		// Log the response and check for goal completion
-		if response != nil && response.Data.Content != nil {
-			thought := strings.TrimSpace(*response.Data.Content)
-			if thought != "" {
-				log.Printf("[AGENT THOUGHT] %s", thought)
-			}
-
-			// Check for goal completion signal
-			thoughtUpper := strings.ToUpper(thought)
-			if strings.Contains(thoughtUpper, "GOAL COMPLETE") ||
-				strings.Contains(thoughtUpper, "GOAL COMPLETED") ||
-				strings.Contains(thoughtUpper, "ALL TASKS COMPLETE") ||
-				strings.Contains(thoughtUpper, "MISSION ACCOMPLISHED") {
-				log.Printf("[AGENT LOOP] Goal completion detected!")
-				goalCompleted = true
-			}
+		// Log the response and pick up the plan
+		if thought := strings.TrimSpace(response); thought != "" {
+			log.Printf("[AGENT THOUGHT] %s", thought)
+			agentFeed.Publish("thought", "%s", thought)
 
 			if strings.Contains(thought, "PLAN:") {
 				parts := strings.SplitN(thought, "PLAN:", 2)
 				if len(parts) > 1 {
 					planEnd := strings.Index(parts[1], "\n")
 					if planEnd > 0 {
-						a.currentPlan = strings.TrimSpace(parts[1][:planEnd])
+						a.setPlan(strings.TrimSpace(parts[1][:planEnd]))
 					} else {
-						a.currentPlan = strings.TrimSpace(parts[1])
+						a.setPlan(strings.TrimSpace(parts[1]))
 					}
 				}
 			}
@@ -969,17 +557,33 @@ After ALL tools complete successfully, respond with "GOAL COMPLETE".`,
 
 		// Brief pause between iterations (LLM call is the main delay)
 		if urgency != "" {
-			time.Sleep(100 * time.Millisecond)
+			pause(ctx, 100*time.Millisecond)
 		} else {
-			time.Sleep(250 * time.Millisecond)
+			pause(ctx, behavior.LoopIntervalDuration())
 		}
 	}
+	log.Printf("[AGENT LOOP] Stopped")
+}
+
+// setPlan records the plan the model announced and shows it on the agent feed
+func (a *StardewAgent) setPlan(plan string) {
+	a.goalMu.Lock()
+	a.currentPlan = plan
+	a.goalMu.Unlock()
+	agentFeed.Publish("plan", "%s", plan)
+}
+
+// Plan returns the plan the model last announced
+func (a *StardewAgent) Plan() string {
+	a.goalMu.Lock()
+	defer a.goalMu.Unlock()
+	return a.currentPlan
 }
 
 // Tool parameter structs
 type MoveToParams struct {
-	X int `json:"x" jsonschema:"Target tile X coordinate"`
-	Y int `json:"y" jsonschema:"Target tile Y coordinate"`
+	X int `json:"x" jsonschema:"Target tile X coordinate" validate:"min=0"`
+	Y int `json:"y" jsonschema:"Target tile Y coordinate" validate:"min=0"`
 }
 
 type NoParams struct {
@@ -988,11 +592,11 @@ type NoParams struct {
 }
 
 type CountParams struct {
-	Count int `json:"count" jsonschema:"Number of times to use the tool (1-100)"`
+	Count int `json:"count" jsonschema:"Number of times to use the tool (1-100)" validate:"min=1,max=100"`
 }
 
 type DirectionParams struct {
-	Direction string `json:"direction" jsonschema:"Direction to face (up, down, left, right)"`
+	Direction string `json:"direction" jsonschema:"Direction to face (up, down, left, right)" validate:"enum=up|down|left|right"`
 }
 
 type NameParams struct {
@@ -1004,7 +608,12 @@ type TargetTypeParams struct {
 }
 
 type SlotParams struct {
-	Slot int `json:"slot" jsonschema:"Inventory slot number"`
+	Slot int `json:"slot" jsonschema:"Inventory slot number" validate:"min=0,max=35"`
+}
+
+type AcquireControlParams struct {
+	TTL     float64 `json:"ttl,omitempty" jsonschema:"Lease duration in seconds (default 60, max 3600); call again before it ends to renew" validate:"min=1,max=3600"`
+	Preempt bool    `json:"preempt,omitempty" jsonschema:"Take control from the current holder (operators only)"`
 }
 
 // Cheat mode parameter structs
@@ -1015,32 +624,32 @@ type CheatWarpParams struct {
 }
 
 type CheatSetMoneyParams struct {
-	Amount int `json:"amount" jsonschema:"Amount of gold to set"`
+	Amount int `json:"amount" jsonschema:"Amount of gold to set" validate:"min=0"`
 }
 
 type CheatAddItemParams struct {
 	ItemID  string `json:"itemId" jsonschema:"Item ID (e.g., '(O)465' for Parsnip Seeds, '(T)Pickaxe' for tools)"`
-	Count   int    `json:"count,omitempty" jsonschema:"Number of items (default 1)"`
-	Quality int    `json:"quality,omitempty" jsonschema:"Quality (0=normal, 1=silver, 2=gold, 4=iridium)"`
+	Count   int    `json:"count,omitempty" jsonschema:"Number of items (default 1)" validate:"min=1,max=999"`
+	Quality int    `json:"quality,omitempty" jsonschema:"Quality (0=normal, 1=silver, 2=gold, 4=iridium)" validate:"enum=0|1|2|4"`
 }
 
 type CheatSetFriendshipParams struct {
 	NPCName string `json:"npcName" jsonschema:"NPC name (e.g., Abigail, Sebastian)"`
-	Hearts  int    `json:"hearts,omitempty" jsonschema:"Friendship hearts (0-14, default 10)"`
-	Points  int    `json:"points,omitempty" jsonschema:"Friendship points (250 per heart)"`
+	Hearts  int    `json:"hearts,omitempty" jsonschema:"Friendship hearts (0-14, default 10)" validate:"min=0,max=14"`
+	Points  int    `json:"points,omitempty" jsonschema:"Friendship points (250 per heart)" validate:"min=0,max=3500"`
 }
 
 type CheatMineWarpParams struct {
-	Level int `json:"level" jsonschema:"Mine level (1-120 for Mines, 121+ for Skull Cavern, 77377 for Quarry)"`
+	Level int `json:"level" jsonschema:"Mine level (1-120 for Mines, 121+ for Skull Cavern, 77377 for Quarry)" validate:"min=1"`
 }
 
 type CheatSpawnOresParams struct {
-	OreType string `json:"oreType" jsonschema:"Type of ore (copper, iron, gold, iridium, coal)"`
-	Count   int    `json:"count,omitempty" jsonschema:"Number of ores (default 10)"`
+	OreType string `json:"oreType" jsonschema:"Type of ore (copper, iron, gold, iridium, coal)" validate:"enum=copper|iron|gold|iridium|coal"`
+	Count   int    `json:"count,omitempty" jsonschema:"Number of ores (default 10)" validate:"min=1,max=999"`
 }
 
 type CheatTimeSetParams struct {
-	Time int `json:"time" jsonschema:"Time in 24-hour format (600=6AM, 1800=6PM, 2600=2AM)"`
+	Time int `json:"time" jsonschema:"Time in 24-hour format (600=6AM, 1800=6PM, 2600=2AM)" validate:"min=600,max=2600"`
 }
 
 type CheatGiveGiftParams struct {
@@ -1053,11 +662,40 @@ type CheatCompleteQuestParams struct {
 }
 
 type CheatHoeAllParams struct {
-	Radius int `json:"radius,omitempty" jsonschema:"Radius around player to hoe (default 50)"`
+	Radius int `json:"radius,omitempty" jsonschema:"Radius around player to hoe (default 50)" validate:"min=0"`
 }
 
 type CheatCutTreesParams struct {
 	IncludeStumps bool `json:"includeStumps,omitempty" jsonschema:"Whether to include tree stumps (default true)"`
+	DryRun        bool `json:"dryRun,omitempty" jsonschema:"Only list and preview the trees that would be cut; nothing changes"`
+}
+
+type CheatMineRocksParams struct {
+	DryRun bool `json:"dryRun,omitempty" jsonschema:"Only list and preview the rocks that would be mined; nothing changes"`
+}
+
+type UndoParams struct {
+	CommandID string `json:"commandId" jsonschema:"Journal id of the command to undo, as shown in its result or by get_undo_journal"`
+}
+
+type ReportGoalStatusParams struct {
+	Status  string `json:"status" jsonschema:"complete, in_progress or blocked" validate:"enum=complete|in_progress|blocked"`
+	Summary string `json:"summary,omitempty" jsonschema:"What was done, or what blocks the goal"`
+}
+
+type EnqueueGoalParams struct {
+	Text     string   `json:"text" jsonschema:"What the agent should do"`
+	Done     []string `json:"done,omitempty" jsonschema:"Conditions that end the goal, e.g. money >= 5000, crops >= 15 or hearts(Abigail) >= 4"`
+	Position int      `json:"position,omitempty" jsonschema:"Queue position, 1 being the head (default: the end)" validate:"min=1"`
+}
+
+type MoveGoalParams struct {
+	GoalID   int `json:"goalId" jsonschema:"Id of the queued goal" validate:"min=1"`
+	Position int `json:"position" jsonschema:"New queue position, 1 being the head" validate:"min=1"`
+}
+
+type GoalIDParams struct {
+	GoalID int `json:"goalId" jsonschema:"Id of the queued goal" validate:"min=1"`
 }
 
 type CheatPlantSeedsParams struct {
@@ -1069,16 +707,16 @@ type CheatFertilizeAllParams struct {
 }
 
 type CheatUpgradeBackpackParams struct {
-	Size int `json:"size,omitempty" jsonschema:"Backpack size: 12, 24, or 36 (default 36)"`
+	Size int `json:"size,omitempty" jsonschema:"Backpack size: 12, 24, or 36 (default 36)" validate:"enum=12|24|36"`
 }
 
 type CheatUpgradeToolParams struct {
 	Tool  string `json:"tool" jsonschema:"Tool name: Hoe, Pickaxe, Axe, WateringCan, FishingRod, or Trash Can"`
-	Level int    `json:"level,omitempty" jsonschema:"Upgrade level: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium (default 4)"`
+	Level int    `json:"level,omitempty" jsonschema:"Upgrade level: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium (default 4)" validate:"min=0,max=4"`
 }
 
 type CheatUpgradeAllToolsParams struct {
-	Level int `json:"level,omitempty" jsonschema:"Upgrade level: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium (default 4)"`
+	Level int `json:"level,omitempty" jsonschema:"Upgrade level: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium (default 4)" validate:"min=0,max=4"`
 }
 
 // Targeted/selective cheat params (for precise control like drawing shapes)
@@ -1095,6 +733,7 @@ type CheatClearTilesParams struct {
 	ClearObjects  bool   `json:"clearObjects,omitempty" jsonschema:"Clear objects like debris, stones (default true)"`
 	ClearFeatures bool   `json:"clearFeatures,omitempty" jsonschema:"Clear terrain features like grass, trees (default true)"`
 	ClearDirt     bool   `json:"clearDirt,omitempty" jsonschema:"Clear hoed dirt (default true)"`
+	DryRun        bool   `json:"dryRun,omitempty" jsonschema:"Only list and preview what would be cleared; nothing changes"`
 }
 
 type CheatTillPatternParams struct {
@@ -1113,7 +752,8 @@ type CheatHoeCustomPatternParams struct {
 	Grid         string `json:"grid,omitempty" jsonschema:"ASCII art grid where # or X marks tiles to hoe. Use \\n for newlines. Example: '..#..\\n.###.\\n#####\\n.###.\\n..#..' for diamond"`
 	OffsetString string `json:"offsetString,omitempty" jsonschema:"Relative offsets as 'dx,dy;dx,dy'. Example: '0,0;1,0;-1,0;0,1;0,-1' for a cross"`
 	ClearArea    bool   `json:"clearArea,omitempty" jsonschema:"Clear surrounding hoed dirt to make pattern visible (default true)"`
-	ClearRadius  int    `json:"clearRadius,omitempty" jsonschema:"Radius around pattern to clear (default: pattern size + 5)"`
+	ClearRadius  int    `json:"clearRadius,omitempty" jsonschema:"Radius around pattern to clear (default: pattern size + 5)" validate:"min=0"`
+	DryRun       bool   `json:"dryRun,omitempty" jsonschema:"Only list and preview the tiles that would be hoed and cleared; nothing changes"`
 }
 
 // TargetInfo contains all info needed to clear a target
@@ -1139,17 +779,18 @@ type Target struct {
 	Distance     int
 }
 
-func (a *StardewAgent) handleMoveTo(x, y int) (string, error) {
+func (a *StardewAgent) handleMoveTo(ctx context.Context, x, y int) (string, error) {
 	a.toolMutex.Lock()
 	defer a.toolMutex.Unlock()
-	return a.doMoveTo(x, y)
+	return a.doMoveTo(ctx, x, y)
 }
 
 // doMoveTo is the internal movement function (caller must hold toolMutex)
-func (a *StardewAgent) doMoveTo(x, y int) (string, error) {
+func (a *StardewAgent) doMoveTo(ctx context.Context, x, y int) (string, error) {
 	log.Printf("[AGENT TOOL: move_to] Target: (%d, %d)", x, y)
 
-	state := gameClient.GetState()
+	client := clientFrom(ctx)
+	state := client.GetState()
 	if state == nil {
 		return "Game disconnected", nil
 	}
@@ -1162,7 +803,7 @@ func (a *StardewAgent) doMoveTo(x, y int) (string, error) {
 		return fmt.Sprintf("Target (%d, %d) is blocked by an obstacle. Choose an adjacent '.' tile instead.", x, y), nil
 	}
 
-	resp, err := gameClient.SendCommand("move_to", map[string]interface{}{"x": x, "y": y})
+	resp, err := client.SendCommandContext(ctx, "move_to", map[string]interface{}{"x": x, "y": y})
 	if err != nil {
 		return fmt.Sprintf("Move command failed: %v", err), nil
 	}
@@ -1176,10 +817,12 @@ func (a *StardewAgent) doMoveTo(x, y int) (string, error) {
 
 	for {
 		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("move_to cancelled: %w", ctx.Err())
 		case <-timeout:
 			return "Movement timed out.", nil
 		case <-ticker.C:
-			state := gameClient.GetState()
+			state := client.GetState()
 			if state != nil && int(state.Player.X) == x && int(state.Player.Y) == y {
 				return "Arrived at destination", nil
 			}
@@ -1190,13 +833,14 @@ func (a *StardewAgent) doMoveTo(x, y int) (string, error) {
 	}
 }
 
-func (a *StardewAgent) clearTarget(targetType string) (string, error) {
+func (a *StardewAgent) clearTarget(ctx context.Context, targetType string) (string, error) {
 	a.toolMutex.Lock()
 	defer a.toolMutex.Unlock()
 
 	log.Printf("[AGENT CLEAR_TARGET] Starting for type: %s", targetType)
 
-	state := gameClient.GetState()
+	client := clientFrom(ctx)
+	state := client.GetState()
 	if state == nil {
 		return "Game disconnected", nil
 	}
@@ -1209,50 +853,62 @@ func (a *StardewAgent) clearTarget(targetType string) (string, error) {
 	log.Printf("[AGENT CLEAR_TARGET] Found: %s at (%d,%d), tool: %s, hits: %d",
 		targetInfo.Name, targetInfo.X, targetInfo.Y, targetInfo.RequiredTool, targetInfo.HitsRequired)
 
-	if targetInfo.RequiredTool != "" {
-		log.Printf("[AGENT CLEAR_TARGET] Selecting tool: %s", targetInfo.RequiredTool)
-		resp, err := gameClient.SendCommand("select_item", map[string]interface{}{"name": targetInfo.RequiredTool})
-		if err != nil || resp == nil {
-			return fmt.Sprintf("Failed to select %s: connection error", targetInfo.RequiredTool), nil
-		}
-		if !resp.Success {
-			return fmt.Sprintf("Failed to select %s: %s", targetInfo.RequiredTool, resp.Message), nil
-		}
-		time.Sleep(50 * time.Millisecond)
+	if !state.Player.CanMove {
+		return "Player is currently busy. Wait for animation to finish.", nil
 	}
-
-	log.Printf("[AGENT CLEAR_TARGET] Moving to approach tile (%d, %d)", targetInfo.ApproachX, targetInfo.ApproachY)
-	moveResult, _ := a.doMoveTo(targetInfo.ApproachX, targetInfo.ApproachY)
-	if !strings.Contains(moveResult, "Arrived") && !strings.Contains(moveResult, "Stopped") {
-		return fmt.Sprintf("Failed to reach approach tile: %s", moveResult), nil
+	if !a.isTileWalkable(state, targetInfo.ApproachX, targetInfo.ApproachY) {
+		return fmt.Sprintf("Approach tile (%d, %d) is blocked by an obstacle.", targetInfo.ApproachX, targetInfo.ApproachY), nil
 	}
 
-	log.Printf("[AGENT CLEAR_TARGET] Facing: %s", targetInfo.FaceDirection)
-	resp, err := gameClient.SendCommand("face_direction", map[string]interface{}{"direction": targetInfo.FaceDirection})
-	if err != nil || resp == nil {
-		return "Failed to face direction: connection error", nil
-	}
-	if !resp.Success {
-		return fmt.Sprintf("Failed to face %s: %s", targetInfo.FaceDirection, resp.Message), nil
+	// Select, walk, face and swing run in the mod as one batch
+	var steps []BatchStep
+	if targetInfo.RequiredTool != "" {
+		steps = append(steps, BatchStep{Action: "select_item", Params: map[string]interface{}{"name": targetInfo.RequiredTool}})
 	}
-	time.Sleep(50 * time.Millisecond)
-
-	var result string
+	steps = append(steps,
+		BatchStep{
+			Action: "move_to",
+			Params: map[string]interface{}{"x": targetInfo.ApproachX, "y": targetInfo.ApproachY},
+			Expect: map[string]interface{}{"arrived": true},
+		},
+		BatchStep{Action: "face_direction", Params: map[string]interface{}{"direction": targetInfo.FaceDirection}},
+	)
 	if targetInfo.HitsRequired > 1 {
-		log.Printf("[AGENT CLEAR_TARGET] Using tool %d times", targetInfo.HitsRequired)
-		resp, err = gameClient.SendCommand("use_tool_repeat", map[string]interface{}{"count": targetInfo.HitsRequired})
+		steps = append(steps, BatchStep{Action: "use_tool_repeat", Params: map[string]interface{}{"count": targetInfo.HitsRequired}})
 	} else if targetInfo.HitsRequired == 0 {
-		log.Printf("[AGENT CLEAR_TARGET] Interacting (no tool needed)")
-		resp, err = gameClient.SendCommand("interact", nil)
+		steps = append(steps, BatchStep{Action: "interact"})
 	} else {
-		log.Printf("[AGENT CLEAR_TARGET] Using tool once")
-		resp, err = gameClient.SendCommand("use_tool", nil)
+		steps = append(steps, BatchStep{Action: "use_tool"})
 	}
 
-	if err != nil || resp == nil {
-		result = "command sent (no response)"
-	} else {
-		result = resp.Message
+	log.Printf("[AGENT CLEAR_TARGET] Sending batch: %d steps, approach (%d, %d) facing %s",
+		len(steps), targetInfo.ApproachX, targetInfo.ApproachY, targetInfo.FaceDirection)
+	batch, err := client.SendBatch(ctx, steps, true)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("clear_target cancelled: %w", ctx.Err())
+		}
+		return fmt.Sprintf("Failed to clear %s: %v", targetInfo.Name, err), nil
+	}
+
+	if failed := batch.Failed(); failed != nil {
+		switch failed.Action {
+		case "select_item":
+			return fmt.Sprintf("Failed to select %s: %s", targetInfo.RequiredTool, failed.Message), nil
+		case "move_to":
+			return fmt.Sprintf("Failed to reach approach tile: %s", failed.Message), nil
+		case "face_direction":
+			return fmt.Sprintf("Failed to face %s: %s", targetInfo.FaceDirection, failed.Message), nil
+		default:
+			return fmt.Sprintf("Failed to clear %s at (%d,%d): %s failed: %s", targetInfo.Name, targetInfo.X, targetInfo.Y, failed.Action, failed.Message), nil
+		}
+	}
+	if !batch.Success {
+		return fmt.Sprintf("Failed to clear %s at (%d,%d): %s", targetInfo.Name, targetInfo.X, targetInfo.Y, batch.Message), nil
+	}
+	result := batch.Message
+	if last := batch.Last(); last != nil {
+		result = last.Message
 	}
 
 	log.Printf("[AGENT CLEAR_TARGET] Done! Result: %s", result)
@@ -1680,23 +1336,30 @@ func (a *StardewAgent) formatGameStateContext(state *GameState) string {
 
 	if state.Surroundings.AsciiMap != "" {
 		sb.WriteString("\n--- ASCII MAP (center 21x21 of 61x61) ---\n")
-		lines := strings.Split(state.Surroundings.AsciiMap, "\n")
-		center := 30
-		viewRadius := 10
-		for y := center - viewRadius; y <= center+viewRadius && y < len(lines); y++ {
-			if y >= 0 && y < len(lines) {
-				line := lines[y]
-				start := center - viewRadius
-				end := center + viewRadius + 1
-				if start >= 0 && end <= len(line) {
-					sb.WriteString(line[start:end] + "\n")
-				} else if len(line) > 0 {
-					sb.WriteString(line + "\n")
-				}
+		sb.WriteString(centerMap(state.Surroundings.AsciiMap))
+	}
+
+	return sb.String()
+}
+
+// centerMap cuts the 21x21 tiles around the player out of the mod's 61x61 ASCII map
+func centerMap(asciiMap string) string {
+	var sb strings.Builder
+	lines := strings.Split(asciiMap, "\n")
+	center := 30
+	viewRadius := 10
+	for y := center - viewRadius; y <= center+viewRadius && y < len(lines); y++ {
+		if y >= 0 && y < len(lines) {
+			line := lines[y]
+			start := center - viewRadius
+			end := center + viewRadius + 1
+			if start >= 0 && end <= len(line) {
+				sb.WriteString(line[start:end] + "\n")
+			} else if len(line) > 0 {
+				sb.WriteString(line + "\n")
 			}
 		}
 	}
-
 	return sb.String()
 }
 
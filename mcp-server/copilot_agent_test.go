@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"stardew-mcp/fakegame"
+)
+
+// TestAutonomousLoop runs the agent loop on a scripted model against the fake
+// game: the first goal ends when its condition holds after the scripted tool
+// calls, the second when the model reports it complete.
+func TestAutonomousLoop(t *testing.T) {
+	fake := fakegame.NewServer(fakegame.Options{StateInterval: -1})
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Cleanup(fake.Close)
+
+	client := NewGameClient()
+	if err := client.Connect("ws" + strings.TrimPrefix(srv.URL, "http") + "/game"); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	queue := NewGoalQueue("")
+	clear, err := queue.Enqueue("Clear the debris", []string{"debris(10) == 0"}, 0, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.Enqueue("Look around", nil, 0, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if status := clear.Goal().Evaluate(client.GetState()); status.Met {
+		t.Fatalf("the fake farm starts without debris: %s", status.Progress())
+	}
+
+	backend := NewScriptedBackend([]ScriptedTurn{
+		{Tools: []ScriptedToolCall{{Name: "cheat_mode_enable"}, {Name: "cheat_clear_debris"}}, Reply: "PLAN: clear the farm"},
+		{Tools: []ScriptedToolCall{{Name: "report_goal_status", Arguments: map[string]interface{}{"status": "Complete"}}}, Reply: "Looked around"},
+	})
+	events, stop := agentFeed.Subscribe()
+	defer stop()
+
+	agent := &StardewAgent{backend: backend, model: "scripted", client: client, queue: queue}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := agent.StartSession(ctx); err != nil {
+		cancel()
+		t.Fatalf("StartSession: %v", err)
+	}
+	// Stop the loop and supervisor before the game goes away
+	t.Cleanup(func() {
+		cancel()
+		<-agent.Done()
+	})
+
+	deadline := time.After(10 * time.Second)
+	var tools []string
+	record := func(ev AgentEvent) {
+		switch ev.Kind {
+		case "tool":
+			tools = append(tools, strings.Fields(ev.Text)[0])
+		case "error":
+			t.Errorf("agent error: %s", ev.Text)
+		}
+	}
+	for queue.Len() > 0 {
+		select {
+		case ev := <-events:
+			record(ev)
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("queue still has %d goal(s) after %d prompt(s) and tool calls %v", queue.Len(), len(backend.Prompts()), tools)
+		}
+	}
+	for len(events) > 0 {
+		record(<-events)
+	}
+
+	if want := "cheat_mode_enable cheat_clear_debris report_goal_status"; strings.Join(tools, " ") != want {
+		t.Errorf("tool calls = %v, want %s", tools, want)
+	}
+	if status := clear.Goal().Evaluate(client.GetState()); !status.Met {
+		t.Errorf("debris left after cheat_clear_debris: %s", status.Progress())
+	}
+
+	if plan := agent.Plan(); plan != "clear the farm" {
+		t.Errorf("plan = %q, want the one from the first reply", plan)
+	}
+
+	prompts := backend.Prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("model prompted %d times, want 2", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "GOAL: Clear the debris") || !strings.Contains(prompts[0], "DONE WHEN: debris(10) == 0") {
+		t.Errorf("first prompt lacks the goal and its condition:\n%s", prompts[0])
+	}
+	if !strings.Contains(prompts[1], "GOAL: Look around") {
+		t.Errorf("second prompt is not about the next goal:\n%s", prompts[1])
+	}
+}
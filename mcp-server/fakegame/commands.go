@@ -0,0 +1,483 @@
+package fakegame
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Command handling - messages match CommandExecutor.cs where the mod has them
+// ============================================================================
+
+// result is the outcome of one command before it is wrapped in a Response
+type result struct {
+	success bool
+	message string
+	data    map[string]interface{}
+}
+
+func ok(message string, data map[string]interface{}) result {
+	return result{success: true, message: message, data: data}
+}
+
+func fail(message string) result {
+	return result{message: message}
+}
+
+// Actions lists every action the simulation implements, as advertised in hello
+var Actions = []string{
+	"move_to", "stop", "face_direction", "switch_tool", "select_item",
+	"use_tool", "use_tool_repeat", "interact", "eat_item", "enter_door", "sleep", "get_state",
+	"cheat_mode_enable", "cheat_mode_disable", "cheat_warp",
+	"cheat_set_money", "cheat_set_energy", "cheat_set_health", "cheat_time_set",
+	"cheat_hoe_all", "cheat_water_all", "cheat_clear_debris", "cheat_cut_trees",
+	"cheat_mine_rocks", "cheat_plant_seeds", "cheat_grow_crops", "cheat_harvest_all",
+	"cheat_add_item", "cheat_hoe_tiles", "cheat_clear_tiles",
+}
+
+// execute runs one command against the world. Unknown actions fail the same
+// way the mod does, so clients can probe for unsupported features.
+func (w *world) execute(action string, params map[string]interface{}) result {
+	action = strings.ToLower(action)
+	if strings.HasPrefix(action, "cheat_") && action != "cheat_mode_enable" && !w.cheatMode {
+		return fail("Cheat mode is disabled. Use cheat_mode_enable first.")
+	}
+
+	switch action {
+	case "move_to":
+		return w.cmdMoveTo(params)
+	case "stop":
+		return ok("Movement stopped", nil)
+	case "face_direction":
+		return w.cmdFaceDirection(params)
+	case "switch_tool":
+		return w.cmdSwitchTool(params)
+	case "select_item":
+		return w.cmdSelectItem(params)
+	case "use_tool":
+		return w.cmdUseTool(1)
+	case "use_tool_repeat":
+		count, _ := intParam(params, "count")
+		return w.cmdUseTool(clamp(count, 1, 100))
+	case "interact":
+		return w.cmdInteract()
+	case "eat_item":
+		return w.cmdEatItem(params)
+	case "enter_door":
+		return w.cmdEnterDoor()
+	case "sleep":
+		return w.cmdSleep()
+	case "get_state":
+		return ok("State retrieved", nil)
+
+	case "cheat_mode_enable":
+		w.cheatMode = true
+		return ok("Cheat mode enabled. All cheat commands are now available.", nil)
+	case "cheat_mode_disable":
+		w.cheatMode = false
+		return ok("Cheat mode disabled.", nil)
+	case "cheat_warp":
+		return w.cmdCheatWarp(params)
+	case "cheat_set_money":
+		amount, found := intParam(params, "amount")
+		if !found {
+			return fail("Missing amount parameter")
+		}
+		w.money = amount
+		return ok(fmt.Sprintf("Money set to %dg", amount), nil)
+	case "cheat_set_energy":
+		amount, found := intParam(params, "amount")
+		if !found {
+			amount = maxEnergy
+		}
+		w.energy = float64(clamp(amount, 0, maxEnergy))
+		return ok(fmt.Sprintf("Energy set to %.0f", w.energy), nil)
+	case "cheat_set_health":
+		amount, found := intParam(params, "amount")
+		if !found {
+			amount = maxHealth
+		}
+		w.health = clamp(amount, 0, maxHealth)
+		return ok(fmt.Sprintf("Health set to %d", w.health), nil)
+	case "cheat_time_set":
+		t, found := intParam(params, "time")
+		if !found {
+			return fail("Missing time parameter")
+		}
+		w.timeOfDay = clamp(t, 600, 2600)
+		return ok(fmt.Sprintf("Time set to %s", formatTime(w.timeOfDay)), nil)
+	case "cheat_add_item":
+		itemID, found := params["itemId"].(string)
+		if !found || itemID == "" {
+			return fail("Missing required parameter: itemId (e.g., '(O)465' for Parsnip Seeds)")
+		}
+		count, found := intParam(params, "count")
+		if !found {
+			count = 1
+		}
+		count = clamp(count, 1, 999)
+		// Items are named after their id; the simulation has no item data
+		w.addItem(itemID, "Item", count)
+		return ok(fmt.Sprintf("Added %dx %s (quality 0) to inventory", count, itemID), map[string]interface{}{"itemId": itemID, "count": count})
+	case "cheat_hoe_tiles":
+		return w.cmdCheatTiles(params, "Hoed", func(t *tile) bool {
+			if !t.tillable() {
+				return false
+			}
+			t.hoed = true
+			return true
+		})
+	case "cheat_clear_tiles":
+		clearObjects := !strings.EqualFold(fmt.Sprint(params["clearObjects"]), "false")
+		clearFeatures := !strings.EqualFold(fmt.Sprint(params["clearFeatures"]), "false")
+		clearDirt := !strings.EqualFold(fmt.Sprint(params["clearDirt"]), "false")
+		return w.cmdCheatTiles(params, "Cleared", func(t *tile) bool {
+			cleared := false
+			if clearObjects && t.object != "" {
+				t.object, cleared = "", true
+			}
+			if clearFeatures && t.tree {
+				t.tree, cleared = false, true
+			}
+			if clearDirt && t.hoed {
+				t.hoed, t.watered, t.crop, t.cropDays, cleared = false, false, "", 0, true
+			}
+			return cleared
+		})
+	case "cheat_hoe_all":
+		radius, found := intParam(params, "radius")
+		if !found {
+			radius = 50
+		}
+		return w.cmdCheatEach(radius, "Hoed %d tiles", func(t *tile) bool {
+			if !t.tillable() {
+				return false
+			}
+			t.hoed = true
+			return true
+		})
+	case "cheat_water_all":
+		return w.cmdCheatEach(w.width, "Watered %d tiles", func(t *tile) bool {
+			if !t.hoed || t.watered {
+				return false
+			}
+			t.watered = true
+			return true
+		})
+	case "cheat_clear_debris":
+		return w.cmdCheatEach(w.width, "Cleared %d debris", func(t *tile) bool {
+			if t.object == "" {
+				return false
+			}
+			t.object = ""
+			return true
+		})
+	case "cheat_cut_trees":
+		if boolParam(params, "dryRun") {
+			return w.cmdCheatPreview("would cut %d trees/stumps", "Tree", func(t *tile) bool { return t.tree })
+		}
+		return w.cmdCheatEach(w.width, "Cut %d trees", func(t *tile) bool {
+			if !t.tree {
+				return false
+			}
+			t.tree = false
+			w.addItem("Wood", "Resource", 10)
+			return true
+		})
+	case "cheat_mine_rocks":
+		if boolParam(params, "dryRun") {
+			return w.cmdCheatPreview("would mine %d rocks/boulders", "Stone", func(t *tile) bool { return t.object == "Stone" })
+		}
+		return w.cmdCheatEach(w.width, "Mined %d rocks", func(t *tile) bool {
+			if t.object != "Stone" {
+				return false
+			}
+			t.object = ""
+			w.addItem("Stone", "Resource", 1)
+			return true
+		})
+	case "cheat_plant_seeds":
+		return w.cmdCheatEach(w.width, "Planted %d seeds", func(t *tile) bool {
+			if !t.hoed || t.crop != "" {
+				return false
+			}
+			t.crop, t.cropDays = "Parsnip", 4
+			return true
+		})
+	case "cheat_grow_crops":
+		return w.cmdCheatEach(w.width, "Grew %d crops", func(t *tile) bool {
+			if t.crop == "" || t.cropDays == 0 {
+				return false
+			}
+			t.cropDays = 0
+			return true
+		})
+	case "cheat_harvest_all":
+		return w.cmdCheatEach(w.width, "Harvested %d crops", func(t *tile) bool {
+			if t.crop == "" || t.cropDays > 0 {
+				return false
+			}
+			w.harvest(t)
+			return true
+		})
+	}
+
+	return fail(fmt.Sprintf("Unknown action: %s", action))
+}
+
+func (w *world) cmdMoveTo(params map[string]interface{}) result {
+	x, hasX := intParam(params, "x")
+	y, hasY := intParam(params, "y")
+	if !hasX || !hasY {
+		return fail("Missing x or y parameter")
+	}
+	if x == w.x && y == w.y {
+		return ok("Already at destination", map[string]interface{}{"arrived": true, "x": x, "y": y})
+	}
+	if _, found := w.findPath(x, y); !found {
+		return fail(fmt.Sprintf("No path found to (%d, %d)", x, y))
+	}
+
+	// Movement is instant; the mod reports arrival the same way once the walk ends
+	w.x, w.y = x, y
+	return ok(fmt.Sprintf("Arrived at (%d, %d) in %s", x, y, w.location), map[string]interface{}{
+		"arrived":  true,
+		"x":        x,
+		"y":        y,
+		"location": w.location,
+	})
+}
+
+func (w *world) cmdFaceDirection(params map[string]interface{}) result {
+	direction, found := params["direction"].(string)
+	if !found {
+		return fail("Missing direction parameter")
+	}
+	direction = strings.ToLower(direction)
+	for i, name := range facings {
+		if name == direction {
+			w.facing = i
+			return ok("Now facing "+direction, map[string]interface{}{"direction": direction, "facingDirection": i})
+		}
+	}
+	return fail(fmt.Sprintf("Invalid direction: %s. Use up, down, left, or right.", direction))
+}
+
+func (w *world) cmdSwitchTool(params map[string]interface{}) result {
+	slot, found := intParam(params, "slot")
+	if !found {
+		return fail("Missing slot parameter")
+	}
+	if slot < 0 || slot > 11 {
+		return fail("Slot must be between 0 and 11")
+	}
+	w.toolIndex = slot
+	return ok(fmt.Sprintf("Switched to slot %d", slot), nil)
+}
+
+func (w *world) cmdSelectItem(params map[string]interface{}) result {
+	name, found := params["name"].(string)
+	if !found || name == "" {
+		return fail("Missing name parameter")
+	}
+	for i, item := range w.inventory {
+		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(name)) {
+			w.toolIndex = i
+			return ok(fmt.Sprintf("Selected %s in slot %d", item.Name, i), nil)
+		}
+	}
+	return fail(fmt.Sprintf("No item matching '%s' found in inventory", name))
+}
+
+func (w *world) cmdUseTool(count int) result {
+	tool := w.currentTool()
+	if tool == "" {
+		return fail("No tool equipped")
+	}
+	x, y := w.front()
+	outcome := "nothing"
+	for i := 0; i < count; i++ {
+		if r := w.applyTool(tool, x, y); r != "nothing" {
+			outcome = r
+		}
+	}
+	if count == 1 {
+		return ok("Used tool: "+tool, map[string]interface{}{"result": outcome})
+	}
+	return ok(fmt.Sprintf("Used %s %d times. VERIFY tile-in-front to confirm target was affected!", tool, count),
+		map[string]interface{}{"swings": count, "tool": tool, "energy": w.energy, "result": outcome})
+}
+
+func (w *world) cmdInteract() result {
+	x, y := w.front()
+	if t := w.at(x, y); t != nil && t.crop != "" && t.cropDays == 0 {
+		w.harvest(t)
+	}
+	return ok(fmt.Sprintf("Interaction triggered at (%d, %d) in %s. VERIFY the expected result occurred!", w.x, w.y, w.location),
+		map[string]interface{}{"x": w.x, "y": w.y, "location": w.location})
+}
+
+func (w *world) cmdEatItem(params map[string]interface{}) result {
+	slot, found := intParam(params, "slot")
+	if !found {
+		return fail("Missing slot parameter")
+	}
+	if slot < 0 || slot > 35 {
+		return fail("Slot must be between 0 and 35")
+	}
+	if slot >= len(w.inventory) {
+		return fail(fmt.Sprintf("No item in slot %d", slot))
+	}
+	item := w.inventory[slot]
+	if !item.IsEdible {
+		return fail(fmt.Sprintf("%s is not edible", item.Name))
+	}
+	w.energy = float64(min(int(w.energy)+item.EnergyRestored, maxEnergy))
+	w.health = min(w.health+item.HealthRestored, maxHealth)
+	w.removeItem(slot)
+	return ok(fmt.Sprintf("Eating %s", item.Name), map[string]interface{}{"item": item.Name, "slot": slot})
+}
+
+// cmdEnterDoor only knows the farmhouse door; inside, the farm grid is reused
+func (w *world) cmdEnterDoor() result {
+	x, y := w.front()
+	if w.location == "Farm" && x == doorX && y == doorY {
+		w.location, w.x, w.y = "FarmHouse", spawnX, spawnY
+		return ok("Entering door to FarmHouse", map[string]interface{}{"tileX": x, "tileY": y})
+	}
+	return ok("Attempting to enter door/warp at current position", map[string]interface{}{"tileX": x, "tileY": y})
+}
+
+func (w *world) cmdSleep() result {
+	if w.location != "FarmHouse" {
+		return fail("You can only sleep in your house. Go to the FarmHouse first.")
+	}
+	bed := map[string]interface{}{"bedX": bedX, "bedY": bedY}
+	if abs(w.x-bedX) > 1 || abs(w.y-bedY) > 1 {
+		return result{message: fmt.Sprintf("Too far from the bed at (%d, %d). move_to it first.", bedX, bedY), data: bed}
+	}
+	at := formatTime(w.timeOfDay)
+	w.newDay()
+	return ok("Going to sleep at "+at, bed)
+}
+
+func (w *world) cmdCheatWarp(params map[string]interface{}) result {
+	location, found := params["location"].(string)
+	if !found || location == "" {
+		return fail("Missing location parameter")
+	}
+	x, hasX := intParam(params, "x")
+	y, hasY := intParam(params, "y")
+	if !hasX || !hasY {
+		x, y = spawnX, spawnY
+	}
+	// Only the farm is simulated; other locations keep the farm grid under a new name
+	w.location, w.x, w.y = location, x, y
+	return ok(fmt.Sprintf("Warped to %s at (%d, %d)", location, x, y), nil)
+}
+
+// cmdCheatEach applies fn to every tile within radius and reports how many changed
+func (w *world) cmdCheatEach(radius int, format string, fn func(t *tile) bool) result {
+	count := 0
+	w.forEachInRadius(radius, func(x, y int, t *tile) {
+		if fn(t) {
+			count++
+		}
+	})
+	return ok(fmt.Sprintf(format, count), map[string]interface{}{"count": count})
+}
+
+// cmdCheatTiles applies fn to the tiles listed as "x,y;x,y" and reports how many changed
+func (w *world) cmdCheatTiles(params map[string]interface{}, verb string, fn func(t *tile) bool) result {
+	list, _ := params["tiles"].(string)
+	var tiles [][2]int
+	for _, pair := range strings.Split(list, ";") {
+		var x, y int
+		if _, err := fmt.Sscanf(strings.TrimSpace(pair), "%d,%d", &x, &y); err == nil {
+			tiles = append(tiles, [2]int{x, y})
+		}
+	}
+	if len(tiles) == 0 {
+		return fail("Missing or invalid 'tiles' parameter. Use string format \"x,y;x,y\".")
+	}
+
+	count := 0
+	for _, xy := range tiles {
+		if t := w.at(xy[0], xy[1]); t != nil && fn(t) {
+			count++
+		}
+	}
+	return ok(fmt.Sprintf("%s %d/%d tiles in %s", verb, count, len(tiles), w.location), map[string]interface{}{
+		"location": w.location,
+		"count":    count,
+	})
+}
+
+// cmdCheatPreview answers a dry run of a destructive cheat with every tile
+// in the location it would remove, without changing any of them
+func (w *world) cmdCheatPreview(format, name string, match func(t *tile) bool) result {
+	affected := []map[string]interface{}{}
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			if match(w.at(x, y)) {
+				affected = append(affected, map[string]interface{}{"x": x, "y": y, "name": name, "effect": "remove"})
+			}
+		}
+	}
+	summary := fmt.Sprintf(format, len(affected)) + " in " + w.location
+	return ok("Dry run: "+summary+". Nothing was changed.", map[string]interface{}{
+		"dryRun":        true,
+		"location":      w.location,
+		"affected":      affected,
+		"affectedCount": len(affected),
+	})
+}
+
+// boolParam reads a flag sent as a JSON bool or as a "true" string
+func boolParam(params map[string]interface{}, key string) bool {
+	switch v := params[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// intParam reads a JSON number parameter, which arrives as float64
+func intParam(params map[string]interface{}, key string) (int, bool) {
+	switch v := params[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// unmetExpectation describes the first expected data value a step's result
+// lacks, comparing JSON encodings like the mod; "" means every one matched
+func unmetExpectation(expect, data map[string]interface{}) string {
+	for key, want := range expect {
+		wantJSON, _ := json.Marshal(want)
+		got, found := data[key]
+		if !found {
+			return fmt.Sprintf("expected %s=%s, got no %s", key, wantJSON, key)
+		}
+		if gotJSON, _ := json.Marshal(got); string(gotJSON) != string(wantJSON) {
+			return fmt.Sprintf("expected %s=%s, got %s", key, wantJSON, gotJSON)
+		}
+	}
+	return ""
+}
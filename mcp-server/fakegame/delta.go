@@ -0,0 +1,73 @@
+package fakegame
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// State deltas - mirrors JsonPatch.cs: objects are diffed member by member,
+// arrays of equal length element by element, anything else is replaced
+// ============================================================================
+
+// diff appends the operations that turn from into to
+func diff(from, to interface{}, path string, ops []PatchOp) []PatchOp {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, fv := range f {
+			child := path + "/" + escapePointer(key)
+			if tv, found := t[key]; found {
+				ops = diff(fv, tv, child, ops)
+			} else {
+				ops = append(ops, PatchOp{Op: "remove", Path: child})
+			}
+		}
+		for key, tv := range t {
+			if _, found := f[key]; !found {
+				ops = append(ops, PatchOp{Op: "add", Path: path + "/" + escapePointer(key), Value: rawValue(tv)})
+			}
+		}
+		return ops
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok || len(t) != len(f) {
+			break
+		}
+		for i := range f {
+			ops = diff(f[i], t[i], path+"/"+strconv.Itoa(i), ops)
+		}
+		return ops
+	}
+
+	if !leafEqual(from, to) {
+		ops = append(ops, PatchOp{Op: "replace", Path: path, Value: rawValue(to)})
+	}
+	return ops
+}
+
+// leafEqual compares decoded JSON scalars; containers that get here differ in shape
+func leafEqual(a, b interface{}) bool {
+	switch a.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	switch b.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return a == b
+}
+
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func rawValue(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
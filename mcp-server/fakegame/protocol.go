@@ -0,0 +1,162 @@
+package fakegame
+
+import "encoding/json"
+
+// Wire types mirror the mod's WebSocketServer.cs and GameStateSerializer.cs.
+// Only the fields the simulation can fill are declared; the rest are left
+// out so clients see their zero values, exactly as with a sparse real state.
+
+// Message is a client-to-mod message (command, batch, get_state, ping, enable_deltas)
+type Message struct {
+	ID     string                 `json:"id,omitempty"`
+	Type   string                 `json:"type"`
+	Action string                 `json:"action,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Response is a mod-to-client message (response, state, state_patch, pong, error).
+// Seq numbers state and state_patch messages once deltas are enabled.
+type Response struct {
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Seq     int64       `json:"seq,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// PatchOp is one RFC 6902 operation of a state_patch message
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// State is the game state payload of a "state" message
+type State struct {
+	Player       PlayerState       `json:"player"`
+	Time         TimeState         `json:"time"`
+	World        WorldState        `json:"world"`
+	Surroundings SurroundingsState `json:"surroundings"`
+	Map          MapInfo           `json:"map"`
+}
+
+type PlayerState struct {
+	Name                string          `json:"name"`
+	X                   int             `json:"x"`
+	Y                   int             `json:"y"`
+	Location            string          `json:"location"`
+	Energy              float64         `json:"energy"`
+	MaxEnergy           int             `json:"maxEnergy"`
+	Health              int             `json:"health"`
+	MaxHealth           int             `json:"maxHealth"`
+	Money               int             `json:"money"`
+	CurrentTool         string          `json:"currentTool"`
+	CurrentToolIndex    int             `json:"currentToolIndex"`
+	FacingDirection     int             `json:"facingDirection"`
+	FacingDirectionName string          `json:"facingDirectionName"`
+	IsMoving            bool            `json:"isMoving"`
+	CanMove             bool            `json:"canMove"`
+	Inventory           []InventoryItem `json:"inventory"`
+}
+
+type TimeState struct {
+	TimeOfDay           int    `json:"timeOfDay"`
+	TimeString          string `json:"timeString"`
+	Day                 int    `json:"day"`
+	Season              string `json:"season"`
+	Year                int    `json:"year"`
+	DayOfWeek           string `json:"dayOfWeek"`
+	IsNight             bool   `json:"isNight"`
+	MinutesUntilMorning int    `json:"minutesUntilMorning"`
+}
+
+type WorldState struct {
+	Weather      string `json:"weather"`
+	IsOutdoors   bool   `json:"isOutdoors"`
+	IsFarm       bool   `json:"isFarm"`
+	LocationType string `json:"locationType"`
+}
+
+type SurroundingsState struct {
+	AsciiMap              string          `json:"asciiMap"`
+	NearbyObjects         []NearbyObject  `json:"nearbyObjects"`
+	NearbyTerrainFeatures []NearbyTerrain `json:"nearbyTerrainFeatures"`
+	WarpPoints            []WarpPoint     `json:"warpPoints"`
+	TileInFront           TileInFront     `json:"tileInFront"`
+}
+
+type MapInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Bed         *Tile  `json:"bed,omitempty"`
+}
+
+type Tile struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type NearbyObject struct {
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	Type         string `json:"type"`
+	IsPassable   bool   `json:"isPassable"`
+	RequiredTool string `json:"requiredTool,omitempty"`
+	HitsRequired int    `json:"hitsRequired"`
+}
+
+type NearbyTerrain struct {
+	X                 int    `json:"x"`
+	Y                 int    `json:"y"`
+	Type              string `json:"type"`
+	IsPassable        bool   `json:"isPassable"`
+	GrowthStage       int    `json:"growthStage"`
+	IsFullyGrown      bool   `json:"isFullyGrown"`
+	CanBeChopped      bool   `json:"canBeChopped"`
+	IsWatered         bool   `json:"isWatered"`
+	HasCrop           bool   `json:"hasCrop"`
+	CropName          string `json:"cropName,omitempty"`
+	DaysUntilHarvest  int    `json:"daysUntilHarvest"`
+	IsReadyForHarvest bool   `json:"isReadyForHarvest"`
+	RequiredTool      string `json:"requiredTool,omitempty"`
+	HitsRequired      int    `json:"hitsRequired"`
+}
+
+type WarpPoint struct {
+	X              int    `json:"x"`
+	Y              int    `json:"y"`
+	TargetLocation string `json:"targetLocation"`
+	IsDoor         bool   `json:"isDoor"`
+}
+
+type TileInFront struct {
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	IsPassable   bool   `json:"isPassable"`
+	IsWater      bool   `json:"isWater"`
+	IsTillable   bool   `json:"isTillable"`
+	ObjectName   string `json:"objectName,omitempty"`
+	ObjectType   string `json:"objectType,omitempty"`
+	TerrainType  string `json:"terrainType,omitempty"`
+	CanInteract  bool   `json:"canInteract"`
+	RequiredTool string `json:"requiredTool,omitempty"`
+}
+
+type InventoryItem struct {
+	Slot        int    `json:"slot"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Stack       int    `json:"stack"`
+	Category    string `json:"category"`
+	IsTool      bool   `json:"isTool"`
+	IsWeapon    bool   `json:"isWeapon"`
+	// Edible items report what eating one restores
+	IsEdible       bool `json:"isEdible"`
+	EnergyRestored int  `json:"energyRestored"`
+	HealthRestored int  `json:"healthRestored"`
+}
@@ -0,0 +1,434 @@
+// Package fakegame implements the Stardew MCP mod's WebSocket protocol
+// against a small deterministic farm simulation, so the MCP server can run
+// and be tested without Stardew Valley or SMAPI.
+//
+// Mount it with httptest in tests:
+//
+//	fake := fakegame.NewServer(fakegame.Options{StateInterval: -1})
+//	srv := httptest.NewServer(fake)
+//	defer srv.Close()
+//	defer fake.Close()
+//	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/game"
+package fakegame
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// ProtocolVersion and ModVersion are reported in the hello handshake
+	ProtocolVersion = 1
+	ModVersion      = "fake"
+
+	// The mod broadcasts once a second and the game clock moves 10 minutes every 7 seconds
+	broadcastsPerClockStep = 7
+	writeTimeout           = 5 * time.Second
+)
+
+// Options configures a fake game
+type Options struct {
+	// Seed selects the farm layout. The same seed always produces the same farm.
+	Seed int64
+	// StateInterval is the period of state broadcasts (default 1s).
+	// A negative value disables the broadcast loop; use Tick and Broadcast instead.
+	StateInterval time.Duration
+	// Legacy makes the fake behave like a mod from before the hello handshake
+	// and state deltas: both messages are answered as unknown message types.
+	Legacy bool
+	// Actions limits the supported actions, e.g. to mimic an older mod build.
+	// Empty means every action in the package-level Actions list.
+	Actions []string
+}
+
+// Server is a fake Stardew mod. It implements http.Handler and upgrades every
+// request to the mod's WebSocket protocol, so it can be mounted at any path.
+type Server struct {
+	opts     Options
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	world *world
+
+	clientsMu sync.Mutex
+	clients   map[*client]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// client is one WebSocket connection; gorilla allows a single concurrent writer
+type client struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+
+	// Delta state, guarded by mu like the writer so seq matches send order
+	deltas   bool
+	base     interface{}
+	baseJSON []byte
+	seq      int64
+}
+
+func (c *client) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(v)
+}
+
+func (c *client) write(v interface{}) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteJSON(v)
+}
+
+// sendState sends the state as a full snapshot, or as a patch against the last
+// state sent once the client has enabled deltas. full forces a snapshot. The
+// snapshot is taken under the send lock so states go out in the order taken.
+func (c *client) sendState(snapshot func() State, full bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := snapshot()
+	if !c.deltas {
+		return c.write(Response{Type: "state", Success: true, Data: state})
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	c.seq++
+	if !full && c.base != nil && bytes.Equal(data, c.baseJSON) {
+		// Nothing changed since the last state, which is the common idle case
+		return c.write(Response{Type: "state_patch", Success: true, Seq: c.seq, Data: []PatchOp{}})
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	msg := Response{Type: "state", Success: true, Seq: c.seq, Data: json.RawMessage(data)}
+	if !full && c.base != nil {
+		// A patch bigger than the snapshot (e.g. after a bulk cheat) is sent as a snapshot
+		patch, err := json.Marshal(diff(c.base, doc, "", []PatchOp{}))
+		if err != nil {
+			return err
+		}
+		if len(patch) < len(data) {
+			msg = Response{Type: "state_patch", Success: true, Seq: c.seq, Data: json.RawMessage(patch)}
+		}
+	}
+	c.base, c.baseJSON = doc, data
+	return c.write(msg)
+}
+
+// enableDeltas switches the client to patches, starting from a fresh snapshot
+func (c *client) enableDeltas(snapshot func() State) error {
+	c.mu.Lock()
+	c.deltas = true
+	c.base, c.baseJSON = nil, nil
+	c.mu.Unlock()
+	return c.sendState(snapshot, true)
+}
+
+// NewServer creates a fake game and starts its broadcast loop
+func NewServer(opts Options) *Server {
+	if opts.StateInterval == 0 {
+		opts.StateInterval = time.Second
+	}
+	if len(opts.Actions) == 0 {
+		opts.Actions = Actions
+	}
+	s := &Server{
+		opts:     opts,
+		upgrader: websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
+		world:    newWorld(opts.Seed),
+		clients:  make(map[*client]struct{}),
+		done:     make(chan struct{}),
+	}
+	if opts.StateInterval > 0 {
+		go s.broadcastLoop()
+	}
+	return s
+}
+
+// Handler serves the fake game at /game like the real mod
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/game", s)
+	return mux
+}
+
+// ListenAndServe serves the fake game at ws://addr/game until Close is called
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve serves the fake game at /game on ln until Close is called
+func (s *Server) Serve(ln net.Listener) error {
+	srv := &http.Server{Handler: s.Handler()}
+	go func() {
+		<-s.done
+		srv.Close()
+	}()
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close stops broadcasting and disconnects every client
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.clientsMu.Lock()
+		defer s.clientsMu.Unlock()
+		for c := range s.clients {
+			c.conn.Close()
+			delete(s.clients, c)
+		}
+	})
+}
+
+// State returns a snapshot of the current game state
+func (s *Server) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.world.snapshot()
+}
+
+// Tick advances the game clock by 10 minutes and broadcasts the new state
+func (s *Server) Tick() {
+	s.mu.Lock()
+	s.world.advanceClock()
+	s.mu.Unlock()
+	s.Broadcast()
+}
+
+// Broadcast sends the current state to every connected client
+func (s *Server) Broadcast() {
+	s.clientsMu.Lock()
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.clientsMu.Unlock()
+
+	for _, c := range clients {
+		if err := c.sendState(s.State, false); err != nil {
+			c.conn.Close()
+		}
+	}
+}
+
+func (s *Server) broadcastLoop() {
+	ticker := time.NewTicker(s.opts.StateInterval)
+	defer ticker.Stop()
+
+	for n := 1; ; n++ {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if n%broadcastsPerClockStep == 0 {
+				s.Tick()
+			} else {
+				s.Broadcast()
+			}
+		}
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[FAKE GAME] WebSocket upgrade failed: %v", err)
+		return
+	}
+	c := &client{conn: conn}
+
+	s.clientsMu.Lock()
+	select {
+	case <-s.done:
+		s.clientsMu.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	s.clients[c] = struct{}{}
+	s.clientsMu.Unlock()
+
+	defer func() {
+		s.clientsMu.Lock()
+		delete(s.clients, c)
+		s.clientsMu.Unlock()
+		conn.Close()
+	}()
+
+	// The mod greets every new connection with the current state
+	c.sendState(s.State, true)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.send(Response{Type: "error", Message: err.Error()})
+			continue
+		}
+
+		switch strings.ToLower(msg.Type) {
+		case "command":
+			s.handleCommand(c, msg)
+		case "batch":
+			if s.opts.Legacy {
+				c.send(Response{Type: "error", Message: "Unknown message type: " + msg.Type})
+				break
+			}
+			s.handleBatch(c, msg)
+		case "get_state":
+			// Always a full snapshot, which is how clients resync after a gap
+			c.sendState(s.State, true)
+		case "hello":
+			if s.opts.Legacy {
+				c.send(Response{Type: "error", Message: "Unknown message type: " + msg.Type})
+				break
+			}
+			c.send(Response{ID: msg.ID, Type: "hello", Success: true, Data: map[string]interface{}{
+				"protocolVersion": ProtocolVersion,
+				"modVersion":      ModVersion,
+				"actions":         s.opts.Actions,
+				"features":        []string{"state_deltas", "batch", "dry_run"},
+			}})
+		case "enable_deltas":
+			if s.opts.Legacy {
+				c.send(Response{Type: "error", Message: "Unknown message type: " + msg.Type})
+				break
+			}
+			c.enableDeltas(s.State)
+		case "ping":
+			c.send(Response{ID: msg.ID, Type: "pong", Success: true})
+		default:
+			c.send(Response{Type: "error", Message: "Unknown message type: " + msg.Type})
+		}
+	}
+}
+
+// handleCommand executes a command, pushes the resulting state to every client
+// and then answers the caller, so the state is current by the time the response lands
+func (s *Server) handleCommand(c *client, msg Message) {
+	var res result
+	if s.supports(msg.Action) {
+		s.mu.Lock()
+		res = s.world.execute(msg.Action, msg.Params)
+		s.mu.Unlock()
+	} else {
+		res = fail(fmt.Sprintf("Unknown action: %s", msg.Action))
+	}
+
+	s.Broadcast()
+
+	resp := Response{
+		ID:      msg.ID,
+		Type:    "response",
+		Success: res.success,
+		Message: res.message,
+	}
+	if res.data != nil {
+		resp.Data = res.data
+	}
+	c.send(resp)
+}
+
+// handleBatch runs the steps of a batch back to back with no other command in
+// between, then answers once with one result per step like the mod does
+func (s *Server) handleBatch(c *client, msg Message) {
+	rawSteps, _ := msg.Params["steps"].([]interface{})
+	if len(rawSteps) == 0 {
+		c.send(Response{ID: msg.ID, Type: "response", Message: "Batch needs a non-empty steps array"})
+		return
+	}
+	stopOnFailure := true
+	if v, ok := msg.Params["stopOnFailure"].(bool); ok {
+		stopOnFailure = v
+	}
+
+	results := make([]map[string]interface{}, 0, len(rawSteps))
+	failed, executed, stopped := 0, 0, false
+	s.mu.Lock()
+	for _, raw := range rawSteps {
+		step, _ := raw.(map[string]interface{})
+		action, _ := step["action"].(string)
+		if stopped {
+			results = append(results, map[string]interface{}{
+				"action": action, "success": false, "skipped": true, "message": "Skipped after a failed step",
+			})
+			continue
+		}
+
+		executed++
+		params, _ := step["params"].(map[string]interface{})
+		expect, _ := step["expect"].(map[string]interface{})
+		var res result
+		if s.supports(action) {
+			res = s.world.execute(action, params)
+		} else {
+			res = fail(fmt.Sprintf("Unknown action: %s", action))
+		}
+		if res.success {
+			if reason := unmetExpectation(expect, res.data); reason != "" {
+				res.success = false
+				res.message = fmt.Sprintf("%s (%s)", res.message, reason)
+			}
+		}
+
+		r := map[string]interface{}{"action": action, "success": res.success, "message": res.message}
+		if res.data != nil {
+			r["data"] = res.data
+		}
+		results = append(results, r)
+		if !res.success {
+			failed++
+			stopped = stopOnFailure
+		}
+	}
+	s.mu.Unlock()
+
+	s.Broadcast()
+
+	message := fmt.Sprintf("Batch completed: %d of %d steps succeeded", len(rawSteps)-failed, len(rawSteps))
+	if stopped {
+		message = fmt.Sprintf("Batch stopped at step %d of %d: %s failed", executed, len(rawSteps), results[executed-1]["action"])
+	}
+	c.send(Response{
+		ID:      msg.ID,
+		Type:    "response",
+		Success: failed == 0,
+		Message: message,
+		Data:    map[string]interface{}{"results": results},
+	})
+}
+
+func (s *Server) supports(action string) bool {
+	for _, a := range s.opts.Actions {
+		if strings.EqualFold(a, action) {
+			return true
+		}
+	}
+	return false
+}
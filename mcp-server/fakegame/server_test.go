@@ -0,0 +1,185 @@
+package fakegame
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialFake mounts a fake game without a broadcast loop and connects to it
+func dialFake(t *testing.T) (*Server, *websocket.Conn) {
+	t.Helper()
+	fake := NewServer(Options{StateInterval: -1})
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Cleanup(fake.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/game", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return fake, conn
+}
+
+// readUntil reads messages until one of type typ arrives
+func readUntil(t *testing.T, conn *websocket.Conn, typ string) json.RawMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", typ, err)
+		}
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &head); err != nil {
+			t.Fatalf("bad message %s: %v", data, err)
+		}
+		if head.Type == typ {
+			return data
+		}
+	}
+}
+
+type wireResponse struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Seq     int64           `json:"seq"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func decodeResponse(t *testing.T, data json.RawMessage) wireResponse {
+	t.Helper()
+	var resp wireResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("bad response %s: %v", data, err)
+	}
+	return resp
+}
+
+func TestHello(t *testing.T) {
+	_, conn := dialFake(t)
+	readUntil(t, conn, "state")
+
+	conn.WriteJSON(Message{ID: "h1", Type: "hello"})
+	resp := decodeResponse(t, readUntil(t, conn, "hello"))
+	if resp.ID != "h1" || !resp.Success {
+		t.Fatalf("hello = %+v", resp)
+	}
+	var hello struct {
+		ProtocolVersion int      `json:"protocolVersion"`
+		Actions         []string `json:"actions"`
+		Features        []string `json:"features"`
+	}
+	json.Unmarshal(resp.Data, &hello)
+	if hello.ProtocolVersion != ProtocolVersion || len(hello.Actions) != len(Actions) {
+		t.Errorf("hello data = %s", resp.Data)
+	}
+	if !strings.Contains(strings.Join(hello.Features, ","), "state_deltas") {
+		t.Errorf("features %v lack state_deltas", hello.Features)
+	}
+}
+
+func TestCommandUpdatesStateBeforeResponse(t *testing.T) {
+	_, conn := dialFake(t)
+	readUntil(t, conn, "state")
+
+	conn.WriteJSON(Message{ID: "c1", Type: "command", Action: "face_direction", Params: map[string]interface{}{"direction": "up"}})
+
+	// The broadcast lands before the response, so the caller sees the new state first
+	var state State
+	json.Unmarshal(decodeResponse(t, readUntil(t, conn, "state")).Data, &state)
+	if state.Player.FacingDirection != 0 {
+		t.Errorf("facing = %d, want 0 (up)", state.Player.FacingDirection)
+	}
+	resp := decodeResponse(t, readUntil(t, conn, "response"))
+	if resp.ID != "c1" || !resp.Success {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	conn.WriteJSON(Message{ID: "c2", Type: "command", Action: "face_direction", Params: map[string]interface{}{"direction": "sideways"}})
+	resp = decodeResponse(t, readUntil(t, conn, "response"))
+	if resp.ID != "c2" || resp.Success || resp.Message == "" {
+		t.Fatalf("invalid direction response = %+v", resp)
+	}
+
+	conn.WriteJSON(Message{ID: "c3", Type: "command", Action: "no_such_action"})
+	resp = decodeResponse(t, readUntil(t, conn, "response"))
+	if resp.Success || !strings.Contains(resp.Message, "Unknown action") {
+		t.Fatalf("unknown action response = %+v", resp)
+	}
+}
+
+func TestGetStateAndPing(t *testing.T) {
+	fake, conn := dialFake(t)
+	readUntil(t, conn, "state")
+
+	conn.WriteJSON(Message{Type: "get_state"})
+	var state State
+	json.Unmarshal(decodeResponse(t, readUntil(t, conn, "state")).Data, &state)
+	if want := fake.State(); state.Player.X != want.Player.X || state.Time.TimeOfDay != want.Time.TimeOfDay {
+		t.Errorf("state = %+v, want %+v", state.Player, want.Player)
+	}
+
+	conn.WriteJSON(Message{ID: "p1", Type: "ping"})
+	if resp := decodeResponse(t, readUntil(t, conn, "pong")); resp.ID != "p1" {
+		t.Errorf("pong id = %q", resp.ID)
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	fake, conn := dialFake(t)
+	readUntil(t, conn, "state")
+
+	conn.WriteJSON(Message{Type: "enable_deltas"})
+	snapshot := decodeResponse(t, readUntil(t, conn, "state"))
+	if snapshot.Seq != 1 {
+		t.Fatalf("first snapshot seq = %d, want 1", snapshot.Seq)
+	}
+	var doc map[string]interface{}
+	json.Unmarshal(snapshot.Data, &doc)
+
+	// An idle broadcast is an empty patch
+	fake.Broadcast()
+	idle := decodeResponse(t, readUntil(t, conn, "state_patch"))
+	if idle.Seq != 2 || string(idle.Data) != "[]" {
+		t.Fatalf("idle patch = seq %d %s", idle.Seq, idle.Data)
+	}
+
+	// A clock step only patches the time
+	fake.Tick()
+	patch := decodeResponse(t, readUntil(t, conn, "state_patch"))
+	if patch.Seq != 3 {
+		t.Fatalf("patch seq = %d, want 3", patch.Seq)
+	}
+	var ops []PatchOp
+	if err := json.Unmarshal(patch.Data, &ops); err != nil || len(ops) == 0 {
+		t.Fatalf("patch = %s (%v)", patch.Data, err)
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Path, "/time/") {
+			t.Errorf("clock step patched %s", op.Path)
+		}
+		if op.Path == "/time/timeOfDay" {
+			var tod int
+			json.Unmarshal(op.Value, &tod)
+			if tod != fake.State().Time.TimeOfDay {
+				t.Errorf("timeOfDay patch = %d, want %d", tod, fake.State().Time.TimeOfDay)
+			}
+		}
+	}
+
+	// get_state always resyncs with a full snapshot
+	conn.WriteJSON(Message{Type: "get_state"})
+	if full := decodeResponse(t, readUntil(t, conn, "state")); full.Seq != 4 {
+		t.Errorf("resync snapshot seq = %d, want 4", full.Seq)
+	}
+}
@@ -0,0 +1,540 @@
+package fakegame
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ============================================================================
+// Simulated farm
+// ============================================================================
+
+const (
+	scanRadius = 30 // matches the mod's 61x61 vision
+
+	farmWidth  = 80
+	farmHeight = 65
+	spawnX     = 64
+	spawnY     = 15
+	doorX      = 64
+	doorY      = 14
+	bedX       = 64 // in the FarmHouse, which reuses the farm grid
+	bedY       = 17
+
+	maxEnergy = 270
+	maxHealth = 100
+)
+
+type tileKind int
+
+const (
+	tileGround tileKind = iota
+	tileWall
+	tileWater
+)
+
+// tile is one cell of the farm grid
+type tile struct {
+	kind     tileKind
+	object   string // Weeds, Stone, Twig or empty
+	tree     bool
+	hoed     bool
+	watered  bool
+	crop     string
+	cropDays int // days until harvest; 0 with a crop means ready
+}
+
+func (t *tile) passable() bool {
+	return t.kind == tileGround && t.object == "" && !t.tree && (t.crop == "" || t.cropDays == 0)
+}
+
+func (t *tile) tillable() bool {
+	return t.kind == tileGround && t.object == "" && !t.tree && !t.hoed
+}
+
+// world holds the simulation; callers must hold Server.mu
+type world struct {
+	tiles  [][]tile
+	width  int
+	height int
+
+	location  string
+	x, y      int
+	facing    int
+	energy    float64
+	health    int
+	money     int
+	toolIndex int
+	inventory []InventoryItem
+
+	timeOfDay int
+	day       int
+	seasonIdx int
+	year      int
+
+	cheatMode bool
+}
+
+var (
+	seasons      = []string{"spring", "summer", "fall", "winter"}
+	weekdays     = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	facings      = []string{"up", "right", "down", "left"}
+	starterItems = []InventoryItem{
+		{Name: "Axe", Category: "Tool", IsTool: true},
+		{Name: "Hoe", Category: "Tool", IsTool: true},
+		{Name: "Watering Can", Category: "Tool", IsTool: true},
+		{Name: "Pickaxe", Category: "Tool", IsTool: true},
+		{Name: "Scythe", Category: "Tool", IsTool: true, IsWeapon: true},
+		{Name: "Parsnip Seeds", Category: "Seed", Stack: 15},
+	}
+
+	// foods lists what the edible items restore as {energy, health}, also
+	// under the ids cheat_add_item names items after
+	foods = map[string][2]int{
+		"Parsnip":     {25, 11},
+		"Salmonberry": {25, 11},
+		"Salad":       {113, 50},
+		"Field Snack": {45, 20},
+		"(O)24":       {25, 11},
+		"(O)196":      {113, 50},
+	}
+)
+
+// newWorld generates a farm from seed. The same seed always yields the same farm.
+func newWorld(seed int64) *world {
+	rng := rand.New(rand.NewSource(seed))
+	w := &world{
+		width:     farmWidth,
+		height:    farmHeight,
+		location:  "Farm",
+		x:         spawnX,
+		y:         spawnY,
+		facing:    2,
+		energy:    maxEnergy,
+		health:    maxHealth,
+		money:     500,
+		timeOfDay: 600,
+		day:       1,
+		year:      1,
+	}
+
+	w.tiles = make([][]tile, w.height)
+	for y := range w.tiles {
+		w.tiles[y] = make([]tile, w.width)
+		for x := range w.tiles[y] {
+			t := &w.tiles[y][x]
+			switch {
+			case x == 0 || y == 0 || x == w.width-1 || y == w.height-1:
+				t.kind = tileWall
+			case x >= 59 && x <= 69 && y >= 9 && y <= 14:
+				t.kind = tileWall // farmhouse
+			case (x-20)*(x-20)+(y-50)*(y-50) <= 25:
+				t.kind = tileWater // pond
+			}
+		}
+	}
+
+	// Scatter debris, keeping the area around the farmhouse door clear
+	for y := 1; y < w.height-1; y++ {
+		for x := 1; x < w.width-1; x++ {
+			t := &w.tiles[y][x]
+			if t.kind != tileGround || (abs(x-spawnX) <= 4 && abs(y-spawnY) <= 3) {
+				continue
+			}
+			switch r := rng.Intn(100); {
+			case r < 6:
+				t.object = "Weeds"
+			case r < 10:
+				t.object = "Stone"
+			case r < 13:
+				t.object = "Twig"
+			case r < 16:
+				t.tree = true
+			}
+		}
+	}
+
+	w.inventory = make([]InventoryItem, len(starterItems))
+	copy(w.inventory, starterItems)
+	w.reindexInventory()
+	return w
+}
+
+func (w *world) at(x, y int) *tile {
+	if x < 0 || y < 0 || x >= w.width || y >= w.height {
+		return nil
+	}
+	return &w.tiles[y][x]
+}
+
+// front returns the coordinates of the tile the player is facing
+func (w *world) front() (int, int) {
+	switch w.facing {
+	case 0:
+		return w.x, w.y - 1
+	case 1:
+		return w.x + 1, w.y
+	case 3:
+		return w.x - 1, w.y
+	default:
+		return w.x, w.y + 1
+	}
+}
+
+func (w *world) currentTool() string {
+	if w.toolIndex < len(w.inventory) && w.inventory[w.toolIndex].IsTool {
+		return w.inventory[w.toolIndex].Name
+	}
+	return ""
+}
+
+func (w *world) reindexInventory() {
+	for i := range w.inventory {
+		w.inventory[i].Slot = i
+		if w.inventory[i].DisplayName == "" {
+			w.inventory[i].DisplayName = w.inventory[i].Name
+		}
+		if w.inventory[i].Stack == 0 {
+			w.inventory[i].Stack = 1
+		}
+		if food, edible := foods[w.inventory[i].Name]; edible {
+			w.inventory[i].IsEdible = true
+			w.inventory[i].EnergyRestored, w.inventory[i].HealthRestored = food[0], food[1]
+		}
+	}
+}
+
+func (w *world) addItem(name, category string, count int) {
+	for i := range w.inventory {
+		if w.inventory[i].Name == name {
+			w.inventory[i].Stack += count
+			return
+		}
+	}
+	w.inventory = append(w.inventory, InventoryItem{Name: name, Category: category, Stack: count})
+	w.reindexInventory()
+}
+
+// removeItem takes one item out of a slot, dropping the slot when it empties
+func (w *world) removeItem(slot int) {
+	if w.inventory[slot].Stack > 1 {
+		w.inventory[slot].Stack--
+		return
+	}
+	w.inventory = append(w.inventory[:slot], w.inventory[slot+1:]...)
+	w.reindexInventory()
+}
+
+// findPath runs a breadth-first search over passable tiles and returns the path length
+func (w *world) findPath(tx, ty int) (int, bool) {
+	target := w.at(tx, ty)
+	if target == nil || !target.passable() {
+		return 0, false
+	}
+	type point struct{ x, y int }
+	dist := map[point]int{{w.x, w.y}: 0}
+	queue := []point{{w.x, w.y}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p.x == tx && p.y == ty {
+			return dist[p], true
+		}
+		for _, d := range []point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			n := point{p.x + d.x, p.y + d.y}
+			if _, seen := dist[n]; seen {
+				continue
+			}
+			if t := w.at(n.x, n.y); t != nil && t.passable() {
+				dist[n] = dist[p] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return 0, false
+}
+
+// applyTool uses the named tool on the tile at (x, y) and reports what happened
+func (w *world) applyTool(tool string, x, y int) string {
+	t := w.at(x, y)
+	if t == nil {
+		return "nothing"
+	}
+
+	cost := 2.0
+	result := "nothing"
+	switch tool {
+	case "Hoe":
+		if t.tillable() {
+			t.hoed = true
+			result = "tilled"
+		}
+	case "Watering Can":
+		if t.hoed && !t.watered {
+			t.watered = true
+			result = "watered"
+		}
+	case "Pickaxe":
+		if t.object == "Stone" {
+			t.object = ""
+			w.addItem("Stone", "Resource", 1)
+			result = "broke Stone"
+		} else if t.hoed && t.crop == "" {
+			t.hoed, t.watered = false, false
+			result = "removed Hoe Dirt"
+		}
+	case "Axe":
+		if t.tree {
+			t.tree = false
+			w.addItem("Wood", "Resource", 10)
+			result = "chopped Tree"
+		} else if t.object == "Twig" {
+			t.object = ""
+			w.addItem("Wood", "Resource", 1)
+			result = "chopped Twig"
+		}
+	case "Scythe":
+		cost = 0
+		if t.object == "Weeds" {
+			t.object = ""
+			result = "cut Weeds"
+		} else if t.crop != "" && t.cropDays == 0 {
+			result = "harvested " + w.harvest(t)
+		}
+	}
+
+	w.energy -= cost
+	if w.energy < 0 {
+		w.energy = 0
+	}
+	return result
+}
+
+func (w *world) harvest(t *tile) string {
+	crop := t.crop
+	t.crop, t.cropDays = "", 0
+	w.addItem(crop, "Vegetable", 1)
+	return crop
+}
+
+// advanceClock moves the game clock by 10 minutes, starting a new day at 2:00 AM
+func (w *world) advanceClock() {
+	w.timeOfDay += 10
+	if w.timeOfDay%100 == 60 {
+		w.timeOfDay += 40
+	}
+	if w.timeOfDay >= 2600 {
+		w.newDay()
+	}
+}
+
+// newDay grows watered crops, dries the soil and wakes the player at home
+func (w *world) newDay() {
+	for y := range w.tiles {
+		for x := range w.tiles[y] {
+			t := &w.tiles[y][x]
+			if t.crop != "" && t.watered && t.cropDays > 0 {
+				t.cropDays--
+			}
+			t.watered = false
+		}
+	}
+	w.timeOfDay = 600
+	w.day++
+	if w.day > 28 {
+		w.day = 1
+		w.seasonIdx = (w.seasonIdx + 1) % len(seasons)
+		if w.seasonIdx == 0 {
+			w.year++
+		}
+	}
+	w.location, w.x, w.y, w.facing = "Farm", spawnX, spawnY, 2
+	w.energy = maxEnergy
+}
+
+// forEachInRadius visits every in-bounds tile within radius of the player
+func (w *world) forEachInRadius(radius int, fn func(x, y int, t *tile)) {
+	for y := w.y - radius; y <= w.y+radius; y++ {
+		for x := w.x - radius; x <= w.x+radius; x++ {
+			if t := w.at(x, y); t != nil {
+				fn(x, y, t)
+			}
+		}
+	}
+}
+
+// snapshot builds the state payload exactly as the mod would serialize it
+func (w *world) snapshot() State {
+	fx, fy := w.front()
+	state := State{
+		Player: PlayerState{
+			Name:                "Fake Farmer",
+			X:                   w.x,
+			Y:                   w.y,
+			Location:            w.location,
+			Energy:              w.energy,
+			MaxEnergy:           maxEnergy,
+			Health:              w.health,
+			MaxHealth:           maxHealth,
+			Money:               w.money,
+			CurrentTool:         w.currentTool(),
+			CurrentToolIndex:    w.toolIndex,
+			FacingDirection:     w.facing,
+			FacingDirectionName: facings[w.facing],
+			CanMove:             true,
+			Inventory:           append([]InventoryItem(nil), w.inventory...),
+		},
+		Time: TimeState{
+			TimeOfDay:           w.timeOfDay,
+			TimeString:          formatTime(w.timeOfDay),
+			Day:                 w.day,
+			Season:              seasons[w.seasonIdx],
+			Year:                w.year,
+			DayOfWeek:           weekdays[(w.day-1)%7],
+			IsNight:             w.timeOfDay >= 1800,
+			MinutesUntilMorning: minutesUntilMorning(w.timeOfDay),
+		},
+		World: WorldState{
+			Weather:      "sunny",
+			IsOutdoors:   true,
+			IsFarm:       w.location == "Farm",
+			LocationType: w.location,
+		},
+		Map: MapInfo{
+			Name:        w.location,
+			DisplayName: w.location,
+			Width:       w.width,
+			Height:      w.height,
+		},
+	}
+
+	if w.location == "FarmHouse" {
+		state.Map.Bed = &Tile{X: bedX, Y: bedY}
+	}
+
+	sur := &state.Surroundings
+	sur.AsciiMap = w.asciiMap()
+	sur.NearbyObjects = []NearbyObject{}
+	sur.NearbyTerrainFeatures = []NearbyTerrain{}
+	sur.WarpPoints = []WarpPoint{{X: doorX, Y: doorY, TargetLocation: "FarmHouse", IsDoor: true}}
+
+	w.forEachInRadius(scanRadius, func(x, y int, t *tile) {
+		if t.object != "" {
+			sur.NearbyObjects = append(sur.NearbyObjects, NearbyObject{
+				X: x, Y: y, Name: t.object, DisplayName: t.object, Type: "object",
+				RequiredTool: requiredToolFor(t), HitsRequired: 1,
+			})
+		}
+		switch {
+		case t.tree:
+			sur.NearbyTerrainFeatures = append(sur.NearbyTerrainFeatures, NearbyTerrain{
+				X: x, Y: y, Type: "tree", GrowthStage: 5, IsFullyGrown: true, CanBeChopped: true,
+				RequiredTool: "Axe", HitsRequired: 10,
+			})
+		case t.hoed:
+			sur.NearbyTerrainFeatures = append(sur.NearbyTerrainFeatures, NearbyTerrain{
+				X: x, Y: y, Type: "hoe_dirt", IsPassable: t.passable(), IsWatered: t.watered,
+				HasCrop: t.crop != "", CropName: t.crop, DaysUntilHarvest: t.cropDays,
+				IsReadyForHarvest: t.crop != "" && t.cropDays == 0, RequiredTool: requiredToolFor(t),
+			})
+		}
+	})
+
+	tif := TileInFront{X: fx, Y: fy}
+	if t := w.at(fx, fy); t != nil {
+		tif.IsPassable = t.passable()
+		tif.IsWater = t.kind == tileWater
+		tif.IsTillable = t.tillable()
+		tif.RequiredTool = requiredToolFor(t)
+		if t.object != "" {
+			tif.ObjectName, tif.ObjectType, tif.CanInteract = t.object, "object", true
+		}
+		if t.tree {
+			tif.TerrainType, tif.CanInteract = "tree", true
+		} else if t.hoed {
+			tif.TerrainType, tif.CanInteract = "hoe_dirt", true
+		}
+	}
+	sur.TileInFront = tif
+
+	return state
+}
+
+// asciiMap renders the 61x61 view using the mod's legend
+func (w *world) asciiMap() string {
+	var sb strings.Builder
+	for dy := -scanRadius; dy <= scanRadius; dy++ {
+		if dy > -scanRadius {
+			sb.WriteByte('\n')
+		}
+		for dx := -scanRadius; dx <= scanRadius; dx++ {
+			x, y := w.x+dx, w.y+dy
+			t := w.at(x, y)
+			switch {
+			case dx == 0 && dy == 0:
+				sb.WriteByte('@')
+			case t == nil || t.kind == tileWall:
+				if x == doorX && y == doorY {
+					sb.WriteByte('>')
+				} else {
+					sb.WriteByte('#')
+				}
+			case t.kind == tileWater:
+				sb.WriteByte('~')
+			case t.tree:
+				sb.WriteByte('T')
+			case t.object != "":
+				sb.WriteByte('O')
+			case t.crop != "":
+				sb.WriteByte('C')
+			case t.hoed:
+				sb.WriteByte('H')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func requiredToolFor(t *tile) string {
+	switch {
+	case t.object == "Weeds":
+		return "Scythe"
+	case t.object == "Stone":
+		return "Pickaxe"
+	case t.object == "Twig", t.tree:
+		return "Axe"
+	case t.crop != "" && t.cropDays == 0:
+		return "Scythe"
+	case t.hoed && t.crop == "" && !t.watered:
+		return "Watering Can"
+	}
+	return ""
+}
+
+func formatTime(t int) string {
+	hour, minute := t/100, t%100
+	suffix := "AM"
+	if hour%24 >= 12 {
+		suffix = "PM"
+	}
+	hour %= 12
+	if hour == 0 {
+		hour = 12
+	}
+	return fmt.Sprintf("%d:%02d %s", hour, minute, suffix)
+}
+
+func minutesUntilMorning(t int) int {
+	minutes := (t/100)*60 + t%100
+	return 30*60 - minutes // 6:00 AM the next day
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Agent feed - what the in-process agent is doing (tool calls and results,
+// thoughts, plan and goal changes, supervisor takeovers) for live displays
+// such as the -tui console. Listeners that fall behind miss events; the log
+// keeps the full record.
+// ============================================================================
+
+// AgentEvent is one step of the autonomous agent
+type AgentEvent struct {
+	Time time.Time
+	Kind string // tool, result, error, thought, plan, goal or supervisor
+	Text string
+}
+
+// AgentFeed fans agent events out to its subscribers
+type AgentFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan AgentEvent]struct{}
+}
+
+// agentFeed carries the events of the in-process agent
+var agentFeed = &AgentFeed{}
+
+// Subscribe returns a channel of agent events and a function that stops them
+func (f *AgentFeed) Subscribe() (<-chan AgentEvent, func()) {
+	ch := make(chan AgentEvent, 64)
+
+	f.mu.Lock()
+	if f.subscribers == nil {
+		f.subscribers = make(map[chan AgentEvent]struct{})
+	}
+	f.subscribers[ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.subscribers, ch)
+		f.mu.Unlock()
+	}
+}
+
+// Publish sends an event to every subscriber that has room for it
+func (f *AgentFeed) Publish(kind, format string, args ...interface{}) {
+	event := AgentEvent{Time: time.Now(), Kind: kind, Text: fmt.Sprintf(format, args...)}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -4,27 +4,30 @@ go 1.23.2
 
 require (
 	github.com/charmbracelet/bubbles v0.18.0
-	github.com/charmbracelet/charm v0.14.1
+	github.com/charmbracelet/bubbletea v0.25.0
 	github.com/charmbracelet/lipgloss v0.11.0
 	github.com/github/copilot-sdk/go v0.1.16
+	github.com/google/jsonschema-go v0.4.2
 	github.com/gorilla/websocket v1.5.3
-	github.com/muesli/termenv v0.15.2
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/charmbracelet/x/ansi v0.5.0 // indirect
-	github.com/charmbracelet/x/exp v0.8.0 // indirect
-	github.com/charmbracelet/x/term v0.2.0 // indirect
-	github.com/google/jsonschema-go v0.4.2 // indirect
+	github.com/atotto/clipboard v0.1.4 // indirect
+	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
+	github.com/charmbracelet/x/ansi v0.1.1 // indirect
+	github.com/containerd/console v1.0.4-0.20230313162750-1ae8d489ac81 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/muesli/ansi v0.0.0-20230316142956-30d1d4c0f369 // indirect
+	github.com/mattn/go-localereader v0.0.1 // indirect
+	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/muesli/ansi v0.0.0-20211018074035-2e021307bc4b // indirect
 	github.com/muesli/cancelreader v0.2.2 // indirect
 	github.com/muesli/reflow v0.3.0 // indirect
-	github.com/rivo/uniseg v0.4.4 // indirect
-	github.com/xo/terminfo v0.0.0-20230922191635-9a9b7243c87a // indirect
+	github.com/muesli/termenv v0.15.2 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/term v0.21.0 // indirect
-	golang.org/x/tools v0.21.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Goal queue - the goals the autonomous agent works through, head first. The
+// agent advances to the next goal when the head is done. Operators enqueue,
+// reorder, cancel, pause and resume through agent tools, HTTP (/goals) and
+// "goals" messages on the remote WebSocket. The queue is saved to
+// agent.goal_queue_file after every change, so it survives restarts.
+// ============================================================================
+
+// ErrGoalNotFound is returned for ids that are not in the queue
+var ErrGoalNotFound = errors.New("no queued goal with that id")
+
+// QueuedGoal is one goal waiting in (or at the head of) the queue
+type QueuedGoal struct {
+	ID      int       `json:"id"`
+	Text    string    `json:"text"`
+	Done    []string  `json:"done,omitempty"`
+	AddedBy string    `json:"addedBy,omitempty"`
+	AddedAt time.Time `json:"addedAt"`
+
+	goal Goal
+}
+
+// Goal returns the queued goal with its done conditions parsed
+func (q QueuedGoal) Goal() Goal {
+	return q.goal
+}
+
+// GoalQueueSnapshot is the queue as shown to operators
+type GoalQueueSnapshot struct {
+	Paused bool         `json:"paused"`
+	Goals  []QueuedGoal `json:"goals"`
+}
+
+// goalQueueFile is the queue as saved to disk
+type goalQueueFile struct {
+	NextID int          `json:"nextId"`
+	Paused bool         `json:"paused"`
+	Goals  []QueuedGoal `json:"goals"`
+}
+
+// GoalQueue is an ordered, persistent list of goals
+type GoalQueue struct {
+	mu      sync.Mutex
+	path    string // "" keeps the queue in memory only
+	nextID  int
+	paused  bool
+	goals   []QueuedGoal
+	changed chan struct{} // closed and replaced on every change
+}
+
+// goalQueue is the queue of the in-process agent
+var goalQueue = NewGoalQueue("")
+
+// NewGoalQueue returns an empty queue saved to path
+func NewGoalQueue(path string) *GoalQueue {
+	return &GoalQueue{path: path, nextID: 1, changed: make(chan struct{})}
+}
+
+// LoadGoalQueue reads the queue saved at path; a missing file is an empty queue
+func LoadGoalQueue(path string) (*GoalQueue, error) {
+	q := NewGoalQueue(path)
+	if path == "" {
+		return q, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read goal queue: %w", err)
+	}
+	var file goalQueueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse goal queue %s: %w", path, err)
+	}
+
+	q.paused = file.Paused
+	for _, g := range file.Goals {
+		goal, err := NewGoal(g.Text, g.Done)
+		if err != nil {
+			log.Printf("[GOALS] Dropping saved goal %d: %v", g.ID, err)
+			continue
+		}
+		g.goal = goal
+		q.goals = append(q.goals, g)
+		q.nextID = max(q.nextID, g.ID+1)
+	}
+	q.nextID = max(q.nextID, file.NextID)
+	return q, nil
+}
+
+// Enqueue adds a goal at position (1 is the head; 0 or past the end appends)
+func (q *GoalQueue) Enqueue(text string, done []string, position int, addedBy string) (QueuedGoal, error) {
+	if text == "" {
+		return QueuedGoal{}, fmt.Errorf("goal text is empty")
+	}
+	goal, err := NewGoal(text, done)
+	if err != nil {
+		return QueuedGoal{}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	g := QueuedGoal{ID: q.nextID, Text: text, Done: done, AddedBy: addedBy, AddedAt: time.Now(), goal: goal}
+	q.nextID++
+	i := len(q.goals)
+	if position > 0 && position <= len(q.goals) {
+		i = position - 1
+	}
+	q.goals = append(q.goals[:i], append([]QueuedGoal{g}, q.goals[i:]...)...)
+	q.changedLocked()
+	log.Printf("[GOALS] Queued goal %d at position %d for %s: %s", g.ID, i+1, addedBy, text)
+	return g, nil
+}
+
+// Move puts a goal at position, 1 being the head
+func (q *GoalQueue) Move(id, position int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from := q.indexLocked(id)
+	if from < 0 {
+		return fmt.Errorf("%w: %d", ErrGoalNotFound, id)
+	}
+	to := min(max(position, 1), len(q.goals)) - 1
+	g := q.goals[from]
+	q.goals = append(q.goals[:from], q.goals[from+1:]...)
+	q.goals = append(q.goals[:to], append([]QueuedGoal{g}, q.goals[to:]...)...)
+	q.changedLocked()
+	log.Printf("[GOALS] Moved goal %d to position %d", id, to+1)
+	return nil
+}
+
+// Cancel removes a goal; cancelling the head moves the agent to the next one
+func (q *GoalQueue) Cancel(id int) (QueuedGoal, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.indexLocked(id)
+	if i < 0 {
+		return QueuedGoal{}, fmt.Errorf("%w: %d", ErrGoalNotFound, id)
+	}
+	g := q.goals[i]
+	q.goals = append(q.goals[:i], q.goals[i+1:]...)
+	q.changedLocked()
+	log.Printf("[GOALS] Cancelled goal %d: %s", id, g.Text)
+	return g, nil
+}
+
+// Complete removes a finished goal, reporting whether it was still queued
+func (q *GoalQueue) Complete(id int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.indexLocked(id)
+	if i < 0 {
+		return false
+	}
+	q.goals = append(q.goals[:i], q.goals[i+1:]...)
+	q.changedLocked()
+	log.Printf("[GOALS] Goal %d complete, %d goal(s) left", id, len(q.goals))
+	return true
+}
+
+// SetPaused pauses or resumes the agent; a paused agent keeps its queue
+func (q *GoalQueue) SetPaused(paused bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused == paused {
+		return
+	}
+	q.paused = paused
+	q.changedLocked()
+	if paused {
+		log.Printf("[GOALS] Goal queue paused")
+	} else {
+		log.Printf("[GOALS] Goal queue resumed")
+	}
+}
+
+// Head returns the goal to work on, or false while the queue is empty or paused
+func (q *GoalQueue) Head() (QueuedGoal, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused || len(q.goals) == 0 {
+		return QueuedGoal{}, false
+	}
+	return q.goals[0], true
+}
+
+// Len returns the number of queued goals
+func (q *GoalQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.goals)
+}
+
+// Snapshot returns the queue for display
+func (q *GoalQueue) Snapshot() GoalQueueSnapshot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return GoalQueueSnapshot{Paused: q.paused, Goals: append([]QueuedGoal{}, q.goals...)}
+}
+
+// Changed returns a channel that is closed at the next change of the queue
+func (q *GoalQueue) Changed() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.changed
+}
+
+func (q *GoalQueue) indexLocked(id int) int {
+	for i, g := range q.goals {
+		if g.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// changedLocked saves the queue and wakes everyone waiting on Changed
+func (q *GoalQueue) changedLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+	if err := q.saveLocked(); err != nil {
+		log.Printf("[GOALS] Failed to save goal queue: %v", err)
+	}
+}
+
+// saveLocked writes the queue through a temporary file, so a crash never
+// leaves a half-written queue behind
+func (q *GoalQueue) saveLocked() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(goalQueueFile{NextID: q.nextID, Paused: q.paused, Goals: q.goals}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
+
+// GoalQueueParams are the arguments of a goal queue operation
+type GoalQueueParams struct {
+	ID       int      `json:"id"`
+	Text     string   `json:"text"`
+	Done     []string `json:"done"`
+	Position int      `json:"position"`
+}
+
+// goalQueueOp runs one operator request on the goal queue. Listing needs the
+// read scope, changing the queue the operator scope.
+func goalQueueOp(ctx context.Context, op string, params GoalQueueParams) (interface{}, error) {
+	scope := scopeOperator
+	if op == "list" {
+		scope = scopeRead
+	}
+	if err := authorize(ctx, scope, "goals "+op); err != nil {
+		log.Printf("[AUTH] %v", err)
+		return nil, err
+	}
+
+	switch op {
+	case "list":
+		return goalQueue.Snapshot(), nil
+	case "enqueue":
+		return goalQueue.Enqueue(params.Text, params.Done, params.Position, controllerFrom(ctx).label)
+	case "move":
+		if err := goalQueue.Move(params.ID, params.Position); err != nil {
+			return nil, err
+		}
+	case "cancel":
+		if _, err := goalQueue.Cancel(params.ID); err != nil {
+			return nil, err
+		}
+	case "pause":
+		goalQueue.SetPaused(true)
+	case "resume":
+		goalQueue.SetPaused(false)
+	default:
+		return nil, fmt.Errorf("unknown goal queue operation %q (use list, enqueue, move, cancel, pause or resume)", op)
+	}
+	return goalQueue.Snapshot(), nil
+}
+
+// serveGoalQueue handles one /goals endpoint of server mode
+func serveGoalQueue(op string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params GoalQueueParams
+		if r.Body != nil && r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				writeGoalQueueResponse(w, nil, fmt.Errorf("invalid JSON body: %w", err))
+				return
+			}
+		}
+		if id := r.PathValue("id"); id != "" {
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				writeGoalQueueResponse(w, nil, fmt.Errorf("%w: %s", ErrGoalNotFound, id))
+				return
+			}
+			params.ID = n
+		}
+
+		ctx := remoteController(r.Context(), "http:"+r.RemoteAddr, "operator "+r.RemoteAddr)
+		result, err := goalQueueOp(ctx, op, params)
+		writeGoalQueueResponse(w, result, err)
+	}
+}
+
+func writeGoalQueueResponse(w http.ResponseWriter, result interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{"success": err == nil}
+	if err != nil {
+		var scopeErr *ScopeError
+		switch {
+		case errors.As(err, &scopeErr):
+			w.WriteHeader(http.StatusForbidden)
+		case errors.Is(err, ErrGoalNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		response["error"] = err.Error()
+	} else {
+		response["data"] = result
+	}
+	json.NewEncoder(w).Encode(response)
+}
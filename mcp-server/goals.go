@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Goals - what the autonomous agent works towards and how it knows it is
+// done. A goal's Done conditions are checked against the game state, e.g.
+// "money >= 5000", "debris(10) == 0", "crops >= 15", "hearts(Abigail) >= 4"
+// or "mine_level >= 40". The agent stops once every condition holds. A goal
+// without conditions is done when the model reports it complete with
+// report_goal_status.
+// ============================================================================
+
+// Goal is one objective for the autonomous agent
+type Goal struct {
+	Text string   `yaml:"text" json:"text"`
+	Done []string `yaml:"done" json:"done,omitempty"`
+
+	conditions []GoalCondition
+}
+
+// NewGoal parses a goal's done conditions
+func NewGoal(text string, done []string) (Goal, error) {
+	g := Goal{Text: text, Done: done}
+	for _, raw := range done {
+		c, err := ParseGoalCondition(raw)
+		if err != nil {
+			return Goal{}, err
+		}
+		g.conditions = append(g.conditions, c)
+	}
+	return g, nil
+}
+
+// Checkable reports whether the goal has conditions the agent can verify itself
+func (g Goal) Checkable() bool {
+	return len(g.conditions) > 0
+}
+
+// GoalCondition compares one metric of the game state with a value
+type GoalCondition struct {
+	Raw    string
+	metric string
+	arg    string
+	op     string
+	value  string
+}
+
+// goalConditionPattern matches "metric op value" and "metric(arg) op value"
+var goalConditionPattern = regexp.MustCompile(`^\s*([A-Za-z_]+)\s*(?:\(\s*([^)]*?)\s*\))?\s*(>=|<=|==|!=|>|<)\s*(.+?)\s*$`)
+
+// goalRadius is how far from the player tile counts look without an argument;
+// the mod only reports objects within its 30-tile scan radius
+const goalRadius = 30
+
+// numericMetrics read a number from the state; arg is the text in parentheses
+var numericMetrics = map[string]func(state *GameState, arg string) (int, error){
+	"money":  func(s *GameState, _ string) (int, error) { return s.Player.Money, nil },
+	"energy": func(s *GameState, _ string) (int, error) { return int(s.Player.Energy), nil },
+	"health": func(s *GameState, _ string) (int, error) { return s.Player.Health, nil },
+	"day":    func(s *GameState, _ string) (int, error) { return s.Time.Day, nil },
+	"year":   func(s *GameState, _ string) (int, error) { return s.Time.Year, nil },
+	"time":   func(s *GameState, _ string) (int, error) { return s.Time.TimeOfDay, nil },
+	"mine_level": func(s *GameState, _ string) (int, error) {
+		if !s.Map.IsMineLevel {
+			return 0, nil
+		}
+		return s.Map.MineLevel, nil
+	},
+	"debris": func(s *GameState, arg string) (int, error) {
+		radius, err := goalRadiusArg(arg)
+		count := 0
+		for _, obj := range s.Surroundings.NearbyObjects {
+			// Debris is what needs a tool to break; chests and machines don't count
+			if obj.RequiredTool != "" && withinRadius(s, obj.X, obj.Y, radius) {
+				count++
+			}
+		}
+		return count, err
+	},
+	"trees": func(s *GameState, arg string) (int, error) {
+		radius, err := goalRadiusArg(arg)
+		count := 0
+		for _, tf := range s.Surroundings.NearbyTerrainFeatures {
+			if (tf.Type == "tree" || tf.Type == "fruit_tree") && withinRadius(s, tf.X, tf.Y, radius) {
+				count++
+			}
+		}
+		return count, err
+	},
+	"crops": func(s *GameState, arg string) (int, error) {
+		radius, err := goalRadiusArg(arg)
+		count := 0
+		for _, tf := range s.Surroundings.NearbyTerrainFeatures {
+			if tf.HasCrop && withinRadius(s, tf.X, tf.Y, radius) {
+				count++
+			}
+		}
+		return count, err
+	},
+	"ready_crops": func(s *GameState, arg string) (int, error) {
+		radius, err := goalRadiusArg(arg)
+		count := 0
+		for _, tf := range s.Surroundings.NearbyTerrainFeatures {
+			if tf.IsReadyForHarvest && withinRadius(s, tf.X, tf.Y, radius) {
+				count++
+			}
+		}
+		return count, err
+	},
+	"item": func(s *GameState, arg string) (int, error) {
+		if arg == "" {
+			return 0, fmt.Errorf("item needs a name, e.g. item(Parsnip)")
+		}
+		count := 0
+		for _, item := range s.Player.Inventory {
+			if strings.EqualFold(item.Name, arg) || strings.EqualFold(item.DisplayName, arg) {
+				count += item.Stack
+			}
+		}
+		return count, nil
+	},
+	"hearts": func(s *GameState, arg string) (int, error) {
+		if arg == "" {
+			return 0, fmt.Errorf("hearts needs an NPC, e.g. hearts(Abigail)")
+		}
+		for _, r := range s.Relationships {
+			if strings.EqualFold(r.NPCName, arg) {
+				return r.Hearts, nil
+			}
+		}
+		return 0, nil
+	},
+	"skill": func(s *GameState, arg string) (int, error) {
+		if s.Skills == nil {
+			return 0, fmt.Errorf("the mod does not report skills")
+		}
+		levels := map[string]int{
+			"farming": s.Skills.Farming, "mining": s.Skills.Mining, "foraging": s.Skills.Foraging,
+			"fishing": s.Skills.Fishing, "combat": s.Skills.Combat,
+		}
+		level, ok := levels[strings.ToLower(arg)]
+		if !ok {
+			return 0, fmt.Errorf("unknown skill %q (use farming, mining, foraging, fishing or combat)", arg)
+		}
+		return level, nil
+	},
+}
+
+// textMetrics read a name from the state; they support == and != only
+var textMetrics = map[string]func(state *GameState) string{
+	"location": func(s *GameState) string { return s.Player.Location },
+	"season":   func(s *GameState) string { return s.Time.Season },
+	"weather":  func(s *GameState) string { return s.World.Weather },
+}
+
+// ParseGoalCondition parses a condition such as "hearts(Abigail) >= 4"
+func ParseGoalCondition(raw string) (GoalCondition, error) {
+	m := goalConditionPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return GoalCondition{}, fmt.Errorf("invalid goal condition %q: want \"metric op value\", e.g. \"money >= 5000\"", raw)
+	}
+	c := GoalCondition{Raw: strings.TrimSpace(raw), metric: strings.ToLower(m[1]), arg: m[2], op: m[3], value: m[4]}
+
+	if _, ok := textMetrics[c.metric]; ok {
+		if c.op != "==" && c.op != "!=" {
+			return GoalCondition{}, fmt.Errorf("invalid goal condition %q: %s only supports == and !=", raw, c.metric)
+		}
+		return c, nil
+	}
+	read, ok := numericMetrics[c.metric]
+	if !ok {
+		return GoalCondition{}, fmt.Errorf("invalid goal condition %q: unknown metric %q", raw, c.metric)
+	}
+	if _, err := strconv.Atoi(c.value); err != nil {
+		return GoalCondition{}, fmt.Errorf("invalid goal condition %q: %s needs a whole number", raw, c.metric)
+	}
+	// Reading an empty state rejects bad arguments before the goal starts
+	if _, err := read(&GameState{Skills: &SkillsInfo{}}, c.arg); err != nil {
+		return GoalCondition{}, fmt.Errorf("invalid goal condition %q: %w", raw, err)
+	}
+	return c, nil
+}
+
+// Check evaluates the condition, returning whether it holds and the current value
+func (c GoalCondition) Check(state *GameState) (bool, string, error) {
+	if read, ok := textMetrics[c.metric]; ok {
+		actual := read(state)
+		equal := strings.EqualFold(actual, c.value)
+		return equal == (c.op == "=="), actual, nil
+	}
+
+	actual, err := numericMetrics[c.metric](state, c.arg)
+	if err != nil {
+		return false, "", err
+	}
+	want, _ := strconv.Atoi(c.value)
+	var met bool
+	switch c.op {
+	case ">=":
+		met = actual >= want
+	case "<=":
+		met = actual <= want
+	case ">":
+		met = actual > want
+	case "<":
+		met = actual < want
+	case "==":
+		met = actual == want
+	case "!=":
+		met = actual != want
+	}
+	return met, strconv.Itoa(actual), nil
+}
+
+// ConditionStatus is the outcome of one condition against a state
+type ConditionStatus struct {
+	Condition string `json:"condition"`
+	Met       bool   `json:"met"`
+	Actual    string `json:"actual,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// GoalStatus is the progress of a goal against a state
+type GoalStatus struct {
+	Goal       string            `json:"goal"`
+	Met        bool              `json:"met"`
+	Conditions []ConditionStatus `json:"conditions,omitempty"`
+}
+
+// Evaluate checks every condition of the goal against state. A goal without
+// conditions is never met by the state alone.
+func (g Goal) Evaluate(state *GameState) GoalStatus {
+	status := GoalStatus{Goal: g.Text, Met: g.Checkable()}
+	for _, c := range g.conditions {
+		cs := ConditionStatus{Condition: c.Raw}
+		met, actual, err := c.Check(state)
+		if err != nil {
+			cs.Error = err.Error()
+		}
+		cs.Met, cs.Actual = met, actual
+		status.Met = status.Met && met
+		status.Conditions = append(status.Conditions, cs)
+	}
+	return status
+}
+
+// Progress describes the conditions for the agent prompt, e.g.
+// "money >= 5000 (now 1200); crops >= 15 (met)"
+func (s GoalStatus) Progress() string {
+	parts := make([]string, 0, len(s.Conditions))
+	for _, c := range s.Conditions {
+		switch {
+		case c.Error != "":
+			parts = append(parts, fmt.Sprintf("%s (error: %s)", c.Condition, c.Error))
+		case c.Met:
+			parts = append(parts, c.Condition+" (met)")
+		default:
+			parts = append(parts, fmt.Sprintf("%s (now %s)", c.Condition, c.Actual))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// goalRadiusArg parses the optional radius of a tile count
+func goalRadiusArg(arg string) (int, error) {
+	if arg == "" {
+		return goalRadius, nil
+	}
+	radius, err := strconv.Atoi(arg)
+	if err != nil || radius < 0 {
+		return goalRadius, fmt.Errorf("radius must be a whole number of tiles, got %q", arg)
+	}
+	return radius, nil
+}
+
+// withinRadius reports whether a tile is within radius tiles of the player
+func withinRadius(state *GameState, x, y, radius int) bool {
+	return abs(x-state.Player.X) <= radius && abs(y-state.Player.Y) <= radius
+}
+
+// splitGoalConditions splits a "money >= 5000; crops >= 15" list from a flag
+// or environment variable
+func splitGoalConditions(list string) []string {
+	var done []string
+	for _, raw := range strings.Split(list, ";") {
+		if raw = strings.TrimSpace(raw); raw != "" {
+			done = append(done, raw)
+		}
+	}
+	return done
+}
+
+// Goal returns the goal the agent works towards
+func (a *StardewAgent) Goal() Goal {
+	a.goalMu.Lock()
+	defer a.goalMu.Unlock()
+	return a.goal
+}
+
+// goalQueueID returns the goal queue id of the goal the agent works towards
+func (a *StardewAgent) goalQueueID() int {
+	a.goalMu.Lock()
+	defer a.goalMu.Unlock()
+	return a.goalID
+}
+
+func (a *StardewAgent) setGoal(id int, goal Goal) {
+	a.goalMu.Lock()
+	defer a.goalMu.Unlock()
+	a.goalID, a.goal, a.goalReported = id, goal, false
+}
+
+func (a *StardewAgent) goalReportedComplete() bool {
+	a.goalMu.Lock()
+	defer a.goalMu.Unlock()
+	return a.goalReported
+}
+
+// reportGoalStatus handles report_goal_status. A goal with conditions is only
+// accepted as complete when the current state meets all of them.
+func (a *StardewAgent) reportGoalStatus(ctx context.Context, params ReportGoalStatusParams) (map[string]interface{}, error) {
+	goal := a.Goal()
+	if goal.Text == "" {
+		return nil, fmt.Errorf("no autonomous goal is running")
+	}
+	state := clientFrom(ctx).GetState()
+	if state == nil {
+		return nil, ErrDisconnected
+	}
+	status := goal.Evaluate(state)
+	if params.Summary != "" {
+		log.Printf("[AGENT GOAL] Reported %s: %s", params.Status, params.Summary)
+	} else {
+		log.Printf("[AGENT GOAL] Reported %s", params.Status)
+	}
+
+	result := map[string]interface{}{"status": status}
+	switch {
+	case params.Status != "complete":
+		result["message"] = "Status noted. Keep working towards the goal."
+	case !goal.Checkable():
+		a.goalMu.Lock()
+		a.goalReported = true
+		a.goalMu.Unlock()
+		result["message"] = "Goal marked complete."
+	case status.Met:
+		result["message"] = "Goal complete: every condition holds."
+	default:
+		result["message"] = "Not complete yet: " + status.Progress() + ". Keep working until every condition holds."
+	}
+	return result, nil
+}
@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -6,20 +8,20 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	tea "github.com/charmbracelet/charm"
 	"github.com/muesli/termenv"
 )
 
 func main() {
-	tea.InitializeTTY()
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if err := p.Start(); err != nil {
+	if _, err := p.Run(); err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
@@ -61,12 +63,12 @@ var (
 			Foreground(warningColor)
 
 	borderStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder).
+			BorderStyle(lipgloss.RoundedBorder()).
 			BorderForeground(primaryColor).
 			Padding(1, 2)
 
 	boxStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.DoubleBorder).
+			BorderStyle(lipgloss.DoubleBorder()).
 			BorderForeground(primaryColor).
 			Padding(1, 2)
 
@@ -94,11 +96,11 @@ var (
 // ============================================================================
 
 type model struct {
-	step           int
-	stardewPath   string
-	openclaw      bool
-	remote        bool
-	autoStart     bool
+	step        int
+	stardewPath string
+	openclaw    bool
+	remote      bool
+	autoStart   bool
 
 	// Welcome step
 	choiceSelected int
@@ -108,13 +110,13 @@ type model struct {
 	pathDetected string
 
 	// Install step
-	spinner       spinner.Model
-	progress      progress.Model
-	logs          viewport.Model
-	logLines      []string
-	installing    bool
-	installError  string
-	installDone   bool
+	spinner      spinner.Model
+	progress     progress.Model
+	logs         viewport.Model
+	logLines     []string
+	installing   bool
+	installError string
+	installDone  bool
 
 	// Final
 	width  int
@@ -136,15 +138,15 @@ func initialModel() model {
 	logs := viewport.New(60, 10)
 
 	return model{
-		step:         0,
-		stardewPath: detectStardewValley(),
+		step:           0,
+		stardewPath:    detectStardewValley(),
 		choiceSelected: 0,
-		pathInput:    ti,
-		pathDetected: detectStardewValley(),
-		spinner:      sp,
-		progress:     *progressBar,
-		logs:         logs,
-		logLines:     []string{},
+		pathInput:      ti,
+		pathDetected:   detectStardewValley(),
+		spinner:        sp,
+		progress:       progressBar,
+		logs:           logs,
+		logLines:       []string{},
 	}
 }
 
@@ -216,9 +218,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.spinner, _ = m.spinner.Update(msg)
 		}
 
-	case progress.Msg:
+	case progress.FrameMsg:
 		if m.step == 3 {
-			m.progress, _ = m.progress.Update(msg)
+			updated, _ := m.progress.Update(msg)
+			m.progress = updated.(progress.Model)
 		}
 	}
 
@@ -393,8 +396,7 @@ Enabled Options:%s
 [Exit]
 `, options))
 
-		return centerContent(successText.Width(50).Render(successText.Width(50).Render("")), m.width, m.height) + "\n\n" +
-			boxStyle.Width(50).Render(nextSteps), m.width, m.height)
+		return centerContent(success+"\n\n"+boxStyle.Width(50).Render(nextSteps), m.width, m.height)
 	}
 
 	// Show logs
@@ -413,7 +415,7 @@ Enabled Options:%s
 }
 
 func centerContent(content string, width, height int) string {
-	lines := lipgloss.SplitLines(content)
+	lines := strings.Split(content, "\n")
 	contentHeight := len(lines)
 
 	if contentHeight >= height {
@@ -624,10 +626,10 @@ func createConfig(autoStart bool) error {
 	config := fmt.Sprintf(`server:
   game_url: "ws://localhost:8765/game"
   auto_start: %v
-  log_level: "info"
 
 remote:
-  host: "0.0.0.0"
+  # 0.0.0.0 serves other machines; the server then requires remote.auth tokens
+  host: "127.0.0.1"
   port: 8765
 
 openclaw:
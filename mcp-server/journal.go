@@ -0,0 +1,485 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Undo journal - every tile-changing cheat is recorded as the object and
+// terrain differences between a fresh state before and after it. States only
+// cover the 61x61 tiles around the player, so changes further away are not
+// journaled. Undo reverses an entry with other cheats where the game allows it:
+// added objects, features and hoed dirt are cleared, removed hoed dirt is
+// hoed again and removed placeable items go back to the inventory. Trees,
+// crops, debris and resource clumps are reported as not reverted.
+// ============================================================================
+
+const (
+	// journalLimit is how many commands each game keeps for undo
+	journalLimit = 20
+
+	// journalStateTimeout bounds each of the state fetches around a command
+	journalStateTimeout = 3 * time.Second
+
+	// stateScanRadius is how far around the player the mod reports tiles
+	stateScanRadius = 30
+)
+
+// journaledActions are the mod actions whose tile changes can be undone
+var journaledActions = map[string]bool{
+	"cheat_hoe_all":            true,
+	"cheat_hoe_tiles":          true,
+	"cheat_till_pattern":       true,
+	"cheat_hoe_custom_pattern": true,
+	"cheat_clear_tiles":        true,
+	"cheat_clear_debris":       true,
+	"cheat_cut_trees":          true,
+	"cheat_mine_rocks":         true,
+	"cheat_dig_artifacts":      true,
+	"cheat_plant_seeds":        true,
+	"cheat_harvest_all":        true,
+	"cheat_spawn_ores":         true,
+	"cheat_collect_all_forage": true,
+}
+
+// TileChange is one tile layer that differs before and after a command.
+// Before and After are "" when the layer was empty.
+type TileChange struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Layer  string `json:"layer"` // object, terrain or clump
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	ItemID string `json:"itemId,omitempty"` // qualified id of a removed object that can be returned to the inventory
+}
+
+// JournalEntry is one recorded command
+type JournalEntry struct {
+	ID       string       `json:"id"`
+	Action   string       `json:"action"`
+	Location string       `json:"location"`
+	At       time.Time    `json:"at"`
+	Changes  []TileChange `json:"changes"`
+	Undone   bool         `json:"undone,omitempty"`
+}
+
+// CommandJournal keeps the latest tile-changing commands of one game
+type CommandJournal struct {
+	mu      sync.Mutex
+	next    int
+	entries []*JournalEntry
+}
+
+// add records a command and returns its entry
+func (j *CommandJournal) add(action, location string, changes []TileChange) *JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.next++
+	entry := &JournalEntry{
+		ID:       strconv.Itoa(j.next),
+		Action:   action,
+		Location: location,
+		At:       time.Now(),
+		Changes:  changes,
+	}
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > journalLimit {
+		j.entries = j.entries[len(j.entries)-journalLimit:]
+	}
+	return entry
+}
+
+// Find returns the entry with id, or the latest one not yet undone when id is ""
+func (j *CommandJournal) Find(id string) (*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		if id == "" && !entry.Undone || entry.ID == id {
+			return entry, nil
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("nothing to undo: no tile-changing command is journaled or all were undone")
+	}
+	return nil, fmt.Errorf("command %s is not in the undo journal (only the last %d are kept)", id, journalLimit)
+}
+
+// Entries returns the journal, newest first
+func (j *CommandJournal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]JournalEntry, 0, len(j.entries))
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entries = append(entries, *j.entries[i])
+	}
+	return entries
+}
+
+// markUndone flags entry as undone, failing if it already was
+func (j *CommandJournal) markUndone(entry *JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if entry.Undone {
+		return fmt.Errorf("command %s (%s) was already undone", entry.ID, entry.Action)
+	}
+	entry.Undone = true
+	return nil
+}
+
+type noJournalKey struct{}
+
+// withoutJournal keeps the commands sent with ctx out of the undo journal
+func withoutJournal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noJournalKey{}, true)
+}
+
+// shouldJournal reports whether a command's tile changes are recorded
+func shouldJournal(ctx context.Context, action string, params map[string]interface{}) bool {
+	if !journaledActions[action] || ctx.Value(noJournalKey{}) != nil {
+		return false
+	}
+	dryRun, _ := params["dryRun"].(bool)
+	return !dryRun
+}
+
+// freshState asks the mod for a full state and waits for it, so the result
+// reflects every command answered before the call
+func (c *GameClient) freshState(ctx context.Context) (*GameState, error) {
+	ctx, cancel := context.WithTimeout(ctx, journalStateTimeout)
+	defer cancel()
+
+	ch, unsubscribe := c.SubscribeState()
+	defer unsubscribe()
+	// Skip the state the subscription starts with
+	select {
+	case <-ch:
+	default:
+	}
+
+	c.mu.RLock()
+	writer, connected := c.writer, c.connected
+	c.mu.RUnlock()
+	if !connected {
+		return nil, ErrDisconnected
+	}
+	if err := writer.SendJSON(ctx, WebSocketMessage{Type: "get_state"}); err != nil {
+		return nil, err
+	}
+	select {
+	case state := <-ch:
+		return state, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no state update: %w", ctx.Err())
+	}
+}
+
+// journalCommand records the changes between before and a fresh state taken
+// after action succeeded, returning the note on the journal id to append to
+// the command's message
+func (c *GameClient) journalCommand(action string, before *GameState) string {
+	after, err := c.freshState(context.Background())
+	if err != nil {
+		log.Printf("[JOURNAL] Not journaling %s: %v", action, err)
+		return ""
+	}
+	if before.Player.Location != after.Player.Location {
+		log.Printf("[JOURNAL] Not journaling %s: location changed from %s to %s", action, before.Player.Location, after.Player.Location)
+		return ""
+	}
+
+	changes := diffTiles(before, after)
+	if len(changes) == 0 {
+		return ""
+	}
+	entry := c.journal.add(action, after.Player.Location, changes)
+	log.Printf("[JOURNAL] Recorded %s as command %s with %d tile change(s)", action, entry.ID, len(changes))
+	return fmt.Sprintf(" [journaled as command %s: %d tile change(s) nearby; undo_last reverts it]", entry.ID, len(changes))
+}
+
+// tileKey addresses one tile of a location
+type tileKey struct{ x, y int }
+
+// tileLayer is what one layer of a tile holds in a state
+type tileLayer struct {
+	desc   string
+	itemID string
+}
+
+// diffTiles lists the object, terrain and resource clump differences between
+// two states of the same location, on tiles both states cover
+func diffTiles(before, after *GameState) []TileChange {
+	inView := func(k tileKey) bool {
+		for _, s := range []*GameState{before, after} {
+			if abs(k.x-s.Player.X) > stateScanRadius || abs(k.y-s.Player.Y) > stateScanRadius {
+				return false
+			}
+		}
+		return true
+	}
+
+	var changes []TileChange
+	for _, layer := range []string{"object", "terrain", "clump"} {
+		was, is := tileLayers(before, layer), tileLayers(after, layer)
+		keys := make([]tileKey, 0, len(was)+len(is))
+		for k := range was {
+			keys = append(keys, k)
+		}
+		for k := range is {
+			if _, seen := was[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].y != keys[j].y {
+				return keys[i].y < keys[j].y
+			}
+			return keys[i].x < keys[j].x
+		})
+
+		for _, k := range keys {
+			if was[k].desc == is[k].desc || !inView(k) {
+				continue
+			}
+			changes = append(changes, TileChange{
+				X: k.x, Y: k.y, Layer: layer,
+				Before: was[k].desc, After: is[k].desc, ItemID: was[k].itemID,
+			})
+		}
+	}
+	return changes
+}
+
+// tileLayers maps each tile of one layer to its description
+func tileLayers(state *GameState, layer string) map[tileKey]tileLayer {
+	tiles := map[tileKey]tileLayer{}
+	sur := &state.Surroundings
+	switch layer {
+	case "object":
+		for _, o := range sur.NearbyObjects {
+			t := tileLayer{desc: o.Name}
+			// Debris needs a tool to break; placed items and forage can be handed back
+			if o.RequiredTool == "" {
+				t.itemID = o.ItemID
+			}
+			tiles[tileKey{o.X, o.Y}] = t
+		}
+	case "terrain":
+		for _, f := range sur.NearbyTerrainFeatures {
+			desc := f.Type
+			if f.HasCrop {
+				desc = fmt.Sprintf("%s (%s)", f.Type, f.CropName)
+			}
+			tiles[tileKey{f.X, f.Y}] = tileLayer{desc: desc}
+		}
+	case "clump":
+		for _, r := range sur.NearbyResourceClumps {
+			tiles[tileKey{r.X, r.Y}] = tileLayer{desc: r.Type}
+		}
+	}
+	return tiles
+}
+
+// undoPlan groups the commands that reverse one journal entry
+type undoPlan struct {
+	clearObjects  []tileKey
+	clearFeatures []tileKey
+	clearDirt     []tileKey
+	hoe           []tileKey
+	items         map[string]int // qualified item id -> count
+	skipped       []string
+}
+
+// planUndo works out how to reverse each change of entry
+func planUndo(entry *JournalEntry) *undoPlan {
+	plan := &undoPlan{items: map[string]int{}}
+	skip := func(ch TileChange, reason string) {
+		plan.skipped = append(plan.skipped, fmt.Sprintf("(%d, %d) %s", ch.X, ch.Y, reason))
+	}
+
+	for _, ch := range entry.Changes {
+		k := tileKey{ch.X, ch.Y}
+		switch ch.Layer {
+		case "object":
+			if ch.After != "" {
+				plan.clearObjects = append(plan.clearObjects, k)
+			}
+			switch {
+			case ch.Before == "":
+			case ch.ItemID != "":
+				plan.items[ch.ItemID]++
+			default:
+				skip(ch, fmt.Sprintf("%s cannot be put back", ch.Before))
+			}
+
+		case "terrain":
+			wasDirt, isDirt := isHoeDirt(ch.Before), isHoeDirt(ch.After)
+			if wasDirt && isDirt {
+				// Planted or harvested in place; hoeing fresh dirt drops a planted crop
+				if ch.After != "hoe_dirt" {
+					plan.clearDirt = append(plan.clearDirt, k)
+					plan.hoe = append(plan.hoe, k)
+				}
+				if ch.Before != "hoe_dirt" {
+					skip(ch, fmt.Sprintf("crop in %s cannot be replanted", ch.Before))
+				}
+				continue
+			}
+			switch {
+			case ch.After == "":
+			case isDirt:
+				plan.clearDirt = append(plan.clearDirt, k)
+			default:
+				plan.clearFeatures = append(plan.clearFeatures, k)
+			}
+			switch {
+			case ch.Before == "":
+			case wasDirt:
+				plan.hoe = append(plan.hoe, k)
+				if ch.Before != "hoe_dirt" {
+					skip(ch, fmt.Sprintf("crop in %s cannot be replanted", ch.Before))
+				}
+			default:
+				skip(ch, fmt.Sprintf("%s cannot be restored", ch.Before))
+			}
+
+		case "clump":
+			if ch.Before != "" {
+				skip(ch, fmt.Sprintf("%s cannot be restored", ch.Before))
+			} else {
+				skip(ch, fmt.Sprintf("%s cannot be removed", ch.After))
+			}
+		}
+	}
+	return plan
+}
+
+// undoEntry reverses a journal entry on the game bound to ctx and reports
+// what was restored and what could not be
+func undoEntry(ctx context.Context, id string) (string, error) {
+	client := clientFrom(ctx)
+	entry, err := client.journal.Find(id)
+	if err != nil {
+		return "", err
+	}
+	state := client.GetState()
+	if state == nil {
+		return "", fmt.Errorf("no game state yet")
+	}
+	if state.Player.Location != entry.Location {
+		return "", fmt.Errorf("command %s changed %s; warp back there before undoing it (you are in %s)",
+			entry.ID, entry.Location, state.Player.Location)
+	}
+	if err := client.journal.markUndone(entry); err != nil {
+		return "", err
+	}
+
+	plan := planUndo(entry)
+	ctx = withoutJournal(ctx)
+	var done, failed []string
+	send := func(action string, params map[string]interface{}) {
+		resp, err := client.SendCommandContext(ctx, action, params)
+		switch {
+		case err != nil:
+			failed = append(failed, fmt.Sprintf("%s: %v", action, err))
+		case !resp.Success:
+			failed = append(failed, fmt.Sprintf("%s: %s", action, resp.Message))
+		default:
+			done = append(done, resp.Message)
+		}
+	}
+
+	// clear_tiles sends its flags as strings; the mod ignores JSON bools for them
+	clearTiles := func(tiles []tileKey, objects, features, dirt bool) {
+		if len(tiles) > 0 {
+			send("cheat_clear_tiles", map[string]interface{}{
+				"tiles":         tileList(tiles),
+				"clearObjects":  strconv.FormatBool(objects),
+				"clearFeatures": strconv.FormatBool(features),
+				"clearDirt":     strconv.FormatBool(dirt),
+			})
+		}
+	}
+	clearTiles(plan.clearObjects, true, false, false)
+	clearTiles(plan.clearFeatures, false, true, false)
+	clearTiles(plan.clearDirt, false, false, true)
+	if len(plan.hoe) > 0 {
+		send("cheat_hoe_tiles", map[string]interface{}{"tiles": tileList(plan.hoe)})
+	}
+	itemIDs := make([]string, 0, len(plan.items))
+	for itemID := range plan.items {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Strings(itemIDs)
+	for _, itemID := range itemIDs {
+		send("cheat_add_item", map[string]interface{}{"itemId": itemID, "count": plan.items[itemID]})
+	}
+
+	var sb strings.Builder
+	if len(done) == 0 && len(failed) == 0 {
+		fmt.Fprintf(&sb, "Nothing of command %s (%s, %d tile change(s)) can be reverted.", entry.ID, entry.Action, len(entry.Changes))
+	} else {
+		fmt.Fprintf(&sb, "Undid command %s (%s, %d tile change(s)).", entry.ID, entry.Action, len(entry.Changes))
+	}
+	for _, msg := range done {
+		sb.WriteString("\n- " + msg)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&sb, "\n\nFailed (%d):", len(failed))
+		for _, msg := range failed {
+			sb.WriteString("\n- " + msg)
+		}
+	}
+	if len(plan.skipped) > 0 {
+		fmt.Fprintf(&sb, "\n\nCould not revert (%d):", len(plan.skipped))
+		for i, msg := range plan.skipped {
+			if i == previewListLimit {
+				fmt.Fprintf(&sb, "\n- ... and %d more", len(plan.skipped)-previewListLimit)
+				break
+			}
+			sb.WriteString("\n- " + msg)
+		}
+	}
+	sb.WriteString("\n\nChanges more than 30 tiles from you when the command ran were never journaled.")
+	return sb.String(), nil
+}
+
+// isHoeDirt reports whether a terrain description is hoed dirt, with or without a crop
+func isHoeDirt(desc string) bool {
+	return desc == "hoe_dirt" || strings.HasPrefix(desc, "hoe_dirt (")
+}
+
+// tileList formats tiles the way cheat_clear_tiles and cheat_hoe_tiles take them
+func tileList(tiles []tileKey) string {
+	parts := make([]string, len(tiles))
+	for i, k := range tiles {
+		parts[i] = fmt.Sprintf("%d,%d", k.x, k.y)
+	}
+	return strings.Join(parts, ";")
+}
+
+// journalSummary lists the journal of the game bound to ctx, newest first
+func journalSummary(ctx context.Context) string {
+	entries := clientFrom(ctx).journal.Entries()
+	if len(entries) == 0 {
+		return "The undo journal is empty"
+	}
+	var sb strings.Builder
+	sb.WriteString("Undo journal (newest first):")
+	for _, e := range entries {
+		status := ""
+		if e.Undone {
+			status = ", undone"
+		}
+		fmt.Fprintf(&sb, "\n- command %s: %s in %s at %s, %d tile change(s)%s",
+			e.ID, e.Action, e.Location, e.At.Format("15:04:05"), len(e.Changes), status)
+	}
+	return sb.String()
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// RFC 6902 JSON Patch - applied to the decoded state document when the mod
+// sends state deltas
+// ============================================================================
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// applyJSONPatch applies ops in order and returns the patched document. doc is
+// modified in place, so it must be discarded if an error is returned.
+func applyJSONPatch(doc interface{}, ops []jsonPatchOp) (interface{}, error) {
+	for i, op := range ops {
+		var err error
+		doc, err = applyPatchOp(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("patch op %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyPatchOp(doc interface{}, op jsonPatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if len(op.Value) > 0 {
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return patchAdd(doc, path, value)
+	case "remove":
+		return patchRemove(doc, path)
+	case "replace":
+		return patchReplace(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = patchRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopyJSON(v)
+		}
+		return patchAdd(doc, path, v)
+	case "test":
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func patchAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(key, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("cannot add to %T", parent)
+	})
+}
+
+func patchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("no member %q", key)
+			}
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove from %T", parent)
+	})
+}
+
+func patchReplace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("no member %q", key)
+			}
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("cannot replace in %T", parent)
+	})
+}
+
+// updateParent walks to the container holding the last token, lets fn change
+// it and stores the (possibly reallocated) container back into its parent
+func updateParent(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", path[0])
+		}
+		updated, err := updateParent(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(n))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(n[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot traverse %T at %q", node, path[0])
+}
+
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", key)
+			}
+			node = v
+		case []interface{}:
+			i, err := arrayIndex(key, len(n))
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse %T at %q", node, key)
+		}
+	}
+	return node, nil
+}
+
+// arrayIndex parses an array index token that must be below limit
+func arrayIndex(token string, limit int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= limit || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func deepCopyJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = deepCopyJSON(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, val := range t {
+			s[i] = deepCopyJSON(val)
+		}
+		return s
+	}
+	return v
+}
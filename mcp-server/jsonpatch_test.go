@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string // empty when the patch must fail
+	}{
+		{"replace", `{"a":1,"b":{"c":2}}`, `[{"op":"replace","path":"/b/c","value":3}]`, `{"a":1,"b":{"c":3}}`},
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":[1]}]`, `{"a":1,"b":[1]}`},
+		{"add array index", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"append", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array index", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/0"}]`, `{"a":[2,3]}`},
+		{"move", `{"a":{"x":1},"b":{}}`, `[{"op":"move","from":"/a/x","path":"/b/y"}]`, `{"a":{},"b":{"y":1}}`},
+		{"copy", `{"a":[1]}`, `[{"op":"copy","from":"/a","path":"/b"}]`, `{"a":[1],"b":[1]}`},
+		{"test", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"escaped pointer", `{"a/b":1,"c~d":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`, `{"a/b":3}`},
+		{"whole document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{"ops apply in order", `{"a":[]}`, `[{"op":"add","path":"/a/-","value":1},{"op":"replace","path":"/a/0","value":2}]`, `{"a":[2]}`},
+
+		{"replace missing", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, ""},
+		{"remove missing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ""},
+		{"index out of range", `{"a":[1]}`, `[{"op":"replace","path":"/a/5","value":1}]`, ""},
+		{"failed test", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"y"}]`, ""},
+		{"bad pointer", `{"a":1}`, `[{"op":"replace","path":"a","value":1}]`, ""},
+		{"unknown op", `{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			var ops []jsonPatchOp
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := applyJSONPatch(doc, ops)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("applyJSONPatch succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyJSONPatch: %v", err)
+			}
+			data, _ := json.Marshal(got)
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+// deltaMod is a minimal mod that advertises state deltas. Once the client
+// enabled them the test owns the connection and writes states and patches
+// itself; every other message the client sends ends up in requests.
+type deltaMod struct {
+	conn     chan *websocket.Conn
+	requests chan string
+}
+
+func newDeltaMod(t *testing.T) (*deltaMod, string) {
+	t.Helper()
+	mod := &deltaMod{conn: make(chan *websocket.Conn, 1), requests: make(chan string, 16)}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteJSON(deltaState(0, 600))
+		for {
+			var msg WebSocketMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			switch msg.Type {
+			case "hello":
+				conn.WriteJSON(map[string]interface{}{"id": msg.ID, "type": "hello", "success": true, "data": map[string]interface{}{
+					"protocolVersion": 1, "actions": []string{"move_to"}, "features": []string{"state_deltas"},
+				}})
+			case "enable_deltas":
+				conn.WriteJSON(deltaState(1, 600))
+				mod.conn <- conn
+			default:
+				mod.requests <- msg.Type
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return mod, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func deltaState(seq int64, timeOfDay int) map[string]interface{} {
+	return map[string]interface{}{"type": "state", "success": true, "seq": seq, "data": map[string]interface{}{
+		"player": map[string]interface{}{"name": "Test", "x": 1, "y": 2},
+		"time":   map[string]interface{}{"timeOfDay": timeOfDay},
+	}}
+}
+
+func deltaPatch(seq int64, ops ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "state_patch", "success": true, "seq": seq, "data": ops}
+}
+
+func setTime(timeOfDay int) map[string]interface{} {
+	return map[string]interface{}{"op": "replace", "path": "/time/timeOfDay", "value": timeOfDay}
+}
+
+// waitForTime waits until the client's state shows timeOfDay
+func waitForTime(t *testing.T, c *GameClient, timeOfDay int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if state := c.GetState(); state != nil && state.Time.TimeOfDay == timeOfDay {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timeOfDay never became %d (state %+v)", timeOfDay, c.GetState().Time)
+}
+
+func expectResync(t *testing.T, mod *deltaMod) {
+	t.Helper()
+	select {
+	case req := <-mod.requests:
+		if req != "get_state" {
+			t.Fatalf("client sent %q, want get_state", req)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not ask for a full state")
+	}
+}
+
+func TestStatePatchResync(t *testing.T) {
+	mod, url := newDeltaMod(t)
+	c := NewGameClient()
+	if err := c.Connect(url); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	var conn *websocket.Conn
+	select {
+	case conn = <-mod.conn:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not enable deltas")
+	}
+
+	// Patches in sequence apply to the snapshot
+	conn.WriteJSON(deltaPatch(2, setTime(610)))
+	waitForTime(t, c, 610)
+	if state := c.GetState(); state.Player.Name != "Test" || state.Player.X != 1 {
+		t.Errorf("patch lost the rest of the state: %+v", state.Player)
+	}
+
+	// A gap drops the base: the patch is not applied and a full state is requested
+	conn.WriteJSON(deltaPatch(4, setTime(700)))
+	expectResync(t, mod)
+	if tod := c.GetState().Time.TimeOfDay; tod != 610 {
+		t.Errorf("patch after a gap applied: timeOfDay %d", tod)
+	}
+
+	// Patches are ignored until the snapshot arrives, which becomes the new base
+	conn.WriteJSON(deltaPatch(5, setTime(710)))
+	conn.WriteJSON(deltaState(10, 800))
+	waitForTime(t, c, 800)
+	conn.WriteJSON(deltaPatch(11, setTime(900)))
+	waitForTime(t, c, 900)
+
+	// A patch that does not apply also resyncs
+	conn.WriteJSON(deltaPatch(12, map[string]interface{}{"op": "replace", "path": "/nope/x", "value": 1}))
+	expectResync(t, mod)
+	if tod := c.GetState().Time.TimeOfDay; tod != 900 {
+		t.Errorf("state changed by a broken patch: timeOfDay %d", tod)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Control lease - while an agent holds a game's lease, only its commands reach
+// the mod and everyone else has read-only access. Without a lease every caller
+// may command the game. Leases expire after their TTL unless renewed, are
+// released when the holder disconnects, and can be preempted by an operator.
+// ============================================================================
+
+const (
+	defaultLeaseTTL = 60 * time.Second
+	maxLeaseTTL     = time.Hour
+)
+
+// ControlLease arbitrates which controller may send commands to one game
+type ControlLease struct {
+	mu      sync.Mutex
+	holder  string // controller id
+	label   string // human-readable holder for status and errors
+	expires time.Time
+}
+
+// LeaseInfo describes the current lease holder
+type LeaseInfo struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LeaseError is returned for commands from a controller that does not hold the lease
+type LeaseError struct {
+	Holder    string
+	ExpiresAt time.Time
+}
+
+func (e *LeaseError) Error() string {
+	return fmt.Sprintf("game is controlled by %s for another %v; you have read-only access until the lease ends (acquire_control)",
+		e.Holder, time.Until(e.ExpiresAt).Round(time.Second))
+}
+
+// expireLocked clears a lease whose TTL has passed; the caller holds l.mu
+func (l *ControlLease) expireLocked() {
+	if l.holder != "" && time.Now().After(l.expires) {
+		log.Printf("[LEASE] Control lease of %s expired", l.label)
+		l.holder, l.label = "", ""
+	}
+}
+
+// Acquire grants or renews the lease for controller id. A lease held by
+// someone else is only taken over with preempt.
+func (l *ControlLease) Acquire(id, label string, ttl time.Duration, preempt bool) (LeaseInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireLocked()
+
+	if l.holder != "" && l.holder != id {
+		if !preempt {
+			return LeaseInfo{}, &LeaseError{Holder: l.label, ExpiresAt: l.expires}
+		}
+		log.Printf("[LEASE] %s preempted the control lease of %s", label, l.label)
+	}
+	if l.holder != id {
+		log.Printf("[LEASE] %s acquired control for %v", label, ttl)
+	}
+	l.holder, l.label, l.expires = id, label, time.Now().Add(ttl)
+	return LeaseInfo{Holder: l.label, ExpiresAt: l.expires}, nil
+}
+
+// Release gives up the lease if controller id holds it
+func (l *ControlLease) Release(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == "" || l.holder != id {
+		return false
+	}
+	log.Printf("[LEASE] %s released control", l.label)
+	l.holder, l.label = "", ""
+	return true
+}
+
+// Check returns a *LeaseError unless the game is free or controller id holds it
+func (l *ControlLease) Check(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireLocked()
+	if l.holder == "" || l.holder == id {
+		return nil
+	}
+	return &LeaseError{Holder: l.label, ExpiresAt: l.expires}
+}
+
+// Info returns the current holder, or nil when the game is free
+func (l *ControlLease) Info() *LeaseInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireLocked()
+	if l.holder == "" {
+		return nil
+	}
+	return &LeaseInfo{Holder: l.label, ExpiresAt: l.expires}
+}
+
+// controller identifies who is driving a game: a remote connection, an MCP
+// session or the in-process agent
+type controller struct {
+	id    string
+	label string
+}
+
+type controllerKey struct{}
+
+// withController binds the caller's controller identity to ctx
+func withController(ctx context.Context, id, label string) context.Context {
+	return context.WithValue(ctx, controllerKey{}, controller{id: id, label: label})
+}
+
+// controllerFrom returns the controller bound to ctx; anonymous callers never hold a lease
+func controllerFrom(ctx context.Context) controller {
+	if c, ok := ctx.Value(controllerKey{}).(controller); ok {
+		return c
+	}
+	return controller{label: "anonymous caller"}
+}
+
+// remoteController identifies a remote connection, naming its token when there is one
+func remoteController(ctx context.Context, id, label string) context.Context {
+	if token, ok := ctx.Value(tokenKey{}).(*TokenConfig); ok {
+		label = fmt.Sprintf("%s (token %q)", label, token.Name)
+	}
+	return withController(ctx, id, label)
+}
+
+// acquireControl takes the lease of the game bound to ctx for the caller.
+// Holding a lease needs the play scope and preempting one the operator scope.
+func acquireControl(ctx context.Context, ttlSeconds float64, preempt bool) (LeaseInfo, error) {
+	if err := authorize(ctx, scopePlay, "acquire_control"); err != nil {
+		return LeaseInfo{}, err
+	}
+	if preempt {
+		if err := authorize(ctx, scopeOperator, "preempting control"); err != nil {
+			return LeaseInfo{}, err
+		}
+	}
+	c := controllerFrom(ctx)
+	if c.id == "" {
+		return LeaseInfo{}, fmt.Errorf("this connection has no controller identity and cannot hold a lease")
+	}
+
+	ttl := defaultLeaseTTL
+	if ttlSeconds > 0 {
+		ttl = seconds(ttlSeconds)
+	}
+	if ttl > maxLeaseTTL {
+		ttl = maxLeaseTTL
+	}
+	return clientFrom(ctx).lease.Acquire(c.id, c.label, ttl, preempt)
+}
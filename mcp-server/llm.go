@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// LLM backends - the autonomous agent talks to its model through LLMBackend,
+// so it can run on the GitHub Copilot SDK, on any OpenAI-compatible chat
+// completions endpoint (hosted or local) or on a scripted fake for tests.
+// Backends run the model's tool calls themselves and report each one to the
+// caller as it finishes.
+// ============================================================================
+
+// LLMBackend creates model sessions
+type LLMBackend interface {
+	// Name identifies the backend in logs, e.g. "copilot"
+	Name() string
+	// CreateSession starts a conversation with a system prompt and the tools
+	// the model may call
+	CreateSession(opts SessionOptions) (LLMSession, error)
+	// Close releases the backend and its sessions
+	Close() error
+}
+
+// LLMSession is one conversation that keeps its history between sends
+type LLMSession interface {
+	// Send adds prompt to the conversation, runs every tool call the model
+	// makes until it answers in text and returns that answer. onToolCall, if
+	// not nil, receives each tool call once it has run. ctx bounds the whole
+	// exchange.
+	Send(ctx context.Context, prompt string, onToolCall func(ToolCall)) (string, error)
+	// Close ends the conversation
+	Close() error
+}
+
+// SessionOptions configures a new session
+type SessionOptions struct {
+	Model        string
+	SystemPrompt string
+	Tools        []LLMTool
+}
+
+// LLMTool is a tool the model may call. Parameters is its JSON Schema; Run
+// executes a call under the context of the Send that made it and returns the
+// text the model sees.
+type LLMTool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+	Run         func(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// ToolCall is one tool call made by the model and its outcome
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]interface{}
+	Result    string
+	Err       error
+}
+
+// NewLLMBackend creates the backend selected by cfg
+func NewLLMBackend(cfg LLMConfig) (LLMBackend, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "copilot":
+		return newCopilotBackend()
+	case "openai":
+		return newOpenAIBackend(cfg)
+	case "scripted":
+		return newScriptedBackend(cfg.Script)
+	}
+	return nil, fmt.Errorf("unknown LLM backend %q (use copilot, openai or scripted)", cfg.Backend)
+}
+
+// toolIndex maps tools by name for backends that dispatch calls themselves
+func toolIndex(tools []LLMTool) map[string]LLMTool {
+	index := make(map[string]LLMTool, len(tools))
+	for _, t := range tools {
+		index[t.Name] = t
+	}
+	return index
+}
+
+// runToolCall runs a call against tools and reports it to onToolCall
+func runToolCall(ctx context.Context, tools map[string]LLMTool, call ToolCall, onToolCall func(ToolCall)) ToolCall {
+	if tool, ok := tools[call.Name]; ok {
+		call.Result, call.Err = tool.Run(ctx, call.Arguments)
+	} else {
+		call.Err = fmt.Errorf("unknown tool %q", call.Name)
+	}
+	if onToolCall != nil {
+		onToolCall(call)
+	}
+	return call
+}
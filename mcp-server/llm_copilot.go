@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// copilotBackend runs sessions on the GitHub Copilot SDK, which executes tool
+// calls through the handlers registered with each session
+type copilotBackend struct {
+	client *copilot.Client
+}
+
+func newCopilotBackend() (*copilotBackend, error) {
+	// Create client with default options
+	client := copilot.NewClient(nil)
+	if err := client.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start copilot client: %w", err)
+	}
+	return &copilotBackend{client: client}, nil
+}
+
+func (b *copilotBackend) Name() string { return "copilot" }
+
+func (b *copilotBackend) CreateSession(opts SessionOptions) (LLMSession, error) {
+	s := &copilotSession{}
+	tools := make([]copilot.Tool, 0, len(opts.Tools))
+	for _, t := range opts.Tools {
+		t := t
+		tools = append(tools, copilot.Tool{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+			Handler: func(inv copilot.ToolInvocation) (copilot.ToolResult, error) {
+				args, _ := inv.Arguments.(map[string]interface{})
+				ctx, onToolCall := s.current()
+				call := runToolCall(ctx, map[string]LLMTool{t.Name: t}, ToolCall{ID: inv.ToolCallID, Name: t.Name, Arguments: args}, onToolCall)
+				if call.Err != nil {
+					return copilot.ToolResult{}, call.Err
+				}
+				return copilot.ToolResult{
+					TextResultForLLM: call.Result,
+					ResultType:       "success",
+				}, nil
+			},
+		})
+	}
+
+	session, err := b.client.CreateSession(&copilot.SessionConfig{
+		Model: opts.Model,
+		SystemMessage: &copilot.SystemMessageConfig{
+			Content: opts.SystemPrompt,
+		},
+		Tools: tools,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.session = session
+	return s, nil
+}
+
+func (b *copilotBackend) Close() error {
+	return errors.Join(b.client.Stop()...)
+}
+
+type copilotSession struct {
+	session *copilot.Session
+
+	mu         sync.Mutex
+	ctx        context.Context // context of the send in progress
+	onToolCall func(ToolCall)  // callback of the send in progress
+}
+
+// current returns the context and tool call callback of the send in progress
+func (s *copilotSession) current() (context.Context, func(ToolCall)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return context.Background(), s.onToolCall
+	}
+	return s.ctx, s.onToolCall
+}
+
+func (s *copilotSession) Send(ctx context.Context, prompt string, onToolCall func(ToolCall)) (string, error) {
+	s.mu.Lock()
+	s.ctx, s.onToolCall = ctx, onToolCall
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.ctx, s.onToolCall = nil, nil
+		s.mu.Unlock()
+	}()
+
+	// The SDK takes a timeout rather than a context; 0 means its default.
+	// Cancelling ctx aborts the turn instead of waiting for the timeout.
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	type reply struct {
+		event *copilot.SessionEvent
+		err   error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		event, err := s.session.SendAndWait(copilot.MessageOptions{Prompt: prompt}, timeout)
+		done <- reply{event, err}
+	}()
+
+	var response *copilot.SessionEvent
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return "", r.err
+		}
+		response = r.event
+	case <-ctx.Done():
+		if err := s.session.Abort(); err != nil {
+			log.Printf("[AGENT] Copilot turn not aborted: %v", err)
+		}
+		return "", ctx.Err()
+	}
+	if response == nil || response.Data.Content == nil {
+		return "", nil
+	}
+	return *response.Data.Content, nil
+}
+
+func (s *copilotSession) Close() error {
+	return s.session.Destroy()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// openAIMaxToolRounds caps the tool-call round trips of one send
+	openAIMaxToolRounds = 50
+
+	// openAIHistoryTurns is how many earlier prompts a session keeps; each
+	// prompt carries the current game state, so older ones only cost tokens
+	openAIHistoryTurns = 10
+)
+
+// openAIBackend runs sessions against an OpenAI-compatible chat completions
+// endpoint: OpenAI itself or a local server such as Ollama, LM Studio or vLLM
+type openAIBackend struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func newOpenAIBackend(cfg LLMConfig) (*openAIBackend, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("the openai LLM backend needs agent.llm.base_url")
+	}
+	return &openAIBackend{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+		http:    &http.Client{},
+	}, nil
+}
+
+func (b *openAIBackend) Name() string { return "openai" }
+
+func (b *openAIBackend) CreateSession(opts SessionOptions) (LLMSession, error) {
+	s := &openAISession{
+		backend: b,
+		model:   opts.Model,
+		tools:   toolIndex(opts.Tools),
+		history: []openAIMessage{{Role: "system", Content: opts.SystemPrompt}},
+	}
+	for _, t := range opts.Tools {
+		s.toolDefs = append(s.toolDefs, openAITool{
+			Type:     "function",
+			Function: openAIFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+	return s, nil
+}
+
+func (b *openAIBackend) Close() error { return nil }
+
+// Chat completions wire format
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type openAISession struct {
+	backend  *openAIBackend
+	model    string
+	tools    map[string]LLMTool
+	toolDefs []openAITool
+
+	mu      sync.Mutex // one send at a time
+	history []openAIMessage
+}
+
+func (s *openAISession) Send(ctx context.Context, prompt string, onToolCall func(ToolCall)) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trimHistory()
+	s.history = append(s.history, openAIMessage{Role: "user", Content: prompt})
+	for round := 0; round < openAIMaxToolRounds; round++ {
+		reply, err := s.complete(ctx)
+		if err != nil {
+			return "", err
+		}
+		s.history = append(s.history, reply)
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+
+		for _, tc := range reply.ToolCalls {
+			call := ToolCall{ID: tc.ID, Name: tc.Function.Name}
+			if tc.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &call.Arguments); err != nil {
+					call.Err = fmt.Errorf("arguments are not a JSON object: %w", err)
+				}
+			}
+			if call.Err == nil {
+				call = runToolCall(ctx, s.tools, call, onToolCall)
+			} else if onToolCall != nil {
+				onToolCall(call)
+			}
+
+			content := call.Result
+			if call.Err != nil {
+				content = "Error: " + call.Err.Error()
+			}
+			s.history = append(s.history, openAIMessage{Role: "tool", Content: content, ToolCallID: tc.ID})
+		}
+	}
+	return "", fmt.Errorf("model still calling tools after %d rounds", openAIMaxToolRounds)
+}
+
+// complete sends the history and returns the model's next message
+func (s *openAISession) complete(ctx context.Context) (openAIMessage, error) {
+	body, err := json.Marshal(openAIRequest{Model: s.model, Messages: s.history, Tools: s.toolDefs})
+	if err != nil {
+		return openAIMessage{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.backend.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return openAIMessage{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.backend.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.backend.apiKey)
+	}
+
+	resp, err := s.backend.http.Do(req)
+	if err != nil {
+		return openAIMessage{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return openAIMessage{}, err
+	}
+
+	var out openAIResponse
+	decodeErr := json.Unmarshal(data, &out)
+	switch {
+	case decodeErr == nil && out.Error != nil:
+		return openAIMessage{}, fmt.Errorf("chat completion failed (%s): %s", resp.Status, out.Error.Message)
+	case resp.StatusCode != http.StatusOK:
+		return openAIMessage{}, fmt.Errorf("chat completion failed (%s): %.200s", resp.Status, data)
+	case decodeErr != nil:
+		return openAIMessage{}, fmt.Errorf("invalid chat completion response: %w", decodeErr)
+	case len(out.Choices) == 0:
+		return openAIMessage{}, fmt.Errorf("chat completion returned no choices")
+	}
+	msg := out.Choices[0].Message
+	msg.Role = "assistant"
+	return msg, nil
+}
+
+// trimHistory drops the oldest exchanges so at most openAIHistoryTurns-1
+// earlier prompts remain. Whole exchanges go, so every tool result keeps the
+// assistant message that called it.
+func (s *openAISession) trimHistory() {
+	var prompts []int
+	for i, m := range s.history {
+		if m.Role == "user" {
+			prompts = append(prompts, i)
+		}
+	}
+	if len(prompts) < openAIHistoryTurns {
+		return
+	}
+	keepFrom := prompts[len(prompts)-openAIHistoryTurns+1]
+	s.history = append(s.history[:1], s.history[keepFrom:]...)
+}
+
+func (s *openAISession) Close() error { return nil }
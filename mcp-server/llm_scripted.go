@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrScriptExhausted is returned by a scripted session once every turn was played
+var ErrScriptExhausted = errors.New("LLM script has no turns left")
+
+// ScriptedTurn is the model's scripted answer to one prompt: tool calls run
+// in order, then Reply is returned
+type ScriptedTurn struct {
+	Tools []ScriptedToolCall `yaml:"tools" json:"tools"`
+	Reply string             `yaml:"reply" json:"reply"`
+}
+
+// ScriptedToolCall is one tool call of a scripted turn
+type ScriptedToolCall struct {
+	Name      string                 `yaml:"name" json:"name"`
+	Arguments map[string]interface{} `yaml:"arguments" json:"arguments"`
+}
+
+// ScriptedBackend replays fixed turns instead of calling a model, for tests
+// and offline runs against -fake-game. Sessions share one script, and every
+// prompt they were sent is kept for inspection.
+type ScriptedBackend struct {
+	mu      sync.Mutex
+	turns   []ScriptedTurn
+	next    int
+	prompts []string
+}
+
+// NewScriptedBackend returns a backend that answers prompts with turns in order
+func NewScriptedBackend(turns []ScriptedTurn) *ScriptedBackend {
+	return &ScriptedBackend{turns: turns}
+}
+
+// newScriptedBackend loads a script file: a YAML or JSON list of turns
+func newScriptedBackend(path string) (*ScriptedBackend, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the scripted LLM backend needs agent.llm.script")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LLM script: %w", err)
+	}
+	var turns []ScriptedTurn
+	if err := yaml.Unmarshal(data, &turns); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM script %s: %w", path, err)
+	}
+	return NewScriptedBackend(turns), nil
+}
+
+func (b *ScriptedBackend) Name() string { return "scripted" }
+
+func (b *ScriptedBackend) CreateSession(opts SessionOptions) (LLMSession, error) {
+	return &scriptedSession{backend: b, tools: toolIndex(opts.Tools)}, nil
+}
+
+func (b *ScriptedBackend) Close() error { return nil }
+
+// Prompts returns every prompt sent to the backend's sessions so far
+func (b *ScriptedBackend) Prompts() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.prompts...)
+}
+
+// nextTurn records prompt and returns the turn that answers it
+func (b *ScriptedBackend) nextTurn(prompt string) (ScriptedTurn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prompts = append(b.prompts, prompt)
+	if b.next >= len(b.turns) {
+		return ScriptedTurn{}, ErrScriptExhausted
+	}
+	b.next++
+	return b.turns[b.next-1], nil
+}
+
+type scriptedSession struct {
+	backend *ScriptedBackend
+	tools   map[string]LLMTool
+}
+
+func (s *scriptedSession) Send(ctx context.Context, prompt string, onToolCall func(ToolCall)) (string, error) {
+	turn, err := s.backend.nextTurn(prompt)
+	if err != nil {
+		return "", err
+	}
+	for i, tc := range turn.Tools {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		// Arguments arrive as JSON from a real model; YAML would leave ints
+		var args map[string]interface{}
+		raw, _ := json.Marshal(tc.Arguments)
+		json.Unmarshal(raw, &args)
+		runToolCall(ctx, s.tools, ToolCall{ID: fmt.Sprintf("call_%d", i+1), Name: tc.Name, Arguments: args}, onToolCall)
+	}
+	return turn.Reply, nil
+}
+
+func (s *scriptedSession) Close() error { return nil }
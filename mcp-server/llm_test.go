@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestToolCallsGetSendContext checks that a tool stuck in a call returns once
+// the Send that made the call times out
+func TestToolCallsGetSendContext(t *testing.T) {
+	backend := NewScriptedBackend([]ScriptedTurn{{Tools: []ScriptedToolCall{{Name: "stuck"}}, Reply: "done"}})
+	session, err := backend.CreateSession(SessionOptions{Tools: []LLMTool{{
+		Name: "stuck",
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(5 * time.Second):
+				return "never cancelled", nil
+			}
+		},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var calls []ToolCall
+	if _, err := session.Send(ctx, "go", func(call ToolCall) { calls = append(calls, call) }); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(calls) != 1 || !errors.Is(calls[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("tool calls = %+v, want one ending with the Send's deadline", calls)
+	}
+}
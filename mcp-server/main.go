@@ -1,26 +1,59 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math/rand"
+	"net"
 	"net/http"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"stardew-mcp/fakegame"
+
 	"github.com/gorilla/websocket"
 )
 
+// ErrDisconnected is returned for commands that cannot complete because the game connection is down
+var ErrDisconnected = errors.New("not connected to game")
+
 // GameClient manages the WebSocket connection to the Stardew Valley mod
 type GameClient struct {
 	conn        *websocket.Conn
-	mu          sync.RWMutex
+	writer      *writePump   // sole writer for conn
+	mu          sync.RWMutex // guards everything except responses; never held across I/O
 	state       *GameState
+	stateAt     time.Time   // when state was last received
+	stateStale  bool        // state predates the current connection
+	stateDoc    interface{} // decoded state document that deltas are applied to
+	stateSeq    int64       // sequence number of stateDoc; 0 when the mod sends full snapshots
+	resyncing   bool        // patches are ignored until the requested full state arrives
 	responses   map[string]chan *WebSocketResponse
 	responsesMu sync.Mutex
 	connected   bool
+	ready       chan struct{} // closed when the current connection delivers its first state
+	hello       chan struct{} // closed when the mod answers (or refuses) the hello handshake
+	helloID     string        // id of the current connection's hello request
+	caps        Capabilities
+	done        chan struct{} // closed when the current connection drops
 	url         string
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan *GameState]struct{}
+
+	lease     ControlLease      // who may send commands; see lease.go
+	scheduler *CommandScheduler // orders commands by priority; see scheduler.go
+	journal   CommandJournal    // tile changes of recent cheats; see journal.go
+
+	lastID atomic.Int64 // message ids; responses are matched by id
 }
 
 // GameState represents the current state of the game
@@ -96,6 +129,13 @@ type MapInfo struct {
 	IsMineLevel bool   `json:"isMineLevel"`
 	MineLevel   int    `json:"mineLevel"`
 	UniqueId    string `json:"uniqueId"`
+	// Bed is the player's bed spot, set in the farmhouse only
+	Bed *TilePosition `json:"bed,omitempty"`
+}
+
+type TilePosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 type TileInfo struct {
@@ -122,6 +162,7 @@ type NearbyObject struct {
 	HeldItemName      string `json:"heldItemName,omitempty"`
 	RequiredTool      string `json:"requiredTool,omitempty"`
 	HitsRequired      int    `json:"hitsRequired"`
+	ItemID            string `json:"itemId,omitempty"`
 }
 
 type NearbyTerrain struct {
@@ -240,6 +281,10 @@ type InventoryItem struct {
 	Category    string `json:"category"`
 	IsTool      bool   `json:"isTool"`
 	IsWeapon    bool   `json:"isWeapon"`
+	// Edible items report what eating one restores
+	IsEdible       bool `json:"isEdible"`
+	EnergyRestored int  `json:"energyRestored"`
+	HealthRestored int  `json:"healthRestored"`
 }
 
 type QuestInfo struct {
@@ -280,6 +325,9 @@ type WebSocketMessage struct {
 	Type   string                 `json:"type"`
 	Action string                 `json:"action,omitempty"`
 	Params map[string]interface{} `json:"params,omitempty"`
+
+	// Priority is the scheduling class of a remote agent's command (survival, user or background)
+	Priority string `json:"priority,omitempty"`
 }
 
 type WebSocketResponse struct {
@@ -290,146 +338,448 @@ type WebSocketResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// wireMessage is a message from the mod with its data left undecoded, so
+// states decode straight into GameState and patches into operations
+type wireMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Success bool            `json:"success"`
+	Message string          `json:"message,omitempty"`
+	Seq     int64           `json:"seq,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
 var gameClient *GameClient
 
 func NewGameClient() *GameClient {
 	return &GameClient{
 		responses: make(map[string]chan *WebSocketResponse),
+		scheduler: NewCommandScheduler(config.Server.Connection.MaxInFlight),
 	}
 }
 
+// nextID returns a message id that is unique for this client
+func (c *GameClient) nextID() string {
+	return fmt.Sprintf("%d", c.lastID.Add(1))
+}
+
+// Connect dials the mod and waits for its first state broadcast
 func (c *GameClient) Connect(url string) error {
+	c.mu.Lock()
 	c.url = url
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	c.mu.Unlock()
+	return c.dialAndSync(url)
+}
+
+// ConnectWithRetry connects to url, retrying with exponential backoff until the mod answers
+func (c *GameClient) ConnectWithRetry(url string) {
+	c.mu.Lock()
+	c.url = url
+	c.mu.Unlock()
+
+	log.Printf("Connecting to Stardew Valley at %s...", url)
+	err := c.dialAndSync(url)
+	if err == nil {
+		return
+	}
+	log.Printf("Failed to connect to game (will retry): %v", err)
+	c.retryUntilSynced(url)
+}
+
+// dialAndSync opens a connection and waits for a fresh state on it
+func (c *GameClient) dialAndSync(url string) error {
+	conn, _, err := gameDialer.Dial(url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to game: %w", err)
 	}
 
+	ready, hello := c.attach(conn)
+
+	timeout := time.NewTimer(config.Server.Connection.CommandTimeoutDuration())
+	defer timeout.Stop()
+	for ready != nil || hello != nil {
+		select {
+		case <-ready:
+			ready = nil
+		case <-hello:
+			hello = nil
+		case <-timeout.C:
+			conn.Close()
+			if ready != nil {
+				return fmt.Errorf("no state received from game within %v", config.Server.Connection.CommandTimeoutDuration())
+			}
+			return fmt.Errorf("no hello answer from game within %v", config.Server.Connection.CommandTimeoutDuration())
+		}
+	}
+	return nil
+}
+
+// attach makes conn the current connection, starts its reader and heartbeat
+// and opens the hello handshake
+func (c *GameClient) attach(conn *websocket.Conn) (ready, hello chan struct{}) {
+	ready = make(chan struct{})
+	hello = make(chan struct{})
+	done := make(chan struct{})
+	writer := newWritePump(conn)
+
 	c.mu.Lock()
 	c.conn = conn
+	c.writer = writer
 	c.connected = true
+	c.ready = ready
+	c.hello = hello
+	c.helloID = "hello-" + c.nextID()
+	helloID := c.helloID
+	c.caps = Capabilities{}
+	c.done = done
+	c.stateDoc = nil
+	c.stateSeq = 0
+	c.resyncing = false
 	c.mu.Unlock()
 
-	go c.listen()
-	go c.keepAlive()
-
-	return nil
+	go c.listen(conn)
+	go c.keepAlive(writer, done)
+	go writer.SendJSON(context.Background(), WebSocketMessage{
+		ID:     helloID,
+		Type:   "hello",
+		Params: map[string]interface{}{"protocolVersion": protocolVersion},
+	})
+	return ready, hello
 }
 
-func (c *GameClient) keepAlive() {
-	ticker := time.NewTicker(15 * time.Second)
+func (c *GameClient) keepAlive(writer *writePump, done chan struct{}) {
+	ticker := time.NewTicker(config.Server.Connection.PingIntervalDuration())
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mu.RLock()
-		conn := c.conn
-		connected := c.connected
-		c.mu.RUnlock()
-
-		if !connected || conn == nil {
+	for {
+		select {
+		case <-done:
 			return
+		case <-ticker.C:
 		}
 
 		ping := WebSocketMessage{
-			ID:   fmt.Sprintf("%d", time.Now().UnixNano()),
+			ID:   c.nextID(),
 			Type: "ping",
 		}
-		data, _ := json.Marshal(ping)
-
-		c.mu.Lock()
-		err := conn.WriteMessage(websocket.TextMessage, data)
-		c.mu.Unlock()
-
-		if err != nil {
+		if err := writer.SendJSON(context.Background(), ping); err != nil {
 			log.Printf("Ping failed: %v", err)
 			return
 		}
 	}
 }
 
-func (c *GameClient) reconnect() {
+// drop tears down conn: pending commands fail with ErrDisconnected and the
+// state is marked stale. A connection that had synced triggers a reconnect.
+func (c *GameClient) drop(conn *websocket.Conn) {
 	c.mu.Lock()
-	c.connected = false
-	if c.conn != nil {
-		c.conn.Close()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
 	}
+	wasReady := false
+	select {
+	case <-c.ready:
+		wasReady = true
+	default:
+	}
+	c.connected = false
+	c.stateStale = true
+	close(c.done)
+	url := c.url
+	writer := c.writer
 	c.mu.Unlock()
 
-	for {
-		log.Printf("Attempting to reconnect...")
-		time.Sleep(5 * time.Second)
+	writer.Close()
+	conn.Close()
 
-		conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
-		if err != nil {
-			log.Printf("Reconnect failed: %v", err)
-			continue
-		}
+	c.responsesMu.Lock()
+	pending := len(c.responses)
+	c.responses = make(map[string]chan *WebSocketResponse)
+	c.responsesMu.Unlock()
+	if pending > 0 {
+		log.Printf("Failed %d pending command(s): connection lost", pending)
+	}
 
-		c.mu.Lock()
-		c.conn = conn
-		c.connected = true
-		c.mu.Unlock()
+	if wasReady {
+		go func() {
+			c.retryUntilSynced(url)
+			log.Printf("Reconnected to Stardew Valley at %s (state resynced)", url)
+		}()
+	}
+}
 
-		log.Printf("Reconnected to Stardew Valley at %s", c.url)
+// retryUntilSynced reconnects with exponential backoff and jitter until a
+// connection delivers a fresh state
+func (c *GameClient) retryUntilSynced(url string) {
+	backoff := newBackoff(config.Server.Connection.ReconnectDelayDuration(), config.Server.Connection.ReconnectMaxDelayDuration())
+	for attempt := 1; ; attempt++ {
+		delay := backoff.Next()
+		log.Printf("Attempting to reconnect in %v (attempt %d)...", delay.Round(time.Millisecond), attempt)
+		time.Sleep(delay)
 
-		go c.listen()
-		go c.keepAlive()
+		if err := c.dialAndSync(url); err != nil {
+			log.Printf("Reconnect failed: %v", err)
+			continue
+		}
 		return
 	}
 }
 
-func (c *GameClient) listen() {
+func (c *GameClient) listen(conn *websocket.Conn) {
 	for {
-		c.mu.RLock()
-		conn := c.conn
-		c.mu.RUnlock()
-
-		if conn == nil {
-			return
-		}
-
 		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Printf("WebSocket read error from %s: %v", c.url, err)
-			go c.reconnect()
+			c.drop(conn)
 			return
 		}
 
-		var response WebSocketResponse
-		if err := json.Unmarshal(message, &response); err != nil {
+		var msg wireMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
 			log.Printf("Failed to parse response: %v", err)
 			continue
 		}
 
-		switch response.Type {
+		switch msg.Type {
 		case "state":
-			c.handleStateUpdate(&response)
+			c.handleStateUpdate(conn, &msg)
+		case "state_patch":
+			c.handleStatePatch(conn, &msg)
 		case "response":
+			response := WebSocketResponse{ID: msg.ID, Type: msg.Type, Success: msg.Success, Message: msg.Message}
+			if len(msg.Data) > 0 {
+				if err := json.Unmarshal(msg.Data, &response.Data); err != nil {
+					log.Printf("Failed to parse response data: %v", err)
+				}
+			}
 			c.handleCommandResponse(&response)
+		case "hello":
+			c.handleHello(conn, &msg)
 		case "pong":
 			// Heartbeat response, ignore
 		case "error":
-			log.Printf("Error from game: %s", response.Message)
+			if c.refusesHello(conn, msg.ID) {
+				c.handleHello(conn, nil)
+				continue
+			}
+			log.Printf("Error from game: %s", msg.Message)
 		}
 	}
 }
 
-func (c *GameClient) handleStateUpdate(response *WebSocketResponse) {
-	data, err := json.Marshal(response.Data)
-	if err != nil {
-		log.Printf("Failed to marshal state data: %v", err)
+// refusesHello reports whether an error from conn answers the pending hello.
+// Mods that predate the handshake send their error without an id, so any
+// error while the hello is unanswered counts as one.
+func (c *GameClient) refusesHello(conn *websocket.Conn, id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn != conn || id != "" && id != c.helloID {
+		return false
+	}
+	select {
+	case <-c.hello:
+		return false
+	default:
+		return true
+	}
+}
+
+// handleHello records the mod's capabilities; msg is nil when the mod predates
+// the handshake. Deltas are requested only from mods that advertise them.
+func (c *GameClient) handleHello(conn *websocket.Conn, msg *wireMessage) {
+	var caps Capabilities
+	if msg != nil {
+		if err := json.Unmarshal(msg.Data, &caps); err != nil {
+			log.Printf("[HELLO] Failed to parse capabilities: %v", err)
+		}
+	}
+
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
 		return
 	}
+	c.caps = caps
+	writer := c.writer
+	select {
+	case <-c.hello:
+	default:
+		close(c.hello)
+	}
+	c.mu.Unlock()
+
+	switch {
+	case !caps.Known():
+		log.Printf("[HELLO] Connected to %s; all tools are offered and unsupported actions fail when called", caps)
+	case caps.ProtocolVersion > protocolVersion:
+		log.Printf("[HELLO] Connected to %s, which is newer than this server (protocol %d); update stardew-mcp", caps, protocolVersion)
+	default:
+		log.Printf("[HELLO] Connected to %s with %d actions", caps, len(caps.Actions))
+	}
+
+	if config.Server.Connection.StateDeltas && caps.HasFeature("state_deltas") {
+		go writer.SendJSON(context.Background(), WebSocketMessage{Type: "enable_deltas"})
+	}
+}
 
+// handleStateUpdate takes a full snapshot. With deltas enabled it also becomes
+// the base document for the patches that follow.
+func (c *GameClient) handleStateUpdate(conn *websocket.Conn, msg *wireMessage) {
 	var state GameState
-	if err := json.Unmarshal(data, &state); err != nil {
+	if err := json.Unmarshal(msg.Data, &state); err != nil {
 		log.Printf("Failed to parse state: %v", err)
 		return
 	}
 
+	var doc interface{}
+	if msg.Seq != 0 {
+		if err := json.Unmarshal(msg.Data, &doc); err != nil {
+			log.Printf("Failed to parse state: %v", err)
+			return
+		}
+	}
+
 	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
 	c.state = &state
+	c.stateAt = time.Now()
+	c.stateStale = false
+	c.stateDoc = doc
+	c.stateSeq = msg.Seq
+	c.resyncing = false
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
 	c.mu.Unlock()
+
+	c.publishState(&state)
+}
+
+// handleStatePatch applies an RFC 6902 delta to the base document. A sequence
+// gap or a patch that does not apply triggers a full resync.
+func (c *GameClient) handleStatePatch(conn *websocket.Conn, msg *wireMessage) {
+	c.mu.RLock()
+	current := c.conn == conn
+	doc, seq, resyncing := c.stateDoc, c.stateSeq, c.resyncing
+	c.mu.RUnlock()
+	if !current || resyncing {
+		return
+	}
+
+	if doc == nil || msg.Seq != seq+1 {
+		c.requestResync(conn, fmt.Sprintf("expected seq %d, got %d", seq+1, msg.Seq))
+		return
+	}
+
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(msg.Data, &ops); err != nil {
+		c.requestResync(conn, fmt.Sprintf("bad patch: %v", err))
+		return
+	}
+
+	// Only the listener touches stateDoc, so it can be patched outside the lock
+	var state *GameState
+	if len(ops) > 0 {
+		patched, err := applyJSONPatch(doc, ops)
+		if err != nil {
+			c.requestResync(conn, err.Error())
+			return
+		}
+		data, err := json.Marshal(patched)
+		if err == nil {
+			state = &GameState{}
+			err = json.Unmarshal(data, state)
+		}
+		if err != nil {
+			c.requestResync(conn, fmt.Sprintf("patched state does not decode: %v", err))
+			return
+		}
+		doc = patched
+	}
+
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	if state != nil {
+		c.state = state
+	}
+	c.stateAt = time.Now()
+	c.stateStale = false
+	c.stateDoc = doc
+	c.stateSeq = msg.Seq
+	c.mu.Unlock()
+
+	if state != nil {
+		c.publishState(state)
+	}
+}
+
+// requestResync drops the delta base and asks the mod for a full state
+func (c *GameClient) requestResync(conn *websocket.Conn, reason string) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.stateDoc = nil
+	c.resyncing = true
+	writer := c.writer
+	c.mu.Unlock()
+
+	log.Printf("[STATE] Resyncing state (%s)", reason)
+	go func() {
+		if err := writer.SendJSON(context.Background(), WebSocketMessage{Type: "get_state"}); err != nil {
+			log.Printf("[STATE] Failed to request full state: %v", err)
+		}
+	}()
+}
+
+// SubscribeState returns a channel that receives every state update, starting
+// with the current state. Slow readers only see the latest update. Call the
+// returned function to unsubscribe.
+func (c *GameClient) SubscribeState() (<-chan *GameState, func()) {
+	ch := make(chan *GameState, 1)
+	if state := c.GetState(); state != nil {
+		ch <- state
+	}
+
+	c.subscribersMu.Lock()
+	if c.subscribers == nil {
+		c.subscribers = make(map[chan *GameState]struct{})
+	}
+	c.subscribers[ch] = struct{}{}
+	c.subscribersMu.Unlock()
+
+	return ch, func() {
+		c.subscribersMu.Lock()
+		delete(c.subscribers, ch)
+		c.subscribersMu.Unlock()
+	}
+}
+
+func (c *GameClient) publishState(state *GameState) {
+	c.subscribersMu.Lock()
+	defer c.subscribersMu.Unlock()
+	for ch := range c.subscribers {
+		// Replace an unread update rather than block the listener
+		select {
+		case ch <- state:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- state
+		}
+	}
 }
 
 func (c *GameClient) handleCommandResponse(response *WebSocketResponse) {
@@ -449,125 +799,336 @@ func (c *GameClient) handleCommandResponse(response *WebSocketResponse) {
 	}
 }
 
+// GetState returns the latest game state, or nil while disconnected and until
+// a fresh state arrives after reconnecting
 func (c *GameClient) GetState() *GameState {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	if c.stateStale {
+		return nil
+	}
 	return c.state
 }
 
+// StateAge reports how long ago the last state was received, and false if none ever was
+func (c *GameClient) StateAge() (time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.stateAt.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.stateAt), true
+}
+
+// IsStateStale reports whether the last state predates the current connection
+func (c *GameClient) IsStateStale() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stateStale || c.state == nil
+}
+
+// Capabilities returns what the current connection's mod reported in its hello.
+// The zero value (before the handshake, or for older mods) supports every action.
+func (c *GameClient) Capabilities() Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.caps
+}
+
 func (c *GameClient) IsConnected() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.connected
 }
 
+// backoff produces exponentially growing delays with jitter
+type backoff struct {
+	next time.Duration
+	max  time.Duration
+}
+
+func newBackoff(initial, max time.Duration) *backoff {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+	return &backoff{next: initial, max: max}
+}
+
+// Next returns a delay between half and all of the current step, then doubles the step
+func (b *backoff) Next() time.Duration {
+	d := b.next
+	b.next *= 2
+	if b.next > b.max {
+		b.next = b.max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// SendCommand sends a command and waits for its response using the action's configured timeout
 func (c *GameClient) SendCommand(action string, params map[string]interface{}) (*WebSocketResponse, error) {
-	if !c.IsConnected() {
-		return nil, fmt.Errorf("not connected to game")
+	return c.SendCommandContext(context.Background(), action, params)
+}
+
+// SendCommandContext sends a command and waits for its response until ctx is done.
+// Without a deadline on ctx the per-action timeout from config applies.
+func (c *GameClient) SendCommandContext(ctx context.Context, action string, params map[string]interface{}) (*WebSocketResponse, error) {
+	c.mu.RLock()
+	connected, caps := c.connected, c.caps
+	c.mu.RUnlock()
+	if !connected {
+		return nil, ErrDisconnected
+	}
+	if !caps.Supports(action) {
+		return nil, &UnsupportedActionError{Action: action, Mod: caps}
+	}
+	if err := c.lease.Check(controllerFrom(ctx).id); err != nil {
+		return nil, err
+	}
+
+	// Wait for a turn; the command timeout starts once it is this command's turn
+	release, err := c.scheduler.Acquire(ctx, priorityFrom(ctx), action)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Server.Connection.CommandTimeoutFor(action))
+		defer cancel()
 	}
 
-	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	// Tile-changing cheats are diffed against fresh states for undo; see journal.go
+	var before *GameState
+	if shouldJournal(ctx, action, params) {
+		if before, err = c.freshState(ctx); err != nil {
+			log.Printf("[JOURNAL] Not journaling %s: %v", action, err)
+		}
+	}
 
-	msg := WebSocketMessage{
-		ID:     id,
+	resp, err := c.roundTrip(ctx, WebSocketMessage{
 		Type:   "command",
 		Action: action,
 		Params: params,
+	}, action)
+	if err == nil && resp.Success && before != nil {
+		resp.Message += c.journalCommand(action, before)
 	}
+	return resp, err
+}
 
-	ch := make(chan *WebSocketResponse, 1)
-	c.responsesMu.Lock()
-	c.responses[id] = ch
-	c.responsesMu.Unlock()
+// roundTrip sends msg under a fresh id and waits for the mod's response to it.
+// label names the command in errors.
+func (c *GameClient) roundTrip(ctx context.Context, msg WebSocketMessage, label string) (*WebSocketResponse, error) {
+	// The connection may have changed while the command was queued
+	c.mu.RLock()
+	writer, done, connected := c.writer, c.done, c.connected
+	c.mu.RUnlock()
+	if !connected {
+		return nil, ErrDisconnected
+	}
+
+	id := c.nextID()
+	msg.ID = id
 
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return nil, err
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
+	ch := make(chan *WebSocketResponse, 1)
+	c.responsesMu.Lock()
+	c.responses[id] = ch
+	c.responsesMu.Unlock()
 
-	if err != nil {
+	// Whatever happens below, a response arriving later has nowhere to go
+	defer func() {
 		c.responsesMu.Lock()
 		delete(c.responses, id)
 		c.responsesMu.Unlock()
-		return nil, err
+	}()
+
+	if err := writer.Send(ctx, data); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", label, err)
 	}
 
-	// Timeout for command responses (15 seconds is sufficient for most operations)
 	select {
 	case response := <-ch:
 		return response, nil
-	case <-time.After(15 * time.Second):
-		c.responsesMu.Lock()
-		delete(c.responses, id)
-		c.responsesMu.Unlock()
-		return nil, fmt.Errorf("timeout waiting for response")
+	case <-done:
+		return nil, fmt.Errorf("%s: %w", label, ErrDisconnected)
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timeout waiting for response to %s: %w", label, ctx.Err())
+		}
+		return nil, fmt.Errorf("%s cancelled: %w", label, ctx.Err())
 	}
 }
 
 func main() {
-	autoFlag := flag.Bool("auto", true, "Start in autonomous mode")
-	goalFlag := flag.String("goal", `USE CHEAT MODE to setup the farm:
-1. cheat_mode_enable first
-3. cheat_clear_debris, cheat_cut_trees, cheat_mine_rocks
-4. cheat_hoe_all to till soil
-5. cheat_plant_seeds season appropriate seeds"
-6. cheat_grow_crops then cheat_harvest_all`, "Goal for autonomous mode")
-	urlFlag := flag.String("url", "ws://localhost:8765/game", "WebSocket URL for the game mod")
+	defaults := DefaultConfig()
+
+	configFlag := flag.String("config", "config.yaml", "Path to YAML config file")
+	autoFlag := flag.Bool("auto", defaults.Server.AutoStart, "Start in autonomous mode")
+	goalFlag := flag.String("goal", defaults.Agent.DefaultGoal, "Goal for autonomous mode")
+	doneFlag := flag.String("done", "", "Conditions that end the goal, e.g. \"money >= 5000; crops >= 15\"")
+	urlFlag := flag.String("url", defaults.Server.GameURL, "WebSocket URL for the game mod")
 
 	// Server mode flags for remote agent connections
 	serverMode := flag.Bool("server", false, "Run as server to accept remote agent connections")
-	hostFlag := flag.String("host", "127.0.0.1", "Host to bind to for remote connections")
-	portFlag := flag.Int("port", 8765, "Port to listen on for remote connections")
+	hostFlag := flag.String("host", defaults.Remote.Host, "Host to bind to for remote connections")
+	portFlag := flag.Int("port", defaults.Remote.Port, "Port to listen on for remote connections")
 
 	// OpenClaw Gateway mode
 	openclawMode := flag.Bool("openclaw", false, "Connect to OpenClaw Gateway as tool provider")
-	openclawURL := flag.String("openclaw-url", "ws://127.0.0.1:18789", "OpenClaw Gateway URL")
+	openclawURL := flag.String("openclaw-url", defaults.OpenClaw.GatewayURL, "OpenClaw Gateway URL")
 	openclawToken := flag.String("openclaw-token", "", "OpenClaw Gateway token (optional)")
 
+	// MCP stdio mode for MCP-capable desktop clients
+	stdioMode := flag.Bool("stdio", false, "Serve the Model Context Protocol over stdin/stdout")
+
+	// Operator console for the autonomous agent
+	tuiMode := flag.Bool("tui", false, "Run the autonomous agent behind an interactive terminal console")
+
+	// LLM backend for autonomous mode
+	llmFlag := flag.String("llm", defaults.Agent.LLM.Backend, "LLM backend for autonomous mode: copilot, openai or scripted")
+	modelFlag := flag.String("model", defaults.Agent.LLM.Model, "Model name for the LLM backend")
+	llmURLFlag := flag.String("llm-url", defaults.Agent.LLM.BaseURL, "Base URL of an OpenAI-compatible API for -llm openai")
+	llmScriptFlag := flag.String("llm-script", "", "YAML/JSON script of turns for -llm scripted")
+
+	// Offline development without Stardew Valley
+	fakeGame := flag.Bool("fake-game", false, "Run against a built-in simulated farm instead of the SMAPI mod")
+	fakeSeed := flag.Int64("fake-seed", 1, "Farm layout seed for -fake-game")
+
 	flag.Parse()
 
+	// Resolve configuration: flags > env > file > defaults
+	configPath := *configFlag
+	explicitConfig := false
+	if v := os.Getenv("STARDEW_MCP_CONFIG"); v != "" {
+		configPath, explicitConfig = v, true
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			configPath, explicitConfig = *configFlag, true
+		}
+	})
+	cfg, err := LoadConfig(configPath, explicitConfig)
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "auto":
+			cfg.Server.AutoStart = *autoFlag
+		case "goal":
+			cfg.Agent.DefaultGoal = *goalFlag
+		case "done":
+			cfg.Agent.DefaultGoalDone = splitGoalConditions(*doneFlag)
+		case "url":
+			cfg.Server.GameURL = *urlFlag
+		case "host":
+			cfg.Remote.Host = *hostFlag
+		case "port":
+			cfg.Remote.Port = *portFlag
+		case "openclaw-url":
+			cfg.OpenClaw.GatewayURL = *openclawURL
+		case "openclaw-token":
+			cfg.OpenClaw.Token = *openclawToken
+		case "llm":
+			cfg.Agent.LLM.Backend = *llmFlag
+		case "model":
+			cfg.Agent.LLM.Model = *modelFlag
+		case "llm-url":
+			cfg.Agent.LLM.BaseURL = *llmURLFlag
+		case "llm-script":
+			cfg.Agent.LLM.Script = *llmScriptFlag
+		}
+	})
+	config = cfg
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "goal" {
+			goalFromFlags = true
+		}
+	})
+	if os.Getenv("STARDEW_MCP_GOAL") != "" {
+		goalFromFlags = true
+	}
+	goal, err := cfg.Agent.Goal()
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	if goalQueue, err = LoadGoalQueue(cfg.Agent.GoalQueueFile); err != nil {
+		log.Fatalf("Goal queue error: %v", err)
+	}
+
+	if *fakeGame {
+		// Every instance gets its own simulated farm
+		urls := cfg.Server.InstanceURLs()
+		names := make([]string, 0, len(urls))
+		for name := range urls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			url, err := startFakeGame(*fakeSeed + int64(i))
+			if err != nil {
+				log.Fatalf("Failed to start fake game: %v", err)
+			}
+			if name == defaultInstance {
+				cfg.Server.GameURL = url
+			} else {
+				cfg.Server.Instances[name] = url
+			}
+		}
+	}
+
+	if gameDialer, err = newDialer(cfg.Server.TLSCA); err != nil {
+		log.Fatalf("Config error: invalid server.tls_ca: %v", err)
+	}
+
 	gameClient = NewGameClient()
+	gamePool = NewGameClientPool(cfg.Server.InstanceURLs())
+	if names := gamePool.Names(); len(names) > 1 {
+		log.Printf("[POOL] Game instances: %s", strings.Join(names, ", "))
+	}
+
+	// If MCP stdio mode
+	if *stdioMode {
+		runStdioMode()
+		return
+	}
 
 	// If OpenClaw Gateway mode
 	if *openclawMode {
-		runOpenClawGatewayMode(*openclawURL, *urlFlag, *openclawToken, *autoFlag, *goalFlag)
+		runOpenClawGatewayMode(cfg.OpenClaw.GatewayURL, cfg.OpenClaw.Token, cfg.Server.AutoStart, goal)
 		return
 	}
 
 	// If server mode, run as remote agent server
 	if *serverMode {
-		runServerMode(*hostFlag, *portFlag, *urlFlag)
+		runServerMode(cfg.Remote.Host, cfg.Remote.Port)
+		return
+	}
+
+	// If console mode, run the agent behind the terminal UI
+	if *tuiMode {
+		if err := runConsole(goal); err != nil {
+			log.Fatalf("Console error: %v", err)
+		}
 		return
 	}
 
 	// Original behavior - connect to game and optionally run agent
 	go func() {
-		for {
-			if err := gameClient.Connect(*urlFlag); err != nil {
-				log.Printf("Failed to connect to game (will retry): %v", err)
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			log.Println("Connected to Stardew Valley!")
-
-			if *autoFlag {
-				log.Printf("Starting autonomous agent with goal: %s", *goalFlag)
+		gamePool.ConnectAll()
+		log.Println("Connected to Stardew Valley!")
 
-				agent, err := NewStardewAgent()
-				if err != nil {
-					log.Printf("Failed to start agent: %v", err)
-					return
-				}
-				if err := agent.StartSession(*goalFlag); err != nil {
-					log.Printf("Failed to start session: %v", err)
-					return
-				}
-			}
-			break
+		if cfg.Server.AutoStart {
+			startAgent(goal)
 		}
 	}()
 
@@ -575,6 +1136,78 @@ func main() {
 	select {}
 }
 
+// goalFromFlags is set when -goal or STARDEW_MCP_GOAL chose the startup goal
+var goalFromFlags bool
+
+// agentStarted makes sure only one autonomous agent runs per process
+var agentStarted atomic.Bool
+
+// startAgent runs the autonomous agent on the goal queue. An empty queue
+// starts with goal; a saved queue is picked up where it left off, behind
+// goal if it was given with -goal or STARDEW_MCP_GOAL. If the agent fails to
+// start, a later call may try again.
+func startAgent(goal Goal) {
+	if !agentStarted.CompareAndSwap(false, true) {
+		log.Printf("Autonomous agent already running")
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			agentStarted.Store(false)
+		}
+	}()
+
+	queued := goalQueue.Snapshot().Goals
+	switch {
+	case len(queued) == 0:
+		log.Printf("Starting autonomous agent with goal: %s", goal.Text)
+		if _, err := goalQueue.Enqueue(goal.Text, goal.Done, 0, "startup"); err != nil {
+			log.Printf("Failed to queue goal: %v", err)
+			return
+		}
+	case goalFromFlags && queued[0].Text != goal.Text:
+		log.Printf("WARNING: %d saved goal(s) in %s; queuing the -goal/STARDEW_MCP_GOAL goal ahead of them: %s", len(queued), config.Agent.GoalQueueFile, goal.Text)
+		if _, err := goalQueue.Enqueue(goal.Text, goal.Done, 1, "startup"); err != nil {
+			log.Printf("Failed to queue goal: %v", err)
+			return
+		}
+	default:
+		log.Printf("Starting autonomous agent with %d saved goal(s) from %s", len(queued), config.Agent.GoalQueueFile)
+	}
+
+	agent, err := NewStardewAgent(config.Agent.LLM, gameClient, goalQueue)
+	if err != nil {
+		log.Printf("Failed to start agent: %v", err)
+		agentFeed.Publish("error", "Failed to start agent: %v", err)
+		return
+	}
+	if err := agent.StartSession(context.Background()); err != nil {
+		log.Printf("Failed to start session: %v", err)
+		agentFeed.Publish("error", "Failed to start session: %v", err)
+		agent.backend.Close()
+		return
+	}
+	started = true
+}
+
+// startFakeGame serves a simulated mod on a free local port and returns its URL
+func startFakeGame(seed int64) (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	fake := fakegame.NewServer(fakegame.Options{Seed: seed})
+	go func() {
+		if err := fake.Serve(ln); err != nil {
+			log.Printf("[FAKE GAME] Server error: %v", err)
+		}
+	}()
+	url := fmt.Sprintf("ws://%s/game", ln.Addr())
+	log.Printf("[FAKE GAME] Simulated farm (seed %d) listening on %s", seed, url)
+	return url, nil
+}
+
 // ============================================================================
 // OpenClaw Gateway Protocol Implementation
 // ============================================================================
@@ -596,11 +1229,11 @@ type OpenClawResponse struct {
 }
 
 type OpenClawEvent struct {
-	Type        string                 `json:"type"`
-	Event       string                 `json:"event"`
-	Payload     map[string]interface{} `json:"payload,omitempty"`
-	Seq         int                    `json:"seq,omitempty"`
-	StateVersion int                   `json:"stateVersion,omitempty"`
+	Type         string                 `json:"type"`
+	Event        string                 `json:"event"`
+	Payload      map[string]interface{} `json:"payload,omitempty"`
+	Seq          int                    `json:"seq,omitempty"`
+	StateVersion int                    `json:"stateVersion,omitempty"`
 }
 
 // OpenClaw Gateway connection
@@ -613,7 +1246,11 @@ func connectToOpenClawGateway(gatewayURL string, token string) (*websocket.Conn,
 		header.Set("Authorization", "Bearer "+token)
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(gatewayURL, header)
+	dialer, err := newDialer(config.OpenClaw.TLSCA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openclaw.tls_ca: %w", err)
+	}
+	conn, _, err := dialer.Dial(gatewayURL, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to OpenClaw Gateway: %w", err)
 	}
@@ -625,10 +1262,11 @@ func connectToOpenClawGateway(gatewayURL string, token string) (*websocket.Conn,
 		Method: "connect",
 		Params: map[string]interface{}{
 			"caps": []string{"tools.call", "tools.catalog", "operator.read"},
-			"name": "stardew-mcp",
+			"name": config.OpenClaw.AgentName,
 		},
 	}
 
+	// The handshake runs before serveOpenClawGateway starts the write pump, so writing directly is safe
 	if err := conn.WriteJSON(connectReq); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to send connect request: %w", err)
@@ -694,18 +1332,10 @@ func registerToolsWithGateway(conn *websocket.Conn) error {
 }
 
 // Run in OpenClaw Gateway mode - connects to Gateway as a tool provider
-func runOpenClawGatewayMode(gatewayURL string, gameURL string, token string, autoStart bool, goal string) {
-	// First connect to the game
-	log.Printf("Connecting to Stardew Valley at %s...", gameURL)
-	for {
-		if err := gameClient.Connect(gameURL); err != nil {
-			log.Printf("Failed to connect to game (will retry): %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
-		log.Println("Connected to Stardew Valley!")
-		break
-	}
+func runOpenClawGatewayMode(gatewayURL string, token string, autoStart bool, goal Goal) {
+	// First connect to the games
+	gamePool.ConnectAll()
+	log.Println("Connected to Stardew Valley!")
 
 	// Connect to OpenClaw Gateway
 	conn, err := connectToOpenClawGateway(gatewayURL, token)
@@ -713,11 +1343,11 @@ func runOpenClawGatewayMode(gatewayURL string, gameURL string, token string, aut
 		log.Printf("Failed to connect to OpenClaw Gateway: %v", err)
 		log.Println("Falling back to standalone mode...")
 		if autoStart {
-			startAutonomousAgent(goal)
+			startAgent(goal)
+			select {}
 		}
 		return
 	}
-	defer conn.Close()
 
 	// Register tools
 	if err := registerToolsWithGateway(conn); err != nil {
@@ -726,15 +1356,48 @@ func runOpenClawGatewayMode(gatewayURL string, gameURL string, token string, aut
 
 	// Start autonomous agent if enabled
 	if autoStart {
-		startAutonomousAgent(goal)
+		startAgent(goal)
+	}
+
+	for {
+		err := serveOpenClawGateway(conn)
+		conn.Close()
+		log.Printf("Gateway read error: %v", err)
+
+		if !config.OpenClaw.AutoReconnect {
+			return
+		}
+
+		// Reconnect and re-register until the Gateway is back
+		backoff := newBackoff(config.Server.Connection.ReconnectDelayDuration(), config.Server.Connection.ReconnectMaxDelayDuration())
+		for {
+			time.Sleep(backoff.Next())
+			conn, err = connectToOpenClawGateway(gatewayURL, token)
+			if err != nil {
+				log.Printf("Gateway reconnect failed: %v", err)
+				continue
+			}
+			if err := registerToolsWithGateway(conn); err != nil {
+				log.Printf("Failed to register tools: %v", err)
+			}
+			break
+		}
 	}
+}
+
+// serveOpenClawGateway handles messages from the Gateway until the connection fails
+func serveOpenClawGateway(conn *websocket.Conn) error {
+	// In-flight tool calls are abandoned when the Gateway connection goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writer := newWritePump(conn)
+	defer writer.Close()
 
-	// Handle messages from Gateway
 	for {
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("Gateway read error: %v", err)
-			break
+			return err
 		}
 
 		var req OpenClawRequest
@@ -744,22 +1407,47 @@ func runOpenClawGatewayMode(gatewayURL string, gameURL string, token string, aut
 
 		// Handle tool calls
 		if req.Type == "req" && req.Method == "tools.call" {
-			go handleToolCall(conn, req)
+			go handleToolCall(ctx, writer, req)
 		}
 	}
 }
 
 // Handle tool call from OpenClaw Gateway
-func handleToolCall(conn *websocket.Conn, req OpenClawRequest) {
+func handleToolCall(ctx context.Context, writer *writePump, req OpenClawRequest) {
 	toolName, ok := req.Params["name"].(string)
 	if !ok {
-		sendErrorResponse(conn, req.ID, "missing tool name")
+		sendErrorResponse(ctx, writer, req.ID, "missing tool name")
 		return
 	}
 
-	params, _ := req.Params["params"].(map[string]interface{})
+	// A bad tool call must never take down the Gateway connection
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Tool %s panicked: %v", toolName, r)
+			sendErrorResponse(ctx, writer, req.ID, fmt.Sprintf("tool %s failed: %v", toolName, r))
+		}
+	}()
+
+	var params map[string]interface{}
+	if raw, present := req.Params["params"]; present && raw != nil {
+		params, ok = raw.(map[string]interface{})
+		if !ok {
+			resp := OpenClawResponse{
+				Type: "res",
+				ID:   req.ID,
+				Error: map[string]interface{}{
+					"code":    "tool_error",
+					"message": "params must be an object",
+					"field":   "params",
+					"reason":  "type",
+				},
+			}
+			writer.SendJSON(ctx, resp)
+			return
+		}
+	}
 
-	result, err := executeOpenClawTool(toolName, params)
+	result, err := executeOpenClawTool(ctx, toolName, params)
 
 	resp := OpenClawResponse{
 		Type: "res",
@@ -772,17 +1460,24 @@ func handleToolCall(conn *websocket.Conn, req OpenClawRequest) {
 			"code":    "tool_error",
 			"message": err.Error(),
 		}
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			resp.Error["field"] = vErr.Field
+			resp.Error["reason"] = vErr.Reason
+		}
 	} else {
 		resp.Payload = map[string]interface{}{
 			"result": result,
 		}
 	}
 
-	conn.WriteJSON(resp)
+	if err := writer.SendJSON(ctx, resp); err != nil {
+		log.Printf("Failed to send %s result to Gateway: %v", toolName, err)
+	}
 }
 
 // Send error response
-func sendErrorResponse(conn *websocket.Conn, id string, message string) {
+func sendErrorResponse(ctx context.Context, writer *writePump, id string, message string) {
 	resp := OpenClawResponse{
 		Type: "res",
 		ID:   id,
@@ -792,188 +1487,93 @@ func sendErrorResponse(conn *websocket.Conn, id string, message string) {
 			"message": message,
 		},
 	}
-	conn.WriteJSON(resp)
+	writer.SendJSON(ctx, resp)
 }
 
+// gatewayAgent runs registry tools on behalf of OpenClaw Gateway calls
+var gatewayAgent = &StardewAgent{}
+
 // Execute tool and return result
-func executeOpenClawTool(name string, params map[string]interface{}) (interface{}, error) {
-	switch name {
-	case "get_state":
-		return gameClient.GetState(), nil
-	case "get_surroundings":
-		return gameClient.SendCommand("get_surroundings", nil)
-	case "move_to":
-		x := int(params["x"].(float64))
-		y := int(params["y"].(float64))
-		return gameClient.SendCommand("move_to", map[string]interface{}{"x": x, "y": y})
-	case "interact":
-		return gameClient.SendCommand("interact", nil)
-	case "use_tool":
-		return gameClient.SendCommand("use_tool", nil)
-	case "select_item":
-		slot := int(params["slot"].(float64))
-		return gameClient.SendCommand("select_item", map[string]interface{}{"slot": slot})
-	case "switch_tool":
-		tool := params["tool"].(string)
-		return gameClient.SendCommand("switch_tool", map[string]interface{}{"tool": tool})
-	case "face_direction":
-		dir := int(params["direction"].(float64))
-		return gameClient.SendCommand("face_direction", map[string]interface{}{"direction": dir})
-	case "cheat_mode_enable":
-		return gameClient.SendCommand("cheat_mode_enable", nil)
-	case "cheat_warp":
-		location := params["location"].(string)
-		return gameClient.SendCommand("cheat_warp", map[string]interface{}{"location": location})
-	case "cheat_set_money":
-		amount := int(params["amount"].(float64))
-		return gameClient.SendCommand("cheat_set_money", map[string]interface{}{"amount": amount})
-	default:
+func executeOpenClawTool(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+	tool, ok := lookupTool(name)
+	if !ok {
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
+	return tool.Invoke(withController(ctx, "openclaw", "the OpenClaw Gateway"), gatewayAgent, params)
 }
 
 // getStardewToolsForGateway returns tool definitions for OpenClaw Gateway
 func getStardewToolsForGateway() []map[string]interface{} {
-	return []map[string]interface{}{
-		{
-			"name":        "get_state",
-			"description": "Get current game state including player position, inventory, time, and surroundings",
-		},
-		{
-			"name":        "get_surroundings",
-			"description": "Get detailed information about tiles around the player",
-		},
-		{
-			"name":        "move_to",
-			"description": "Move player to specified coordinates",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"x": map[string]interface{}{"type": "integer"},
-					"y": map[string]interface{}{"type": "integer"},
-				},
-				"required": []string{"x", "y"},
-			},
-		},
-		{
-			"name":        "interact",
-			"description": "Interact with object in front of player",
-		},
-		{
-			"name":        "use_tool",
-			"description": "Use currently selected tool",
-		},
-		{
-			"name":        "select_item",
-			"description": "Select item from inventory by slot number",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"slot": map[string]interface{}{"type": "integer"},
-				},
-				"required": []string{"slot"},
-			},
-		},
-		{
-			"name":        "switch_tool",
-			"description": "Switch to tool by name",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"tool": map[string]interface{}{"type": "string"},
-				},
-				"required": []string{"tool"},
-			},
-		},
-		{
-			"name":        "face_direction",
-			"description": "Face a direction",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"direction": map[string]interface{}{"type": "integer", "description": "0=down, 1=left, 2=right, 3=up"},
-				},
-				"required": []string{"direction"},
-			},
-		},
-		{
-			"name":        "cheat_mode_enable",
-			"description": "Enable god-mode cheat commands",
-		},
-		{
-			"name":        "cheat_warp",
-			"description": "Teleport to location",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{"type": "string"},
-				},
-				"required": []string{"location"},
-			},
-		},
-		{
-			"name":        "cheat_set_money",
-			"description": "Set money amount",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"amount": map[string]interface{}{"type": "integer"},
-				},
-				"required": []string{"amount"},
-			},
-		},
-	}
+	return toolCatalog(gameClient)
 }
 
-// runServerMode runs the MCP server that accepts remote agent connections
-func runServerMode(host string, port int, gameURL string) {
-	addr := fmt.Sprintf("%s:%d", host, port)
-
-	// First connect to the game
-	log.Printf("Connecting to Stardew Valley at %s...", gameURL)
-	for {
-		if err := gameClient.Connect(gameURL); err != nil {
-			log.Printf("Failed to connect to game (will retry): %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
-		log.Println("Connected to Stardew Valley!")
-		break
+// serveRemoteAgent serves one remote agent connection on a game instance's /mcp endpoint
+func serveRemoteAgent(w http.ResponseWriter, r *http.Request, upgrader *websocket.Upgrader, client *GameClient, mcpHTTP *MCPHTTPHandler) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		mcpHTTP.ServeHTTP(w, r)
+		return
 	}
 
-	// Set up WebSocket upgrader
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
 	}
+	defer conn.Close()
 
-	// HTTP server for WebSocket connections
-	http.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			log.Printf("WebSocket upgrade failed: %v", err)
-			return
+	log.Printf("Remote agent connected from %s (%s)", r.RemoteAddr, r.URL.Path)
+
+	// Commands run concurrently and are cancelled when the agent disconnects
+	// The context keeps the caller's token but not the request's cancellation
+	ctx, cancel := context.WithCancel(withGameClient(context.WithoutCancel(r.Context()), client))
+	defer cancel()
+
+	// Each connection is its own controller and gives up its lease on disconnect
+	controllerID := "ws:" + r.RemoteAddr
+	ctx = remoteController(ctx, controllerID, "remote agent "+r.RemoteAddr)
+	defer client.lease.Release(controllerID)
+
+	writer := newWritePump(conn)
+	defer writer.Close()
+	writeJSON := func(v interface{}) {
+		if err := writer.SendJSON(ctx, v); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to write to remote agent: %v", err)
 		}
-		defer conn.Close()
+	}
 
-		log.Printf("Remote agent connected from %s", r.RemoteAddr)
+	// At most one state subscription per agent; a new subscribe replaces it
+	var stopSubscription context.CancelFunc
 
-		// Handle messages from remote agent
-		for {
-			_, msg, err := conn.ReadMessage()
-			if err != nil {
-				log.Printf("Remote agent disconnected: %v", err)
-				break
-			}
+	// Handle messages from remote agent
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("Remote agent disconnected: %v", err)
+			break
+		}
 
-			var req WebSocketMessage
-			if err := json.Unmarshal(msg, &req); err != nil {
-				log.Printf("Failed to parse message: %v", err)
-				continue
-			}
+		var req WebSocketMessage
+		if err := json.Unmarshal(msg, &req); err != nil {
+			log.Printf("Failed to parse message: %v", err)
+			continue
+		}
 
-			// Process command and send to game
-			if req.Type == "command" {
-				resp, err := gameClient.SendCommand(req.Action, req.Params)
+		// Process command and send to game
+		if req.Type == "command" {
+			go func(req WebSocketMessage) {
+				// Out-of-scope commands never reach the game
+				err := authorize(ctx, actionScope(req.Action), req.Action)
+				var resp *WebSocketResponse
+				if err != nil {
+					log.Printf("[AUTH] %v", err)
+				} else if priority, perr := priorityParam(req.Priority); perr != nil {
+					err = perr
+				} else {
+					resp, err = client.SendCommandContext(withPriority(ctx, priority), req.Action, req.Params)
+				}
+				if ctx.Err() != nil {
+					return
+				}
 
 				// Send response back to agent
 				response := map[string]interface{}{
@@ -989,43 +1589,263 @@ func runServerMode(host string, port int, gameURL string) {
 					response["data"] = resp.Data
 				}
 
-				conn.WriteJSON(response)
-			} else if req.Type == "get_state" {
-				// Return current game state
-				state := gameClient.GetState()
-				response := map[string]interface{}{
-					"id":   req.ID,
-					"type": "state",
-					"data": state,
+				writeJSON(response)
+			}(req)
+		} else if req.Type == "batch" {
+			go func(req WebSocketMessage) {
+				// Every step must be in scope before any of them runs
+				steps, stopOnFailure, err := parseBatchSteps(req.Params)
+				for i := 0; err == nil && i < len(steps); i++ {
+					if err = authorize(ctx, actionScope(steps[i].Action), steps[i].Action); err != nil {
+						log.Printf("[AUTH] %v", err)
+					}
+				}
+				var resp *BatchResponse
+				if err == nil {
+					var priority Priority
+					if priority, err = priorityParam(req.Priority); err == nil {
+						resp, err = client.SendBatch(withPriority(ctx, priority), steps, stopOnFailure)
+					}
+				}
+				if ctx.Err() != nil {
+					return
 				}
-				conn.WriteJSON(response)
-			} else if req.Type == "ping" {
+
 				response := map[string]interface{}{
-					"id":   req.ID,
-					"type": "pong",
+					"id":      req.ID,
+					"type":    "response",
+					"success": err == nil,
 				}
-				conn.WriteJSON(response)
+				if err != nil {
+					response["error"] = err.Error()
+				} else {
+					response["success"] = resp.Success
+					response["message"] = resp.Message
+					response["data"] = map[string]interface{}{"results": resp.Results}
+				}
+				writeJSON(response)
+			}(req)
+		} else if req.Type == "get_state" {
+			// Return current game state
+			state := client.GetState()
+			response := map[string]interface{}{
+				"id":   req.ID,
+				"type": "state",
+				"data": state,
+			}
+			writeJSON(response)
+		} else if req.Type == "subscribe" {
+			// Push filtered state updates until unsubscribe or disconnect
+			sub, err := parseSubscription(req.Params)
+			if err != nil {
+				writeJSON(map[string]interface{}{
+					"id":      req.ID,
+					"type":    "response",
+					"success": false,
+					"error":   err.Error(),
+				})
+				continue
+			}
+			if stopSubscription != nil {
+				stopSubscription()
+			}
+			subCtx, subCancel := context.WithCancel(ctx)
+			stopSubscription = subCancel
+			writeJSON(map[string]interface{}{
+				"id":      req.ID,
+				"type":    "response",
+				"success": true,
+				"message": sub.describe(),
+			})
+			go runStateSubscription(subCtx, sub, func(data interface{}) {
+				writeJSON(map[string]interface{}{
+					"type": "state",
+					"data": data,
+				})
+			})
+		} else if req.Type == "acquire_control" {
+			ttl, _ := req.Params["ttl"].(float64)
+			preempt, _ := req.Params["preempt"].(bool)
+			response := map[string]interface{}{
+				"id":      req.ID,
+				"type":    "response",
+				"success": true,
 			}
+			if info, err := acquireControl(ctx, ttl, preempt); err != nil {
+				response["success"] = false
+				response["error"] = err.Error()
+			} else {
+				response["message"] = fmt.Sprintf("Control acquired for %v", time.Until(info.ExpiresAt).Round(time.Second))
+				response["data"] = info
+			}
+			writeJSON(response)
+		} else if req.Type == "release_control" {
+			message := "Control released"
+			if !client.lease.Release(controllerID) {
+				message = "Not holding control"
+			}
+			writeJSON(map[string]interface{}{
+				"id":      req.ID,
+				"type":    "response",
+				"success": true,
+				"message": message,
+			})
+		} else if req.Type == "goals" {
+			// Operator access to the goal queue: params {op, id, text, done, position}
+			op, _ := req.Params["op"].(string)
+			var params GoalQueueParams
+			raw, _ := json.Marshal(req.Params)
+			response := map[string]interface{}{
+				"id":      req.ID,
+				"type":    "response",
+				"success": true,
+			}
+			var result interface{}
+			err := json.Unmarshal(raw, &params)
+			if err == nil {
+				result, err = goalQueueOp(ctx, op, params)
+			}
+			if err != nil {
+				response["success"] = false
+				response["error"] = err.Error()
+			} else {
+				response["data"] = result
+			}
+			writeJSON(response)
+		} else if req.Type == "unsubscribe" {
+			if stopSubscription != nil {
+				stopSubscription()
+				stopSubscription = nil
+			}
+			writeJSON(map[string]interface{}{
+				"id":      req.ID,
+				"type":    "response",
+				"success": true,
+				"message": "Unsubscribed",
+			})
+		} else if req.Type == "ping" {
+			response := map[string]interface{}{
+				"id":   req.ID,
+				"type": "pong",
+			}
+			writeJSON(response)
+		}
+	}
+
+}
+
+// runServerMode runs the MCP server that accepts remote agent connections
+func runServerMode(host string, port int) {
+	addr := fmt.Sprintf("%s:%d", host, port)
+
+	tokens, err := loadTokens(config.Remote.Auth)
+	if err != nil {
+		log.Fatalf("Auth config error: %v", err)
+	}
+	tlsConfig, err := serverTLSConfig(config.Remote)
+	if err != nil {
+		log.Fatalf("TLS config error: %v", err)
+	}
+	mutualTLS := tlsConfig != nil && tlsConfig.ClientCAs != nil
+	switch {
+	case len(tokens) == 0 && !mutualTLS && !isLoopbackHost(host):
+		log.Fatalf("[AUTH] Refusing to serve %s without authentication - anyone who could reach it could control the game, including cheats. Set remote.auth.tokens or remote.tls_client_ca in config.yaml, or bind to 127.0.0.1.", addr)
+	case len(tokens) > 0 && tlsConfig == nil && !isLoopbackHost(host):
+		log.Printf("[AUTH] WARNING: %d bearer token(s) loaded, but without remote.tls_cert they travel in plaintext", len(tokens))
+	case len(tokens) > 0:
+		log.Printf("[AUTH] %d bearer token(s) loaded", len(tokens))
+	}
+	if mutualTLS {
+		log.Printf("[AUTH] Client certificates required (%s)", config.Remote.TLSClientCA)
+	}
+
+	// First connect to the games
+	gamePool.ConnectAll()
+	log.Println("Connected to Stardew Valley!")
+
+	// Operators steer this agent through the goal queue
+	if config.Remote.RunAgent {
+		goal, _ := config.Agent.Goal() // validated at startup
+		go startAgent(goal)
+	}
+
+	// Set up WebSocket upgrader
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	// serveInstance handles /mcp for one game instance. MCP Streamable HTTP
+	// shares the path with the WebSocket dialect.
+	serveInstance := func(client *GameClient) http.HandlerFunc {
+		mcpHTTP := NewMCPHTTPHandler(NewMCPServer(&StardewAgent{}), client)
+		return requireToken(tokens, func(w http.ResponseWriter, r *http.Request) {
+			serveRemoteAgent(w, r, &upgrader, client, mcpHTTP)
+		})
+	}
+	instances := make(map[string]http.HandlerFunc)
+	for _, name := range gamePool.Names() {
+		client, _ := gamePool.Get(name)
+		instances[name] = serveInstance(client)
+	}
+
+	// /mcp is the default instance, /mcp/{instance} any configured one
+	http.HandleFunc("/mcp", instances[defaultInstance])
+	http.HandleFunc("/mcp/{instance}", func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := instances[r.PathValue("instance")]
+		if !ok {
+			http.Error(w, "unknown game instance: "+r.PathValue("instance"), http.StatusNotFound)
+			return
 		}
+		handler(w, r)
 	})
 
+	// Goal queue of the autonomous agent; listing needs the read scope, changes the operator scope
+	http.HandleFunc("GET /goals", requireToken(tokens, serveGoalQueue("list")))
+	http.HandleFunc("POST /goals", requireToken(tokens, serveGoalQueue("enqueue")))
+	http.HandleFunc("POST /goals/pause", requireToken(tokens, serveGoalQueue("pause")))
+	http.HandleFunc("POST /goals/resume", requireToken(tokens, serveGoalQueue("resume")))
+	http.HandleFunc("POST /goals/{id}/move", requireToken(tokens, serveGoalQueue("move")))
+	http.HandleFunc("DELETE /goals/{id}", requireToken(tokens, serveGoalQueue("cancel")))
+
 	// Also handle root path
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"status": "ok", "service": "stardew-mcp-remote"}`))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "ok",
+			"service":   "stardew-mcp-remote",
+			"instances": gamePool.Status(),
+		})
 	})
 
 	log.Printf("========================================")
 	log.Printf("Stardew MCP Server - Remote Mode")
 	log.Printf("========================================")
-	log.Printf("Listening for remote agents on: ws://%s/mcp", addr)
-	log.Printf("Game connected at: %s", gameURL)
+	wsScheme, httpScheme := "ws", "http"
+	if tlsConfig != nil {
+		wsScheme, httpScheme = "wss", "https"
+	}
+	log.Printf("Listening for remote agents on: %s://%s/mcp", wsScheme, addr)
+	log.Printf("MCP Streamable HTTP endpoint:   %s://%s/mcp", httpScheme, addr)
+	for _, name := range gamePool.Names() {
+		if name == defaultInstance {
+			log.Printf("Game connected at: %s", gamePool.URL(name))
+		} else {
+			log.Printf("Instance %q at: %s://%s/mcp/%s -> %s", name, wsScheme, addr, name, gamePool.URL(name))
+		}
+	}
 	log.Printf("========================================")
 	log.Printf("Waiting for remote connections...")
 	log.Printf("(Press Ctrl+C to stop)")
 	log.Printf("========================================")
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	server := &http.Server{Addr: addr, TLSConfig: tlsConfig}
+	if tlsConfig != nil {
+		// The certificate is already in TLSConfig
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil {
 		log.Printf("HTTP server error: %v", err)
 	}
 }
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// MCP Streamable HTTP transport (POST/GET/DELETE + Server-Sent Events)
+// ============================================================================
+
+const (
+	mcpSessionHeader   = "Mcp-Session-Id"
+	mcpEventHistory    = 256 // GET stream events kept per session for Last-Event-ID replay
+	mcpSSEKeepAlive    = 15 * time.Second
+	mcpMaxRequestBytes = 4 << 20
+)
+
+// mcpEvent is a single SSE event with a per-session monotonically increasing id
+type mcpEvent struct {
+	ID   int64
+	Data []byte
+}
+
+// mcpSession tracks one Streamable HTTP client. Event ids are unique across
+// the session, but only the GET stream's events are kept for replay: replies
+// to a POST belong to that request's own stream.
+type mcpSession struct {
+	id       string
+	token    *TokenConfig // bearer token that created the session, nil without auth
+	mu       sync.Mutex
+	nextID   int64
+	history  []mcpEvent // GET stream events
+	streams  map[chan mcpEvent]struct{}
+	lastSeen time.Time
+}
+
+func newMCPSession(token *TokenConfig) *mcpSession {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return &mcpSession{
+		id:       hex.EncodeToString(buf),
+		token:    token,
+		streams:  make(map[chan mcpEvent]struct{}),
+		lastSeen: time.Now(),
+	}
+}
+
+// reply assigns an event id to a POST response without storing it
+func (s *mcpSession) reply(data []byte) mcpEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return mcpEvent{ID: s.nextID, Data: data}
+}
+
+// record assigns an event id and stores the event for GET stream replay
+func (s *mcpSession) record(data []byte) mcpEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	ev := mcpEvent{ID: s.nextID, Data: data}
+	s.history = append(s.history, ev)
+	if len(s.history) > mcpEventHistory {
+		s.history = s.history[len(s.history)-mcpEventHistory:]
+	}
+	return ev
+}
+
+// publish records an event and fans it out to every open GET stream
+func (s *mcpSession) publish(data []byte) {
+	ev := s.record(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.streams {
+		select {
+		case ch <- ev:
+		default:
+			// Slow stream - the client can resume with Last-Event-ID
+		}
+	}
+}
+
+// replayAfter returns stored events newer than lastID
+func (s *mcpSession) replayAfter(lastID int64) []mcpEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []mcpEvent
+	for _, ev := range s.history {
+		if ev.ID > lastID {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+func (s *mcpSession) attach() chan mcpEvent {
+	ch := make(chan mcpEvent, 32)
+	s.mu.Lock()
+	s.streams[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *mcpSession) detach(ch chan mcpEvent) {
+	s.mu.Lock()
+	delete(s.streams, ch)
+	s.mu.Unlock()
+}
+
+func (s *mcpSession) closeStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.streams {
+		close(ch)
+		delete(s.streams, ch)
+	}
+}
+
+// MCPHTTPHandler serves MCP over Streamable HTTP using the same dispatch as the stdio transport
+type MCPHTTPHandler struct {
+	server   *MCPServer
+	client   *GameClient // game instance this endpoint serves
+	mu       sync.RWMutex
+	sessions map[string]*mcpSession
+}
+
+// NewMCPHTTPHandler creates a Streamable HTTP handler for one game instance and
+// starts the state notifier and the idle session reaper
+func NewMCPHTTPHandler(server *MCPServer, client *GameClient) *MCPHTTPHandler {
+	h := &MCPHTTPHandler{
+		server:   server,
+		client:   client,
+		sessions: make(map[string]*mcpSession),
+	}
+	go h.notifyStateChanges()
+	go h.reapIdleSessions()
+	return h
+}
+
+func (h *MCPHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *MCPHTTPHandler) session(r *http.Request) *mcpSession {
+	id := r.Header.Get(mcpSessionHeader)
+	if id == "" {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	sess := h.sessions[id]
+	if sess != nil {
+		sess.mu.Lock()
+		sess.lastSeen = time.Now()
+		sess.mu.Unlock()
+	}
+	return sess
+}
+
+// foreign rejects requests for a session created with a different token
+func (h *MCPHTTPHandler) foreign(w http.ResponseWriter, r *http.Request, sess *mcpSession) bool {
+	if token := tokenFrom(r.Context()); token != sess.token {
+		log.Printf("[AUTH] Rejected %s %s from %s: session %s belongs to another token", r.Method, r.URL.Path, r.RemoteAddr, sess.id)
+		http.Error(w, "session belongs to another token", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+func (h *MCPHTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, mcpMaxRequestBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	// A POST body is either a single JSON-RPC message or a batch
+	var messages []json.RawMessage
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(body, &messages); err != nil {
+			writeJSON(w, http.StatusBadRequest, rpcError(nil, jsonRPCParseError, "parse error: "+err.Error()))
+			return
+		}
+	} else {
+		messages = []json.RawMessage{body}
+	}
+
+	isInitialize := false
+	for _, msg := range messages {
+		var req JSONRPCRequest
+		if json.Unmarshal(msg, &req) == nil && req.Method == "initialize" {
+			isInitialize = true
+		}
+	}
+
+	sess := h.session(r)
+	if isInitialize {
+		sess = newMCPSession(tokenFrom(r.Context()))
+		h.mu.Lock()
+		h.sessions[sess.id] = sess
+		h.mu.Unlock()
+		log.Printf("[MCP HTTP] Session %s initialized from %s", sess.id, r.RemoteAddr)
+	} else if r.Header.Get(mcpSessionHeader) == "" {
+		http.Error(w, "missing "+mcpSessionHeader+" header", http.StatusBadRequest)
+		return
+	} else if sess == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	} else if h.foreign(w, r, sess) {
+		return
+	}
+	w.Header().Set(mcpSessionHeader, sess.id)
+
+	// Each session is its own controller for the control lease
+	ctx := withGameClient(r.Context(), h.client)
+	ctx = remoteController(ctx, "mcp:"+sess.id, "MCP session "+sess.id[:8])
+	ctx = withMCPSession(ctx, sess.id)
+
+	// Requests are dispatched concurrently; notifications and responses produce nothing
+	responses := make([]*JSONRPCResponse, len(messages))
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		wg.Add(1)
+		go func(i int, msg json.RawMessage) {
+			defer wg.Done()
+			responses[i] = h.server.HandleMessage(ctx, msg)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	var out []*JSONRPCResponse
+	for _, resp := range responses {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Prefer an SSE stream when the client accepts it
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		flusher, ok := w.(http.Flusher)
+		if ok {
+			startSSE(w)
+			for _, resp := range out {
+				data, _ := json.Marshal(resp)
+				writeSSEEvent(w, sess.reply(data))
+			}
+			flusher.Flush()
+			return
+		}
+	}
+
+	if len(out) == 1 && len(messages) == 1 {
+		writeJSON(w, http.StatusOK, out[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *MCPHTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	sess := h.session(r)
+	if sess == nil {
+		http.Error(w, "unknown or missing session", http.StatusNotFound)
+		return
+	}
+	if h.foreign(w, r, sess) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := sess.attach()
+	defer sess.detach(ch)
+
+	w.Header().Set(mcpSessionHeader, sess.id)
+	startSSE(w)
+
+	// Resume from the last event the client saw
+	if lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		for _, ev := range sess.replayAfter(lastID) {
+			writeSSEEvent(w, ev)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(mcpSSEKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeSSEEvent(w, ev)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *MCPHTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess := h.session(r)
+	if sess == nil {
+		http.Error(w, "unknown or missing session", http.StatusNotFound)
+		return
+	}
+	if h.foreign(w, r, sess) {
+		return
+	}
+	h.mu.Lock()
+	delete(h.sessions, sess.id)
+	h.mu.Unlock()
+	sess.closeStreams()
+	h.server.forgetSession(sess.id)
+	h.client.lease.Release("mcp:" + sess.id)
+	log.Printf("[MCP HTTP] Session %s terminated", sess.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Notify sends a JSON-RPC notification to every session for which want is true
+func (h *MCPHTTPHandler) Notify(method string, params interface{}, want func(session string) bool) {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for id, sess := range h.sessions {
+		if want(id) {
+			sess.publish(data)
+		}
+	}
+}
+
+// notifyStateChanges emits resource updates to subscribed sessions when the
+// in-game clock advances
+func (h *MCPHTTPHandler) notifyStateChanges() {
+	watchClock(context.Background(), h.client, func() {
+		h.Notify("notifications/resources/updated", map[string]interface{}{"uri": "stardew://state"}, func(session string) bool {
+			return h.server.subscribed(session, "stardew://state")
+		})
+	})
+}
+
+// reapIdleSessions ends sessions that have been idle for an hour
+func (h *MCPHTTPHandler) reapIdleSessions() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.mu.Lock()
+		for id, sess := range h.sessions {
+			sess.mu.Lock()
+			idle := time.Since(sess.lastSeen) > time.Hour && len(sess.streams) == 0
+			sess.mu.Unlock()
+			if idle {
+				delete(h.sessions, id)
+				h.server.forgetSession(id)
+				h.client.lease.Release("mcp:" + id)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+func startSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeSSEEvent(w io.Writer, ev mcpEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", ev.ID, ev.Data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
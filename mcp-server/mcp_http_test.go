@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postSSE posts a JSON-RPC message asking for an SSE reply and returns the
+// session id
+func postSSE(t *testing.T, url, session, body string) string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if session != "" {
+		req.Header.Set(mcpSessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s: status %d", body, resp.StatusCode)
+	}
+	return resp.Header.Get(mcpSessionHeader)
+}
+
+// TestReplayOnlyGetStreamEvents checks that resuming the GET stream replays
+// its notifications but not the replies to POST requests
+func TestReplayOnlyGetStreamEvents(t *testing.T) {
+	h := NewMCPHTTPHandler(NewMCPServer(&StardewAgent{}), NewGameClient())
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	session := postSSE(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	postSSE(t, srv.URL, session, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	h.Notify("notifications/message", map[string]interface{}{"data": "hi"}, func(string) bool { return true })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(mcpSessionHeader, session)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if !strings.Contains(data, "notifications/message") {
+			t.Fatalf("replayed %s, want only the notification", data)
+		}
+		return
+	}
+	t.Fatalf("no event replayed: %v", scanner.Err())
+}
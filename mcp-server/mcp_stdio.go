@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Model Context Protocol (MCP) - JSON-RPC 2.0 over stdio
+// ============================================================================
+
+const mcpProtocolVersion = "2024-11-05"
+
+// JSON-RPC 2.0 error codes
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+	jsonRPCInternalError  = -32603
+)
+
+type JSONRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type JSONRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+type JSONRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type MCPToolCallParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+type MCPResourceReadParams struct {
+	URI string `json:"uri"`
+}
+
+type MCPContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type MCPResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// mcpResources lists the read-only resources exposed over MCP
+var mcpResources = []MCPResource{
+	{URI: "stardew://state", Name: "Game State", Description: "Full game state as last broadcast by the mod", MimeType: "application/json"},
+	{URI: "stardew://surroundings", Name: "Surroundings", Description: "Agent view of the player's surroundings, targets and ASCII map", MimeType: "text/plain"},
+	{URI: "stardew://knowledge", Name: "Game Knowledge", Description: "Stardew Valley reference used as the agent system prompt", MimeType: "text/markdown"},
+}
+
+type MCPCancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// MCPServer dispatches MCP JSON-RPC requests to the tool registry
+type MCPServer struct {
+	agent *StardewAgent
+
+	// In-flight requests by session and JSON-RPC id, for notifications/cancelled
+	inflightMu sync.Mutex
+	inflight   map[inflightKey]context.CancelFunc
+
+	// Resource URIs each session subscribed to with resources/subscribe
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]map[string]struct{}
+}
+
+// NewMCPServer creates an MCP server exposing the same tools as the Copilot session
+func NewMCPServer(agent *StardewAgent) *MCPServer {
+	return &MCPServer{
+		agent:         agent,
+		inflight:      make(map[inflightKey]context.CancelFunc),
+		subscriptions: make(map[string]map[string]struct{}),
+	}
+}
+
+// inflightKey identifies a request; ids are only unique within a session
+type inflightKey struct {
+	session string
+	id      string
+}
+
+type mcpSessionKey struct{}
+
+// withMCPSession tags ctx with the MCP session a request belongs to; stdio has one unnamed session
+func withMCPSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, mcpSessionKey{}, id)
+}
+
+func mcpSessionFrom(ctx context.Context) string {
+	id, _ := ctx.Value(mcpSessionKey{}).(string)
+	return id
+}
+
+// HandleMessage processes one JSON-RPC message. It returns nil for notifications.
+// ctx is cancelled when the transport abandons the request; a client can also
+// cancel it with notifications/cancelled.
+func (s *MCPServer) HandleMessage(ctx context.Context, raw []byte) *JSONRPCResponse {
+	var req JSONRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return rpcError(nil, jsonRPCParseError, "parse error: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcError(req.ID, jsonRPCInvalidRequest, "invalid request")
+	}
+
+	// Notifications carry no id and never get a response
+	isNotification := len(req.ID) == 0
+	if !isNotification && strings.HasPrefix(req.Method, "notifications/") {
+		return rpcError(req.ID, jsonRPCMethodNotFound, req.Method+" is a notification and takes no id")
+	}
+
+	if !isNotification {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		key := inflightKey{session: mcpSessionFrom(ctx), id: string(req.ID)}
+		s.inflightMu.Lock()
+		s.inflight[key] = cancel
+		s.inflightMu.Unlock()
+		defer func() {
+			s.inflightMu.Lock()
+			delete(s.inflight, key)
+			s.inflightMu.Unlock()
+			cancel()
+		}()
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if isNotification {
+		return nil
+	}
+	if rpcErr != nil {
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	// A response needs a result even when the method has nothing to return
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (s *MCPServer) dispatch(ctx context.Context, req JSONRPCRequest) (interface{}, *JSONRPCError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]interface{}{"listChanged": false},
+				"resources": map[string]interface{}{"listChanged": false, "subscribe": true},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "stardew-mcp",
+				"version": "1.0.0",
+			},
+		}, nil
+	case "notifications/initialized":
+		return nil, nil
+	case "notifications/cancelled":
+		var params MCPCancelledParams
+		if json.Unmarshal(req.Params, &params) == nil {
+			s.cancelRequest(ctx, params.RequestID, params.Reason)
+		}
+		return nil, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.listTools(ctx)}, nil
+	case "tools/call":
+		var params MCPToolCallParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: "tools/call requires a tool name"}
+		}
+		return s.callTool(ctx, params)
+	case "resources/list":
+		return map[string]interface{}{"resources": mcpResources}, nil
+	case "resources/read":
+		var params MCPResourceReadParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+			return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: "resources/read requires a uri"}
+		}
+		return s.readResource(ctx, params.URI)
+	case "resources/subscribe", "resources/unsubscribe":
+		var params MCPResourceReadParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+			return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: req.Method + " requires a uri"}
+		}
+		if !knownResource(params.URI) {
+			return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: "unknown resource: " + params.URI}
+		}
+		s.setSubscribed(mcpSessionFrom(ctx), params.URI, req.Method == "resources/subscribe")
+		return map[string]interface{}{}, nil
+	default:
+		return nil, &JSONRPCError{Code: jsonRPCMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func knownResource(uri string) bool {
+	for _, r := range mcpResources {
+		if r.URI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MCPServer) setSubscribed(session, uri string, subscribed bool) {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	uris := s.subscriptions[session]
+	if subscribed {
+		if uris == nil {
+			uris = make(map[string]struct{})
+			s.subscriptions[session] = uris
+		}
+		uris[uri] = struct{}{}
+	} else {
+		delete(uris, uri)
+	}
+}
+
+// subscribed reports whether a session wants notifications/resources/updated for uri
+func (s *MCPServer) subscribed(session, uri string) bool {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	_, ok := s.subscriptions[session][uri]
+	return ok
+}
+
+// forgetSession drops the subscriptions of an ended session
+func (s *MCPServer) forgetSession(session string) {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	delete(s.subscriptions, session)
+}
+
+// watchClock calls notify whenever the in-game clock of client advances, until ctx ends
+func watchClock(ctx context.Context, client *GameClient, notify func()) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastTime := -1
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if state := client.GetState(); state != nil && state.Time.TimeOfDay != lastTime {
+			lastTime = state.Time.TimeOfDay
+			notify()
+		}
+	}
+}
+
+func (s *MCPServer) listTools(ctx context.Context) []map[string]interface{} {
+	return toolCatalog(clientFrom(ctx))
+}
+
+// cancelRequest aborts an in-flight request of the caller's session; its
+// pending game command is dropped
+func (s *MCPServer) cancelRequest(ctx context.Context, id json.RawMessage, reason string) {
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[inflightKey{session: mcpSessionFrom(ctx), id: string(id)}]
+	s.inflightMu.Unlock()
+	if ok {
+		log.Printf("[MCP] Request %s cancelled by client: %s", id, reason)
+		cancel()
+	}
+}
+
+func (s *MCPServer) callTool(ctx context.Context, params MCPToolCallParams) (result interface{}, rpcErr *JSONRPCError) {
+	tool, ok := lookupTool(params.Name)
+	if !ok {
+		return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+
+	// A tool handler must never take down the transport
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[MCP] Tool %s panicked: %v", params.Name, r)
+			result, rpcErr = mcpToolResult(fmt.Sprintf("tool %s failed: %v", params.Name, r), true), nil
+		}
+	}()
+
+	res, err := tool.Invoke(ctx, s.agent, params.Arguments)
+	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return nil, &JSONRPCError{
+				Code:    jsonRPCInvalidParams,
+				Message: vErr.Error(),
+				Data:    map[string]interface{}{"field": vErr.Field, "reason": vErr.Reason},
+			}
+		}
+		return mcpToolResult(err.Error(), true), nil
+	}
+	return mcpToolResult(toolResultText(res), false), nil
+}
+
+func (s *MCPServer) readResource(ctx context.Context, uri string) (interface{}, *JSONRPCError) {
+	client := clientFrom(ctx)
+	var text, mimeType string
+	switch uri {
+	case "stardew://state":
+		data, err := json.MarshalIndent(client.GetState(), "", "  ")
+		if err != nil {
+			return nil, &JSONRPCError{Code: jsonRPCInternalError, Message: err.Error()}
+		}
+		text, mimeType = string(data), "application/json"
+	case "stardew://surroundings":
+		state := client.GetState()
+		if state == nil {
+			text = "Disconnected"
+		} else {
+			text = s.agent.formatGameStateContext(state)
+		}
+		mimeType = "text/plain"
+	case "stardew://knowledge":
+		text, mimeType = gameKnowledge, "text/markdown"
+	default:
+		return nil, &JSONRPCError{Code: jsonRPCInvalidParams, Message: "unknown resource: " + uri}
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"uri": uri, "mimeType": mimeType, "text": text},
+		},
+	}, nil
+}
+
+func mcpToolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []MCPContent{{Type: "text", Text: text}},
+		"isError": isError,
+	}
+}
+
+func rpcError(id json.RawMessage, code int, message string) *JSONRPCResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &JSONRPCError{Code: code, Message: message},
+	}
+}
+
+// runStdioMode serves MCP over stdin/stdout. Logs go to stderr so stdout stays pure JSON-RPC.
+func runStdioMode() {
+	log.SetOutput(os.Stderr)
+
+	// Connect to the games in the background so the client can initialize immediately
+	go func() {
+		gamePool.ConnectAll()
+		log.Println("Connected to Stardew Valley!")
+	}()
+
+	server := NewMCPServer(&StardewAgent{})
+	log.Printf("Stardew MCP Server - stdio mode (%d tools)", len(toolRegistry))
+	ctx := withController(context.Background(), "stdio", "the stdio MCP client")
+
+	var writeMu sync.Mutex
+	writer := bufio.NewWriter(os.Stdout)
+	write := func(resp *JSONRPCResponse) {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			log.Printf("[MCP] Failed to marshal response: %v", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		writer.Write(data)
+		writer.WriteByte('\n')
+		writer.Flush()
+	}
+
+	// Tell a subscribed client when the game state changes
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go watchClock(watchCtx, gameClient, func() {
+		if !server.subscribed("", "stardew://state") {
+			return
+		}
+		data, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "notifications/resources/updated",
+			"params":  map[string]interface{}{"uri": "stardew://state"},
+		})
+		if err != nil {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		writer.Write(data)
+		writer.WriteByte('\n')
+		writer.Flush()
+	})
+
+	// Requests still running at EOF finish and write their responses before we exit
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		msg := make([]byte, len(line))
+		copy(msg, line)
+
+		// Tool calls can block (move_to waits for arrival), so handle each request concurrently
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			if resp := server.HandleMessage(ctx, msg); resp != nil {
+				write(resp)
+			}
+		}()
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("[MCP] stdin read error: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Game instances - one GameClient per configured game. The instance named
+// "default" connects to server.game_url; server.instances adds more. Tools
+// pick an instance with their optional "instance" argument and server mode
+// serves each one at /mcp/{instance}.
+// ============================================================================
+
+const defaultInstance = "default"
+
+// GameClientPool holds the clients of every configured game instance
+type GameClientPool struct {
+	clients map[string]*GameClient
+	urls    map[string]string
+}
+
+// gamePool is the active pool; main creates it after loading the config
+var gamePool *GameClientPool
+
+// NewGameClientPool creates one client per instance URL. The default instance
+// reuses the package-level gameClient so single-game code keeps working.
+func NewGameClientPool(urls map[string]string) *GameClientPool {
+	p := &GameClientPool{
+		clients: make(map[string]*GameClient, len(urls)),
+		urls:    make(map[string]string, len(urls)),
+	}
+	for name, url := range urls {
+		client := NewGameClient()
+		if name == defaultInstance && gameClient != nil {
+			client = gameClient
+		}
+		p.clients[name] = client
+		p.urls[name] = url
+	}
+	return p
+}
+
+// Get returns the client of an instance; "" is the default instance
+func (p *GameClientPool) Get(name string) (*GameClient, bool) {
+	if name == "" {
+		name = defaultInstance
+	}
+	client, ok := p.clients[name]
+	return client, ok
+}
+
+// URL returns the game URL an instance connects to
+func (p *GameClientPool) URL(name string) string {
+	return p.urls[name]
+}
+
+// Names lists the instances, default first and the rest sorted
+func (p *GameClientPool) Names() []string {
+	names := make([]string, 0, len(p.clients))
+	for name := range p.clients {
+		if name != defaultInstance {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := p.clients[defaultInstance]; ok {
+		names = append([]string{defaultInstance}, names...)
+	}
+	return names
+}
+
+// ConnectAll blocks until the default instance is connected. The other
+// instances connect in the background so one stopped game cannot hold up the rest.
+func (p *GameClientPool) ConnectAll() {
+	for _, name := range p.Names() {
+		if name == defaultInstance {
+			continue
+		}
+		go func(name string) {
+			p.clients[name].ConnectWithRetry(p.urls[name])
+			log.Printf("[POOL] Instance %q connected at %s", name, p.urls[name])
+		}(name)
+	}
+	if client, ok := p.clients[defaultInstance]; ok {
+		client.ConnectWithRetry(p.urls[defaultInstance])
+	}
+}
+
+// Status reports the URL, connection state, lease holder and command queue of every instance
+func (p *GameClientPool) Status() map[string]interface{} {
+	status := make(map[string]interface{}, len(p.clients))
+	for name, client := range p.clients {
+		status[name] = map[string]interface{}{
+			"url":        p.urls[name],
+			"connected":  client.IsConnected(),
+			"controller": client.lease.Info(),
+			"queue":      client.scheduler.Stats(),
+		}
+	}
+	return status
+}
+
+// resolveInstance returns the client named by a tool's "instance" argument,
+// or the client already bound to ctx when the argument is absent
+func resolveInstance(ctx context.Context, tool string, args map[string]interface{}) (*GameClient, error) {
+	raw, ok := args["instance"]
+	if !ok || raw == nil {
+		return clientFrom(ctx), nil
+	}
+	name, ok := raw.(string)
+	if !ok {
+		return nil, &ValidationError{Tool: tool, Field: "instance", Reason: "type", Message: "must be a string"}
+	}
+	if gamePool == nil {
+		if name == "" || name == defaultInstance {
+			return clientFrom(ctx), nil
+		}
+		return nil, &ValidationError{Tool: tool, Field: "instance", Reason: "enum", Message: "must be one of: " + defaultInstance}
+	}
+	client, ok := gamePool.Get(name)
+	if !ok {
+		return nil, &ValidationError{Tool: tool, Field: "instance", Reason: "enum", Message: "must be one of: " + strings.Join(gamePool.Names(), ", ")}
+	}
+	return client, nil
+}
+
+type gameClientKey struct{}
+
+// withGameClient binds the game instance that tools run against to ctx
+func withGameClient(ctx context.Context, client *GameClient) context.Context {
+	return context.WithValue(ctx, gameClientKey{}, client)
+}
+
+// clientFrom returns the game instance bound to ctx, or the default instance
+func clientFrom(ctx context.Context) *GameClient {
+	if client, ok := ctx.Value(gameClientKey{}).(*GameClient); ok {
+		return client
+	}
+	return gameClient
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Dry-run previews - destructive cheats called with dryRun only report the
+// tiles they would change. The tools list those tiles and draw them over the
+// player's 61x61 ASCII map so an agent or human can check before committing.
+// ============================================================================
+
+const featureDryRun = "dry_run"
+
+const (
+	previewRemove = 'X' // tile whose object, feature or hoed dirt would be removed
+	previewHoe    = '+' // tile that would be hoed
+
+	// previewListLimit caps the affected tiles listed in text; the map shows them all
+	previewListLimit = 40
+)
+
+// AffectedTile is one tile a dry run would change. Resource clumps cover
+// Width x Height tiles from X, Y.
+type AffectedTile struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Name   string `json:"name"`
+	Effect string `json:"effect"` // remove or hoe
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// sendDryRunCommand sends a destructive cheat and, when params ask for a dry
+// run, renders the mod's list of affected tiles as text plus a map preview
+func sendDryRunCommand(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	client := clientFrom(ctx)
+	dryRun, _ := params["dryRun"].(bool)
+	// An older mod would ignore the flag and apply the command
+	if caps := client.Capabilities(); dryRun && !caps.HasFeature(featureDryRun) {
+		return nil, fmt.Errorf("%s dry run is not supported by the connected %s; update the mod to preview it", action, caps)
+	}
+
+	resp, err := client.SendCommandContext(ctx, action, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", action, err)
+	}
+	if !dryRun || !resp.Success {
+		return resp.Message, nil
+	}
+
+	var data struct {
+		DryRun   bool           `json:"dryRun"`
+		Affected []AffectedTile `json:"affected"`
+	}
+	raw, _ := json.Marshal(resp.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || !data.DryRun {
+		return nil, fmt.Errorf("%s did not return a dry-run preview: %s", action, resp.Message)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(resp.Message)
+	if len(data.Affected) > 0 {
+		fmt.Fprintf(&sb, "\n\nAffected tiles (%d):", len(data.Affected))
+		for i, t := range data.Affected {
+			if i == previewListLimit {
+				fmt.Fprintf(&sb, "\n- ... and %d more", len(data.Affected)-previewListLimit)
+				break
+			}
+			fmt.Fprintf(&sb, "\n- (%d, %d) %s: %s", t.X, t.Y, t.Name, t.Effect)
+		}
+	}
+
+	if state := client.GetState(); state != nil && state.Surroundings.AsciiMap != "" {
+		preview, outside := renderPreview(state.Surroundings.AsciiMap, state.Player.X, state.Player.Y, data.Affected)
+		fmt.Fprintf(&sb, "\n\nPreview (%c = removed, %c = hoed, @ = you):\n%s", previewRemove, previewHoe, preview)
+		if outside > 0 {
+			fmt.Fprintf(&sb, "\n%d affected tile(s) are outside this view.", outside)
+		}
+	}
+	sb.WriteString("\n\nCall again without dryRun to apply.")
+	return sb.String(), nil
+}
+
+// renderPreview draws affected tiles over an ASCII map centered on the
+// player at px, py. It returns the map and how many tiles fell outside it.
+func renderPreview(asciiMap string, px, py int, affected []AffectedTile) (string, int) {
+	lines := strings.Split(asciiMap, "\n")
+	grid := make([][]rune, len(lines))
+	for i, line := range lines {
+		grid[i] = []rune(line)
+	}
+	radius := (len(grid) - 1) / 2
+
+	outside := 0
+	for _, t := range affected {
+		marker := previewRemove
+		if t.Effect == "hoe" {
+			marker = previewHoe
+		}
+		w, h := max(t.Width, 1), max(t.Height, 1)
+		for dy := 0; dy < h; dy++ {
+			for dx := 0; dx < w; dx++ {
+				row, col := t.Y+dy-py+radius, t.X+dx-px+radius
+				if row < 0 || row >= len(grid) || col < 0 || col >= len(grid[row]) {
+					outside++
+					continue
+				}
+				if grid[row][col] != '@' {
+					grid[row][col] = marker
+				}
+			}
+		}
+	}
+
+	for i, row := range grid {
+		lines[i] = string(row)
+	}
+	return strings.Join(lines, "\n"), outside
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Command scheduler - every command to a game waits here for one of
+// server.connection.max_in_flight slots. Waiting commands run in priority
+// order (survival, then user, then background) and first-come within a class.
+// Background work is expendable: queued background commands are cancelled
+// whenever a survival or user command arrives. A running command always finishes.
+// ============================================================================
+
+// Priority is the scheduling class of a game command
+type Priority int
+
+const (
+	PrioritySurvival   Priority = iota // emergencies: eat, flee, go to bed
+	PriorityUser                       // explicit commands from agents and clients
+	PriorityBackground                 // autonomous chores that may be dropped
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PrioritySurvival:
+		return "survival"
+	case PriorityUser:
+		return "user"
+	case PriorityBackground:
+		return "background"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// ParsePriority parses a priority class name
+func ParsePriority(name string) (Priority, error) {
+	for p := PrioritySurvival; p < numPriorities; p++ {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return PriorityUser, fmt.Errorf("unknown priority %q (want survival, user or background)", name)
+}
+
+// priorityParam parses an optional priority field; empty means user
+func priorityParam(name string) (Priority, error) {
+	if name == "" {
+		return PriorityUser, nil
+	}
+	return ParsePriority(name)
+}
+
+// ErrPreempted is returned for queued commands cancelled by a higher-priority command
+var ErrPreempted = errors.New("preempted by a higher-priority command")
+
+type priorityKey struct{}
+
+// withPriority sets the scheduling class of the commands sent with ctx
+func withPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the scheduling class bound to ctx; user by default
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityUser
+}
+
+// queuedCommand is a command waiting for a slot
+type queuedCommand struct {
+	action   string
+	priority Priority
+	enqueued time.Time
+	granted  chan struct{} // closed when the command gets a slot or is preempted
+	err      error         // set before granted is closed when preempted
+}
+
+// classStats accumulates metrics for one priority class
+type classStats struct {
+	executed  int64
+	preempted int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// CommandScheduler orders the commands sent to one game
+type CommandScheduler struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	queue   []*queuedCommand // sorted by priority, then arrival
+	stats   [numPriorities]classStats
+}
+
+// NewCommandScheduler creates a scheduler running up to slots commands at once
+func NewCommandScheduler(slots int) *CommandScheduler {
+	if slots < 1 {
+		slots = 1
+	}
+	return &CommandScheduler{slots: slots}
+}
+
+// Acquire waits until a command of class p may be sent and returns the
+// function that frees its slot. It fails when ctx ends or the command is
+// preempted while queued.
+func (s *CommandScheduler) Acquire(ctx context.Context, p Priority, action string) (release func(), err error) {
+	s.mu.Lock()
+	if p < PriorityBackground {
+		s.preemptLocked(p, action)
+	}
+	if s.running < s.slots && len(s.queue) == 0 {
+		s.running++
+		s.recordLocked(p, 0)
+		s.mu.Unlock()
+		return s.release, nil
+	}
+
+	cmd := &queuedCommand{action: action, priority: p, enqueued: time.Now(), granted: make(chan struct{})}
+	i := len(s.queue)
+	for i > 0 && s.queue[i-1].priority > p {
+		i--
+	}
+	s.queue = append(s.queue, nil)
+	copy(s.queue[i+1:], s.queue[i:])
+	s.queue[i] = cmd
+	s.mu.Unlock()
+
+	select {
+	case <-cmd.granted:
+		if cmd.err != nil {
+			return nil, fmt.Errorf("%s: %w", action, cmd.err)
+		}
+		return s.release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-cmd.granted:
+			// Granted while giving up - hand the slot on
+			if cmd.err == nil {
+				s.releaseLocked()
+			}
+		default:
+			s.removeLocked(cmd)
+		}
+		return nil, fmt.Errorf("%s cancelled while queued: %w", action, ctx.Err())
+	}
+}
+
+// preemptLocked cancels queued background commands for an arriving command of class p
+func (s *CommandScheduler) preemptLocked(p Priority, action string) {
+	kept := s.queue[:0]
+	preempted := 0
+	for _, cmd := range s.queue {
+		if cmd.priority == PriorityBackground {
+			cmd.err = ErrPreempted
+			close(cmd.granted)
+			s.stats[PriorityBackground].preempted++
+			preempted++
+			continue
+		}
+		kept = append(kept, cmd)
+	}
+	s.queue = kept
+	if preempted > 0 {
+		log.Printf("[QUEUE] %s command %s preempted %d queued background command(s)", p, action, preempted)
+	}
+}
+
+func (s *CommandScheduler) removeLocked(cmd *queuedCommand) {
+	for i, c := range s.queue {
+		if c == cmd {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *CommandScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+// releaseLocked frees a slot and grants it to the first queued command
+func (s *CommandScheduler) releaseLocked() {
+	s.running--
+	for s.running < s.slots && len(s.queue) > 0 {
+		cmd := s.queue[0]
+		s.queue = s.queue[1:]
+		s.running++
+		wait := time.Since(cmd.enqueued)
+		s.recordLocked(cmd.priority, wait)
+		if wait > time.Second {
+			log.Printf("[QUEUE] %s command %s waited %v", cmd.priority, cmd.action, wait.Round(time.Millisecond))
+		}
+		close(cmd.granted)
+	}
+}
+
+func (s *CommandScheduler) recordLocked(p Priority, wait time.Duration) {
+	st := &s.stats[p]
+	st.executed++
+	st.totalWait += wait
+	if wait > st.maxWait {
+		st.maxWait = wait
+	}
+}
+
+// QueueStats reports the scheduler's queue depth and per-class wait times
+type QueueStats struct {
+	Running int                   `json:"running"`
+	Depth   int                   `json:"depth"`
+	Classes map[string]ClassStats `json:"classes"`
+}
+
+// ClassStats are the metrics of one priority class since startup
+type ClassStats struct {
+	Queued    int     `json:"queued"`
+	Executed  int64   `json:"executed"`
+	Preempted int64   `json:"preempted"`
+	AvgWaitMs float64 `json:"avgWaitMs"`
+	MaxWaitMs float64 `json:"maxWaitMs"`
+}
+
+// Stats returns a snapshot of the queue metrics
+func (s *CommandScheduler) Stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := QueueStats{Running: s.running, Depth: len(s.queue), Classes: make(map[string]ClassStats, numPriorities)}
+	for p := PrioritySurvival; p < numPriorities; p++ {
+		st := s.stats[p]
+		cs := ClassStats{
+			Executed:  st.executed,
+			Preempted: st.preempted,
+			MaxWaitMs: float64(st.maxWait) / float64(time.Millisecond),
+		}
+		if st.executed > 0 {
+			cs.AvgWaitMs = float64(st.totalWait) / float64(st.executed) / float64(time.Millisecond)
+		}
+		for _, cmd := range s.queue {
+			if cmd.priority == p {
+				cs.Queued++
+			}
+		}
+		stats.Classes[p.String()] = cs
+	}
+	return stats
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type acquireResult struct {
+	name    string
+	release func()
+	err     error
+}
+
+// acquireAsync queues a command and reports when it gets its slot or fails
+func acquireAsync(ctx context.Context, s *CommandScheduler, p Priority, name string, results chan<- acquireResult) {
+	go func() {
+		release, err := s.Acquire(ctx, p, name)
+		results <- acquireResult{name: name, release: release, err: err}
+	}()
+}
+
+// waitForDepth waits until depth commands are queued
+func waitForDepth(t *testing.T, s *CommandScheduler, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats().Depth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth %d, want %d", s.Stats().Depth, depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func nextResult(t *testing.T, results <-chan acquireResult) acquireResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command finished waiting")
+		return acquireResult{}
+	}
+}
+
+func TestSchedulerPreemptsBackground(t *testing.T) {
+	s := NewCommandScheduler(1)
+	hold, err := s.Acquire(context.Background(), PriorityUser, "hold")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(chan acquireResult, 8)
+	acquireAsync(context.Background(), s, PriorityBackground, "bg1", results)
+	acquireAsync(context.Background(), s, PriorityBackground, "bg2", results)
+	waitForDepth(t, s, 2)
+
+	// A user command drops every queued background command at once
+	acquireAsync(context.Background(), s, PriorityUser, "user", results)
+	for i := 0; i < 2; i++ {
+		r := nextResult(t, results)
+		if !errors.Is(r.err, ErrPreempted) {
+			t.Fatalf("%s: err = %v, want ErrPreempted", r.name, r.err)
+		}
+	}
+	waitForDepth(t, s, 1)
+
+	// A background command arriving later queues behind the user command
+	acquireAsync(context.Background(), s, PriorityBackground, "bg3", results)
+	waitForDepth(t, s, 2)
+
+	// Survival jumps the queue; it preempts bg3 as well
+	acquireAsync(context.Background(), s, PrioritySurvival, "survival", results)
+	if r := nextResult(t, results); r.name != "bg3" || !errors.Is(r.err, ErrPreempted) {
+		t.Fatalf("%s: err = %v, want bg3 preempted", r.name, r.err)
+	}
+	waitForDepth(t, s, 2)
+
+	// The slot goes to survival, then user
+	hold()
+	for _, want := range []string{"survival", "user"} {
+		r := nextResult(t, results)
+		if r.name != want || r.err != nil {
+			t.Fatalf("granted %s (err %v), want %s", r.name, r.err, want)
+		}
+		r.release()
+	}
+
+	stats := s.Stats()
+	if bg := stats.Classes[PriorityBackground.String()]; bg.Preempted != 3 || bg.Executed != 0 {
+		t.Errorf("background stats = %+v, want 3 preempted and none executed", bg)
+	}
+	if stats.Running != 0 || stats.Depth != 0 {
+		t.Errorf("running %d depth %d after all releases", stats.Running, stats.Depth)
+	}
+}
+
+func TestSchedulerCancelWhileQueued(t *testing.T) {
+	s := NewCommandScheduler(1)
+	hold, err := s.Acquire(context.Background(), PriorityBackground, "hold")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The running background command is never preempted, only queued ones
+	results := make(chan acquireResult, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	acquireAsync(ctx, s, PriorityUser, "cancelled", results)
+	waitForDepth(t, s, 1)
+	cancel()
+	if r := nextResult(t, results); !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", r.err)
+	}
+	waitForDepth(t, s, 0)
+
+	hold()
+	release, err := s.Acquire(context.Background(), PriorityUser, "next")
+	if err != nil {
+		t.Fatalf("slot not freed: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// State subscriptions for remote agents
+//
+//	{"type":"subscribe","id":"1","params":{"fields":["player","time"],"minInterval":2}}
+//	{"type":"unsubscribe","id":"2"}
+//
+// Fields are dotted JSON paths into the state (e.g. "surroundings.nearbyMonsters");
+// no fields means the whole state. minInterval is in seconds. Updates whose
+// filtered content did not change are not sent again.
+// ============================================================================
+
+// stateSubscription is one remote agent's filter and rate limit
+type stateSubscription struct {
+	fields      []string
+	minInterval time.Duration
+}
+
+// parseSubscription validates subscribe params
+func parseSubscription(params map[string]interface{}) (*stateSubscription, error) {
+	sub := &stateSubscription{}
+
+	if raw, ok := params["fields"]; ok && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("fields must be an array of strings")
+		}
+		for _, item := range list {
+			field, ok := item.(string)
+			if !ok || field == "" {
+				return nil, fmt.Errorf("fields must be an array of strings")
+			}
+			if !validStatePath(field) {
+				return nil, fmt.Errorf("unknown state field: %s", field)
+			}
+			sub.fields = append(sub.fields, field)
+		}
+	}
+
+	if raw, ok := params["minInterval"]; ok && raw != nil {
+		seconds, ok := raw.(float64)
+		if !ok || seconds < 0 {
+			return nil, fmt.Errorf("minInterval must be a non-negative number of seconds")
+		}
+		sub.minInterval = time.Duration(seconds * float64(time.Second))
+	}
+
+	return sub, nil
+}
+
+// describe summarizes the subscription for the acknowledgement message
+func (s *stateSubscription) describe() string {
+	fields := "all fields"
+	if len(s.fields) > 0 {
+		fields = strings.Join(s.fields, ", ")
+	}
+	if s.minInterval > 0 {
+		return fmt.Sprintf("Subscribed to %s, at most every %v", fields, s.minInterval)
+	}
+	return fmt.Sprintf("Subscribed to %s", fields)
+}
+
+// validStatePath checks a dotted path against the json tags of GameState
+func validStatePath(path string) bool {
+	t := reflect.TypeOf(GameState{})
+	for _, part := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == part {
+				t = t.Field(i).Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterState keeps only the requested paths of the state, preserving nesting
+func filterState(state *GameState, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return state, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var full map[string]interface{}
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	for _, field := range fields {
+		parts := strings.Split(field, ".")
+		var value interface{} = full
+		for _, part := range parts {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[part]
+		}
+		if value == nil {
+			continue
+		}
+
+		dst := out
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := dst[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				dst[part] = next
+			}
+			dst = next
+		}
+		dst[parts[len(parts)-1]] = value
+	}
+	return out, nil
+}
+
+// runStateSubscription pushes filtered state of the game instance bound to ctx
+// to send until ctx is done.
+// Updates arriving faster than minInterval are coalesced into the latest one.
+func runStateSubscription(ctx context.Context, sub *stateSubscription, send func(data interface{})) {
+	updates, unsubscribe := clientFrom(ctx).SubscribeState()
+	defer unsubscribe()
+
+	var (
+		lastSent  time.Time
+		lastData  []byte
+		pending   *GameState
+		throttle  *time.Timer
+		throttleC <-chan time.Time
+	)
+	defer func() {
+		if throttle != nil {
+			throttle.Stop()
+		}
+	}()
+
+	push := func(state *GameState) {
+		filtered, err := filterState(state, sub.fields)
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(filtered)
+		if err != nil || bytes.Equal(data, lastData) {
+			return
+		}
+		lastData, lastSent = data, time.Now()
+		send(json.RawMessage(data))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-updates:
+			wait := sub.minInterval - time.Since(lastSent)
+			if wait <= 0 {
+				push(state)
+				continue
+			}
+			pending = state
+			if throttleC == nil {
+				throttle = time.NewTimer(wait)
+				throttleC = throttle.C
+			}
+		case <-throttleC:
+			throttleC = nil
+			if pending != nil {
+				push(pending)
+				pending = nil
+			}
+		}
+	}
+}
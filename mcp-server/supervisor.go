@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Survival supervisor - a rule-based watchdog next to the autonomous agent.
+// It reads every state update and acts on emergencies itself instead of
+// hoping the model notices them: it eats when energy or health runs low,
+// flees or eats when nearby monsters could finish the player off, and walks
+// home to bed past the bedtime threshold. Its commands run at survival
+// priority, so they preempt the agent's queued background commands, and the
+// LLM loop and its tools wait until the supervisor is idle again.
+// ============================================================================
+
+const (
+	// supervisorCooldown spaces out attempts at the same emergency, giving an
+	// eaten item or a finished walk time to show up in the state
+	supervisorCooldown = 5 * time.Second
+
+	// monsterHitsMargin is how many hits from the monsters in reach the
+	// player must be able to take before the supervisor steps in
+	monsterHitsMargin = 3
+
+	// fleeDistance is how far from the monsters the supervisor runs
+	fleeDistance = 6
+
+	// maxHomeHops bounds the warps taken on the way to the farmhouse
+	maxHomeHops = 4
+)
+
+// ErrNoWayHome is returned when neither warps nor cheats lead to the farmhouse
+var ErrNoWayHome = errors.New("no warp towards the FarmHouse from here")
+
+// Supervisor watches one game and handles survival emergencies
+type Supervisor struct {
+	agent  *StardewAgent
+	client *GameClient
+
+	mu       sync.Mutex
+	active   string        // emergency being handled, "" when idle
+	idle     chan struct{} // closed while no emergency is handled
+	attempts map[string]time.Time
+}
+
+// NewSupervisor creates an idle supervisor for the agent's game
+func NewSupervisor(agent *StardewAgent, client *GameClient) *Supervisor {
+	idle := make(chan struct{})
+	close(idle)
+	return &Supervisor{
+		agent:    agent,
+		client:   client,
+		idle:     idle,
+		attempts: make(map[string]time.Time),
+	}
+}
+
+// Active returns the emergency being handled, or "" when the agent may act
+func (s *Supervisor) Active() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// WaitIdle blocks while the supervisor is handling an emergency
+func (s *Supervisor) WaitIdle(ctx context.Context) error {
+	s.mu.Lock()
+	idle := s.idle
+	s.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run checks every state update until ctx is cancelled
+func (s *Supervisor) Run(ctx context.Context) {
+	updates, unsubscribe := s.client.SubscribeState()
+	defer unsubscribe()
+	log.Printf("[SUPERVISOR] Watching for low energy, low health, monsters and bedtime")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-updates:
+			emergency, handle := s.check(state)
+			if handle == nil || !s.due(emergency) {
+				continue
+			}
+			s.take(ctx, emergency, state, handle)
+		}
+	}
+}
+
+// check picks the most urgent emergency in state and how to handle it
+func (s *Supervisor) check(state *GameState) (string, func(context.Context, *GameState) error) {
+	behavior := config.Agent.Behavior
+	player := &state.Player
+	// Menus, cutscenes and falling asleep take the controls away
+	if !player.CanMove {
+		return "", nil
+	}
+
+	if damage := monsterDamageInReach(state, behavior.MonsterDistance); damage > 0 && player.Health <= damage*monsterHitsMargin {
+		if bestFood(state.Player.Inventory, "health") != nil {
+			return "monsters nearby", s.eatFor("health")
+		}
+		return "monsters nearby", s.flee
+	}
+	if player.Health < behavior.LowHealth {
+		if bestFood(state.Player.Inventory, "health") != nil {
+			return "low health", s.eatFor("health")
+		}
+	}
+	if player.Energy < behavior.EmergencyEnergy {
+		if bestFood(state.Player.Inventory, "energy") != nil {
+			return "low energy", s.eatFor("energy")
+		}
+		return "low energy", s.goToBed
+	}
+	if state.Time.TimeOfDay >= behavior.BedtimeThreshold {
+		return "bedtime", s.goToBed
+	}
+	return "", nil
+}
+
+// due reports whether an emergency may be attempted again, and records the attempt
+func (s *Supervisor) due(emergency string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.attempts[emergency]) < supervisorCooldown {
+		return false
+	}
+	s.attempts[emergency] = time.Now()
+	return true
+}
+
+// take pauses the agent, handles the emergency and resumes the agent
+func (s *Supervisor) take(ctx context.Context, emergency string, state *GameState, handle func(context.Context, *GameState) error) {
+	s.mu.Lock()
+	s.active = emergency
+	s.idle = make(chan struct{})
+	s.mu.Unlock()
+	log.Printf("[SUPERVISOR] Taking over from the agent: %s (energy %.0f, health %d, time %s)",
+		emergency, state.Player.Energy, state.Player.Health, state.Time.TimeString)
+	agentFeed.Publish("supervisor", "Handling %s", emergency)
+
+	ctx = withPriority(withController(ctx, "agent", "the survival supervisor"), PrioritySurvival)
+	if err := handle(ctx, state); err != nil {
+		log.Printf("[SUPERVISOR] Could not handle %s: %v", emergency, err)
+	}
+
+	s.mu.Lock()
+	s.active = ""
+	close(s.idle)
+	s.mu.Unlock()
+	log.Printf("[SUPERVISOR] Handing control back to the agent")
+	agentFeed.Publish("supervisor", "Done with %s", emergency)
+}
+
+// eatFor returns a handler that eats the food restoring the most of stat
+func (s *Supervisor) eatFor(stat string) func(context.Context, *GameState) error {
+	return func(ctx context.Context, state *GameState) error {
+		food := bestFood(state.Player.Inventory, stat)
+		if food == nil {
+			return fmt.Errorf("no food restores %s", stat)
+		}
+		log.Printf("[SUPERVISOR] Eating %s from slot %d (+%d energy, +%d health)",
+			food.Name, food.Slot, food.EnergyRestored, food.HealthRestored)
+		_, err := s.command(ctx, "eat_item", map[string]interface{}{"slot": food.Slot})
+		return err
+	}
+}
+
+// flee runs to the reachable tile farthest from the monsters in reach
+func (s *Supervisor) flee(ctx context.Context, state *GameState) error {
+	px, py := state.Player.X, state.Player.Y
+	bestX, bestY, bestDist := 0, 0, -1
+	for _, d := range [][2]int{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}} {
+		x, y := px+d[0]*fleeDistance, py+d[1]*fleeDistance
+		if !s.agent.isTileWalkable(state, x, y) {
+			continue
+		}
+		nearest := -1
+		for _, m := range state.Surroundings.NearbyMonsters {
+			if dist := max(abs(m.X-x), abs(m.Y-y)); nearest < 0 || dist < nearest {
+				nearest = dist
+			}
+		}
+		if nearest > bestDist {
+			bestX, bestY, bestDist = x, y, nearest
+		}
+	}
+	if bestDist < 0 {
+		return fmt.Errorf("no walkable tile to flee to")
+	}
+	log.Printf("[SUPERVISOR] Fleeing to (%d, %d)", bestX, bestY)
+	_, err := s.command(ctx, "move_to", map[string]interface{}{"x": bestX, "y": bestY})
+	return err
+}
+
+// goToBed walks to the farmhouse through warps and sleeps in the bed
+func (s *Supervisor) goToBed(ctx context.Context, state *GameState) error {
+	for hop := 0; state.Player.Location != "FarmHouse"; hop++ {
+		if hop == maxHomeHops {
+			return ErrNoWayHome
+		}
+		if err := s.walkHome(ctx, state); err != nil {
+			return err
+		}
+		state = s.client.GetState()
+		if state == nil {
+			return ErrDisconnected
+		}
+	}
+
+	bed := state.Map.Bed
+	if bed == nil {
+		return fmt.Errorf("the mod does not report the bed position; update it")
+	}
+	log.Printf("[SUPERVISOR] Walking to the bed at (%d, %d)", bed.X, bed.Y)
+	if _, err := s.command(ctx, "move_to", map[string]interface{}{"x": bed.X, "y": bed.Y}); err != nil {
+		// The bed itself may not be walkable; sleep works from the next tile
+		if _, err := s.command(ctx, "move_to", map[string]interface{}{"x": bed.X, "y": bed.Y + 1}); err != nil {
+			return err
+		}
+	}
+	resp, err := s.command(ctx, "sleep", nil)
+	if err != nil {
+		return err
+	}
+	log.Printf("[SUPERVISOR] %s", resp.Message)
+	return nil
+}
+
+// walkHome takes one warp towards the farmhouse: straight into it when a
+// warp leads there, otherwise to the farm. Cheat mode warps as a last resort.
+func (s *Supervisor) walkHome(ctx context.Context, state *GameState) error {
+	from := state.Player.Location
+	for _, target := range []string{"FarmHouse", "Farm"} {
+		for _, w := range state.Surroundings.WarpPoints {
+			if w.TargetLocation != target {
+				continue
+			}
+			log.Printf("[SUPERVISOR] Heading to %s through the warp at (%d, %d)", target, w.X, w.Y)
+			if w.IsDoor {
+				// Doors open from the tile below them
+				if _, err := s.command(ctx, "move_to", map[string]interface{}{"x": w.X, "y": w.Y + 1}); err != nil {
+					return err
+				}
+				if _, err := s.command(ctx, "face_direction", map[string]interface{}{"direction": "up"}); err != nil {
+					return err
+				}
+				if _, err := s.command(ctx, "enter_door", nil); err != nil {
+					return err
+				}
+			} else if _, err := s.command(ctx, "move_to", map[string]interface{}{"x": w.X, "y": w.Y}); err != nil {
+				return err
+			}
+			return s.waitForLocation(ctx, from)
+		}
+	}
+
+	if config.Agent.Behavior.SupervisorCheatWarp && s.client.Capabilities().Supports("cheat_warp") {
+		log.Printf("[SUPERVISOR] No warp home from %s; warping with cheats", from)
+		if _, err := s.command(ctx, "cheat_mode_enable", nil); err != nil {
+			return err
+		}
+		if _, err := s.command(ctx, "cheat_warp", map[string]interface{}{"location": "FarmHouse"}); err != nil {
+			return err
+		}
+		return s.waitForLocation(ctx, from)
+	}
+	return fmt.Errorf("%w (in %s)", ErrNoWayHome, from)
+}
+
+// waitForLocation waits for the player to leave a location
+func (s *Supervisor) waitForLocation(ctx context.Context, from string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	updates, unsubscribe := s.client.SubscribeState()
+	defer unsubscribe()
+	for {
+		select {
+		case state := <-updates:
+			if state.Player.Location != from {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("still in %s after taking the warp", from)
+		}
+	}
+}
+
+// command sends one supervisor command, treating a refusal as an error
+func (s *Supervisor) command(ctx context.Context, action string, params map[string]interface{}) (*WebSocketResponse, error) {
+	resp, err := s.client.SendCommandContext(ctx, action, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", action, err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("%s failed: %s", action, resp.Message)
+	}
+	return resp, nil
+}
+
+// monsterDamageInReach sums the damage of the monsters within distance tiles
+func monsterDamageInReach(state *GameState, distance int) int {
+	damage := 0
+	for _, m := range state.Surroundings.NearbyMonsters {
+		if max(abs(m.X-state.Player.X), abs(m.Y-state.Player.Y)) <= distance {
+			damage += max(m.DamageToFarmer, 1)
+		}
+	}
+	return damage
+}
+
+// bestFood returns the edible item restoring the most energy or health
+func bestFood(inventory []InventoryItem, stat string) *InventoryItem {
+	var best *InventoryItem
+	restored := func(item *InventoryItem) int {
+		if stat == "health" {
+			return item.HealthRestored
+		}
+		return item.EnergyRestored
+	}
+	for i := range inventory {
+		item := &inventory[i]
+		if item.IsEdible && restored(item) > 0 && (best == nil || restored(item) > restored(best)) {
+			best = item
+		}
+	}
+	return best
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/gorilla/websocket"
+)
+
+// ============================================================================
+// TLS - certificates for the remote server (optionally requiring client
+// certificates) and CA bundles for wss:// dials to the game and the gateway
+// ============================================================================
+
+// gameDialer dials the mod; main replaces it when server.tls_ca is set
+var gameDialer = websocket.DefaultDialer
+
+// loadCAPool returns the system roots plus the PEM certificates in path
+func loadCAPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// newDialer returns a WebSocket dialer that also trusts the CA bundle at caFile.
+// Without a bundle it is the default dialer.
+func newDialer(caFile string) (*websocket.Dialer, error) {
+	if caFile == "" {
+		return websocket.DefaultDialer, nil
+	}
+	pool, err := loadCAPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &dialer, nil
+}
+
+// serverTLSConfig builds the TLS config for remote mode, or nil when no
+// certificate is configured. With a client CA every agent must present a
+// certificate signed by it.
+func serverTLSConfig(remote RemoteConfig) (*tls.Config, error) {
+	if remote.TLSCert == "" && remote.TLSKey == "" {
+		if remote.TLSClientCA != "" {
+			return nil, fmt.Errorf("remote.tls_client_ca requires remote.tls_cert and remote.tls_key")
+		}
+		return nil, nil
+	}
+	if remote.TLSCert == "" || remote.TLSKey == "" {
+		return nil, fmt.Errorf("remote.tls_cert and remote.tls_key must be set together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(remote.TLSCert, remote.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if remote.TLSClientCA != "" {
+		data, err := os.ReadFile(remote.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in client CA %s", remote.TLSClientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
@@ -0,0 +1,729 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// ============================================================================
+// Tool registry - the single definition of every tool. The Copilot session,
+// the OpenClaw Gateway catalog and the MCP transports are all generated from it.
+// ============================================================================
+
+// ToolDescriptor describes one tool: its name, description, typed parameter
+// struct, the JSON Schema derived from that struct, the mod actions it sends,
+// and its handler.
+type ToolDescriptor struct {
+	Name        string
+	Description string
+	Params      reflect.Type
+	Schema      map[string]interface{}
+	Actions     []string
+	rules       []fieldRule
+	handler     func(ctx context.Context, a *StardewAgent, args map[string]interface{}) (interface{}, error)
+}
+
+// defineTool builds a descriptor whose handler receives validated, typed parameters.
+// ctx is cancelled when the caller abandons the request and must reach every game command.
+// The tool is assumed to send the mod action of the same name; see requires.
+func defineTool[T any](name, description string, handler func(ctx context.Context, a *StardewAgent, params T) (interface{}, error)) ToolDescriptor {
+	paramsType := reflect.TypeOf((*T)(nil)).Elem()
+	rules := parseFieldRules(paramsType)
+	schema := schemaForType(paramsType)
+	applyRulesToSchema(schema, rules)
+	addInstanceProperty(schema)
+
+	return ToolDescriptor{
+		Name:        name,
+		Description: description,
+		Params:      paramsType,
+		Schema:      schema,
+		Actions:     []string{name},
+		rules:       rules,
+		handler: func(ctx context.Context, a *StardewAgent, args map[string]interface{}) (interface{}, error) {
+			var params T
+			if err := decodeArgs(name, args, &params); err != nil {
+				return nil, err
+			}
+			return handler(ctx, a, params)
+		},
+	}
+}
+
+// requires replaces the mod actions the tool sends; none for tools that only read state
+func (d ToolDescriptor) requires(actions ...string) ToolDescriptor {
+	d.Actions = actions
+	return d
+}
+
+// Invoke validates args, decodes them into the tool's parameter struct and runs the handler.
+// Invalid arguments are reported as *ValidationError.
+func (d ToolDescriptor) Invoke(ctx context.Context, a *StardewAgent, args map[string]interface{}) (interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	log.Printf("[TOOL CALL] %s %v", d.Name, args)
+
+	if err := validateArgs(d.Name, d.rules, args); err != nil {
+		log.Printf("[TOOL ERROR] %v", err)
+		return nil, err
+	}
+
+	if err := authorize(ctx, toolScope(d), d.Name); err != nil {
+		log.Printf("[TOOL ERROR] %v", err)
+		return nil, err
+	}
+
+	client, err := resolveInstance(ctx, d.Name, args)
+	if err != nil {
+		log.Printf("[TOOL ERROR] %v", err)
+		return nil, err
+	}
+	ctx = withGameClient(ctx, client)
+
+	caps := client.Capabilities()
+	for _, action := range d.Actions {
+		if !caps.Supports(action) {
+			err := &UnsupportedActionError{Action: action, Mod: caps}
+			log.Printf("[TOOL ERROR] %s: %v", d.Name, err)
+			return nil, err
+		}
+	}
+	if len(d.Actions) > 0 {
+		if err := client.lease.Check(controllerFrom(ctx).id); err != nil {
+			log.Printf("[TOOL ERROR] %s: %v", d.Name, err)
+			return nil, err
+		}
+	}
+
+	result, err := d.handler(ctx, a, args)
+	if err != nil {
+		log.Printf("[TOOL ERROR] %s: %v", d.Name, err)
+		return nil, err
+	}
+	log.Printf("[TOOL RESULT] %s", toolResultText(result))
+	return result, nil
+}
+
+// addInstanceProperty adds the optional "instance" argument every tool accepts
+func addInstanceProperty(schema map[string]interface{}) {
+	props, _ := schema["properties"].(map[string]interface{})
+	if props == nil {
+		props = map[string]interface{}{}
+		schema["properties"] = props
+	}
+	props["instance"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Game instance to run against (default: the instance this connection is bound to)",
+	}
+}
+
+// schemaForType generates a JSON Schema map for a parameter struct
+func schemaForType(t reflect.Type) map[string]interface{} {
+	schema, err := jsonschema.ForType(t, nil)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate schema for %v: %v", t, err))
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal schema for %v: %v", t, err))
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		panic(fmt.Sprintf("failed to unmarshal schema for %v: %v", t, err))
+	}
+	return m
+}
+
+// toolResultText renders a tool result as text for LLM-facing transports
+func toolResultText(result interface{}) string {
+	switch v := result.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
+
+// sendGameCommand sends a mod command and returns its message
+func sendGameCommand(ctx context.Context, action string, params map[string]interface{}) (interface{}, error) {
+	resp, err := clientFrom(ctx).SendCommandContext(ctx, action, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", action, err)
+	}
+	return resp.Message, nil
+}
+
+// lookupTool finds a registered tool by name
+func lookupTool(name string) (ToolDescriptor, bool) {
+	for _, d := range toolRegistry {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return ToolDescriptor{}, false
+}
+
+// availableTools returns the registry without the tools whose actions the mod
+// behind client cannot execute. Before the hello handshake every tool is available.
+func availableTools(client *GameClient) []ToolDescriptor {
+	caps := client.Capabilities()
+	tools := make([]ToolDescriptor, 0, len(toolRegistry))
+	for _, d := range toolRegistry {
+		if caps.SupportsAll(d.Actions) {
+			tools = append(tools, d)
+		}
+	}
+	if hidden := len(toolRegistry) - len(tools); hidden > 0 {
+		log.Printf("[TOOLS] Hiding %d tool(s) the connected %s does not support", hidden, caps)
+	}
+	return tools
+}
+
+// toolCatalog returns name/description/inputSchema entries for tool listings
+func toolCatalog(client *GameClient) []map[string]interface{} {
+	available := availableTools(client)
+	tools := make([]map[string]interface{}, 0, len(available))
+	for _, d := range available {
+		tools = append(tools, map[string]interface{}{
+			"name":        d.Name,
+			"description": d.Description,
+			"inputSchema": d.Schema,
+		})
+	}
+	return tools
+}
+
+// llmTools converts the registry into LLM backend tools bound to this agent
+func (a *StardewAgent) llmTools() []LLMTool {
+	available := availableTools(a.client)
+	tools := make([]LLMTool, 0, len(available))
+	for _, d := range available {
+		d := d
+		tools = append(tools, LLMTool{
+			Name:        d.Name,
+			Description: d.Description,
+			Parameters:  d.Schema,
+			Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+				if a.supervisor != nil {
+					if emergency := a.supervisor.Active(); emergency != "" {
+						return "", fmt.Errorf("the survival supervisor is handling %s; wait for it to finish", emergency)
+					}
+				}
+				agentFeed.Publish("tool", "%s %s", d.Name, toolResultText(args))
+				result, err := d.Invoke(withGameClient(agentContext(ctx), a.client), a, args)
+				if err != nil {
+					agentFeed.Publish("error", "%s: %v", d.Name, err)
+					return "", err
+				}
+				text := toolResultText(result)
+				agentFeed.Publish("result", "%s: %s", d.Name, text)
+				return text, nil
+			},
+		})
+	}
+	return tools
+}
+
+// toolRegistry lists every tool in the order it is presented to agents
+var toolRegistry = []ToolDescriptor{
+	// ========== STANDARD GAMEPLAY TOOLS ==========
+
+	defineTool("get_state", "Get current game state including player position, inventory, time, and surroundings",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			state := clientFrom(ctx).GetState()
+			if state == nil {
+				return "Disconnected", nil
+			}
+			return state, nil
+		}).requires(),
+
+	defineTool("move_to", "Move to a WALKABLE tile. This tool BLOCKS until arrival.",
+		func(ctx context.Context, a *StardewAgent, params MoveToParams) (interface{}, error) {
+			return a.handleMoveTo(ctx, params.X, params.Y)
+		}),
+
+	defineTool("get_surroundings", "Refresh vision to see 61x61 area coordinates.",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			state := clientFrom(ctx).GetState()
+			if state == nil {
+				return "Disconnected", nil
+			}
+			return a.formatGameStateContext(state), nil
+		}).requires(),
+
+	defineTool("interact", "Interact with tile in front",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "interact", nil)
+		}),
+
+	defineTool("use_tool", "Use tool once",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "use_tool", nil)
+		}),
+
+	defineTool("use_tool_repeat", "Execute tool multiple times",
+		func(ctx context.Context, a *StardewAgent, params CountParams) (interface{}, error) {
+			return sendGameCommand(ctx, "use_tool_repeat", map[string]interface{}{"count": params.Count})
+		}),
+
+	defineTool("face_direction", "Turn character to face direction",
+		func(ctx context.Context, a *StardewAgent, params DirectionParams) (interface{}, error) {
+			return sendGameCommand(ctx, "face_direction", map[string]interface{}{"direction": params.Direction})
+		}),
+
+	defineTool("select_item", "Find and equip item by name",
+		func(ctx context.Context, a *StardewAgent, params NameParams) (interface{}, error) {
+			return sendGameCommand(ctx, "select_item", map[string]interface{}{"name": params.Name})
+		}),
+
+	defineTool("switch_tool", "Equip inventory slot",
+		func(ctx context.Context, a *StardewAgent, params SlotParams) (interface{}, error) {
+			return sendGameCommand(ctx, "switch_tool", map[string]interface{}{"slot": params.Slot})
+		}),
+
+	defineTool("eat_item", "Eat food from inventory",
+		func(ctx context.Context, a *StardewAgent, params SlotParams) (interface{}, error) {
+			return sendGameCommand(ctx, "eat_item", map[string]interface{}{"slot": params.Slot})
+		}),
+
+	defineTool("enter_door", "Enter door/warp point in front of player",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "enter_door", nil)
+		}),
+
+	defineTool("find_best_target", "Find nearest target of specified type with walkable approach tile",
+		func(ctx context.Context, a *StardewAgent, params TargetTypeParams) (interface{}, error) {
+			state := clientFrom(ctx).GetState()
+			if state == nil {
+				return "Game disconnected", nil
+			}
+			return a.findBestTarget(state, params.TargetType), nil
+		}).requires(),
+
+	defineTool("clear_target", "Find and clear the nearest target automatically (does select_item + move_to + face + use_tool in one call)",
+		func(ctx context.Context, a *StardewAgent, params TargetTypeParams) (interface{}, error) {
+			return a.clearTarget(ctx, params.TargetType)
+		}).requires("select_item", "move_to", "face_direction", "use_tool", "use_tool_repeat", "interact"),
+
+	// ========== CONTROL LEASE TOOLS ==========
+	// While another agent holds the lease, commands are rejected and only state can be read
+
+	defineTool("acquire_control", "Take exclusive control of the game for a while so other agents cannot send commands. Call again to renew.",
+		func(ctx context.Context, a *StardewAgent, params AcquireControlParams) (interface{}, error) {
+			info, err := acquireControl(ctx, params.TTL, params.Preempt)
+			if err != nil {
+				return nil, err
+			}
+			return fmt.Sprintf("You control the game for %v. Other agents are read-only until then.",
+				time.Until(info.ExpiresAt).Round(time.Second)), nil
+		}).requires(),
+
+	defineTool("release_control", "Give up exclusive control of the game",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			if !clientFrom(ctx).lease.Release(controllerFrom(ctx).id) {
+				return "You did not hold control of the game", nil
+			}
+			return "Control released", nil
+		}).requires(),
+
+	// ========== UNDO TOOLS ==========
+	// Undo reverts journaled cheats with other cheats, so cheat mode must be enabled
+
+	defineTool("undo_last", "Undo the most recent tile-changing cheat (hoeing, clearing, cutting, mining, planting) that has not been undone yet. Reports what could not be reverted.",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return undoEntry(ctx, "")
+		}).requires("cheat_clear_tiles", "cheat_hoe_tiles", "cheat_add_item"),
+
+	defineTool("undo", "Undo one journaled tile-changing cheat by its command id. Reports what could not be reverted.",
+		func(ctx context.Context, a *StardewAgent, params UndoParams) (interface{}, error) {
+			return undoEntry(ctx, params.CommandID)
+		}).requires("cheat_clear_tiles", "cheat_hoe_tiles", "cheat_add_item"),
+
+	defineTool("get_undo_journal", "List the recent tile-changing cheats that undo can revert, newest first",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return journalSummary(ctx), nil
+		}).requires(),
+
+	defineTool("report_goal_status", "Report progress on the autonomous goal. Status complete ends a goal once its DONE WHEN conditions hold; the result lists each condition and its current value.",
+		func(ctx context.Context, a *StardewAgent, params ReportGoalStatusParams) (interface{}, error) {
+			return a.reportGoalStatus(ctx, params)
+		}).requires(),
+
+	// The goal queue is shared with the operator endpoints; changing it needs the operator scope
+
+	defineTool("get_goal_queue", "List the queued goals of the autonomous agent, head first, and whether the queue is paused",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return goalQueueOp(ctx, "list", GoalQueueParams{})
+		}).requires(),
+
+	defineTool("enqueue_goal", "Add a goal to the autonomous agent's queue. The agent works on the head goal until its done conditions hold, then moves on.",
+		func(ctx context.Context, a *StardewAgent, params EnqueueGoalParams) (interface{}, error) {
+			return goalQueueOp(ctx, "enqueue", GoalQueueParams{Text: params.Text, Done: params.Done, Position: params.Position})
+		}).requires(),
+
+	defineTool("move_goal", "Move a queued goal to a new position; position 1 makes it the goal the agent works on",
+		func(ctx context.Context, a *StardewAgent, params MoveGoalParams) (interface{}, error) {
+			return goalQueueOp(ctx, "move", GoalQueueParams{ID: params.GoalID, Position: params.Position})
+		}).requires(),
+
+	defineTool("cancel_goal", "Remove a goal from the queue. Cancelling the head goal moves the agent on to the next one.",
+		func(ctx context.Context, a *StardewAgent, params GoalIDParams) (interface{}, error) {
+			return goalQueueOp(ctx, "cancel", GoalQueueParams{ID: params.GoalID})
+		}).requires(),
+
+	defineTool("pause_goals", "Pause the autonomous agent after its current step; the queue is kept",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return goalQueueOp(ctx, "pause", GoalQueueParams{})
+		}).requires(),
+
+	defineTool("resume_goals", "Resume a paused autonomous agent at the head of the goal queue",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return goalQueueOp(ctx, "resume", GoalQueueParams{})
+		}).requires(),
+
+	// ========== CHEAT MODE TOOLS ==========
+	// These tools require cheat_mode_enable to be called first
+
+	defineTool("cheat_mode_enable", "Enable cheat mode. Required before using other cheat commands.",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_mode_enable", nil)
+		}),
+
+	defineTool("cheat_mode_disable", "Disable cheat mode",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_mode_disable", nil)
+		}),
+
+	defineTool("cheat_warp", "Instantly teleport to any location (Farm, Town, Mountain, Beach, Forest, Mine, etc.)",
+		func(ctx context.Context, a *StardewAgent, params CheatWarpParams) (interface{}, error) {
+			p := map[string]interface{}{"location": params.Location}
+			if params.X != 0 {
+				p["x"] = params.X
+			}
+			if params.Y != 0 {
+				p["y"] = params.Y
+			}
+			return sendGameCommand(ctx, "cheat_warp", p)
+		}),
+
+	defineTool("cheat_set_money", "Set player's gold amount",
+		func(ctx context.Context, a *StardewAgent, params CheatSetMoneyParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_set_money", map[string]interface{}{"amount": params.Amount})
+		}),
+
+	defineTool("cheat_add_item", "Add any item to inventory by ID (e.g., '(O)465' for seeds)",
+		func(ctx context.Context, a *StardewAgent, params CheatAddItemParams) (interface{}, error) {
+			p := map[string]interface{}{"itemId": params.ItemID}
+			if params.Count > 0 {
+				p["count"] = params.Count
+			}
+			if params.Quality > 0 {
+				p["quality"] = params.Quality
+			}
+			return sendGameCommand(ctx, "cheat_add_item", p)
+		}),
+
+	defineTool("cheat_set_energy", "Restore stamina to max (or specific amount)",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_set_energy", nil)
+		}),
+
+	defineTool("cheat_set_health", "Restore health to max (or specific amount)",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_set_health", nil)
+		}),
+
+	defineTool("cheat_set_friendship", "Instantly set friendship with any NPC (hearts or points)",
+		func(ctx context.Context, a *StardewAgent, params CheatSetFriendshipParams) (interface{}, error) {
+			p := map[string]interface{}{"npcName": params.NPCName}
+			if params.Hearts > 0 {
+				p["hearts"] = params.Hearts
+			} else if params.Points > 0 {
+				p["points"] = params.Points
+			} else {
+				p["hearts"] = 10 // default to max
+			}
+			return sendGameCommand(ctx, "cheat_set_friendship", p)
+		}),
+
+	defineTool("cheat_max_all_friendships", "Max out friendship with ALL NPCs at once",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_max_all_friendships", nil)
+		}),
+
+	defineTool("cheat_harvest_all", "Instantly harvest all ready crops in current location",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_harvest_all", nil)
+		}),
+
+	defineTool("cheat_water_all", "Instantly water all soil in current location",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_water_all", nil)
+		}),
+
+	defineTool("cheat_grow_crops", "Instantly grow all crops to harvest-ready",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_grow_crops", nil)
+		}),
+
+	defineTool("cheat_clear_debris", "Remove all weeds, stones, twigs, grass in current location",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_clear_debris", nil)
+		}),
+
+	defineTool("cheat_mine_warp", "Warp directly to specific mine level (1-120 Mines, 121+ Skull Cavern)",
+		func(ctx context.Context, a *StardewAgent, params CheatMineWarpParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_mine_warp", map[string]interface{}{"level": params.Level})
+		}),
+
+	defineTool("cheat_spawn_ores", "Add ores directly to inventory (copper, iron, gold, iridium, coal)",
+		func(ctx context.Context, a *StardewAgent, params CheatSpawnOresParams) (interface{}, error) {
+			p := map[string]interface{}{"oreType": params.OreType}
+			if params.Count > 0 {
+				p["count"] = params.Count
+			}
+			return sendGameCommand(ctx, "cheat_spawn_ores", p)
+		}),
+
+	defineTool("cheat_collect_all_forage", "Instantly collect all forage items in current location",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_collect_all_forage", nil)
+		}),
+
+	defineTool("cheat_instant_mine", "Mine ALL ore nodes in current mine level instantly",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_instant_mine", nil)
+		}),
+
+	defineTool("cheat_time_set", "Set the game time (600=6AM, 1200=noon, 1800=6PM, 2400=midnight)",
+		func(ctx context.Context, a *StardewAgent, params CheatTimeSetParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_time_set", map[string]interface{}{"time": params.Time})
+		}),
+
+	defineTool("cheat_time_freeze", "Toggle time freeze on/off",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_time_freeze", nil)
+		}),
+
+	defineTool("cheat_infinite_energy", "Toggle infinite stamina on/off",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_infinite_energy", nil)
+		}),
+
+	defineTool("cheat_unlock_recipes", "Unlock ALL crafting and cooking recipes",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_unlock_recipes", nil)
+		}),
+
+	defineTool("cheat_pet_all_animals", "Pet ALL farm animals instantly",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_pet_all_animals", nil)
+		}),
+
+	defineTool("cheat_complete_quest", "Complete active quests instantly",
+		func(ctx context.Context, a *StardewAgent, params CheatCompleteQuestParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.QuestID != "" {
+				p["questId"] = params.QuestID
+			}
+			return sendGameCommand(ctx, "cheat_complete_quest", p)
+		}),
+
+	defineTool("cheat_give_gift", "Give a gift to an NPC instantly (for friendship)",
+		func(ctx context.Context, a *StardewAgent, params CheatGiveGiftParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_give_gift", map[string]interface{}{
+				"npcName": params.NPCName,
+				"itemId":  params.ItemID,
+			})
+		}),
+
+	// ========== FARMING CHEAT TOOLS ==========
+
+	defineTool("cheat_hoe_all", "Instantly hoe/till all diggable tiles in current location",
+		func(ctx context.Context, a *StardewAgent, params CheatHoeAllParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.Radius > 0 {
+				p["radius"] = params.Radius
+			}
+			return sendGameCommand(ctx, "cheat_hoe_all", p)
+		}),
+
+	defineTool("cheat_cut_trees", "Instantly cut/chop ALL trees in current location, collect wood/hardwood. Use dryRun first to preview.",
+		func(ctx context.Context, a *StardewAgent, params CheatCutTreesParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if !params.IncludeStumps {
+				p["includeStumps"] = "false"
+			}
+			if params.DryRun {
+				p["dryRun"] = true
+			}
+			return sendDryRunCommand(ctx, "cheat_cut_trees", p)
+		}),
+
+	defineTool("cheat_mine_rocks", "Instantly mine ALL rocks/stones/boulders in current location, collect ores. Use dryRun first to preview.",
+		func(ctx context.Context, a *StardewAgent, params CheatMineRocksParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.DryRun {
+				p["dryRun"] = true
+			}
+			return sendDryRunCommand(ctx, "cheat_mine_rocks", p)
+		}),
+
+	defineTool("cheat_dig_artifacts", "Instantly dig up ALL artifact spots in current location",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_dig_artifacts", nil)
+		}),
+
+	defineTool("cheat_plant_seeds", "Instantly plant seeds on ALL empty hoed tiles",
+		func(ctx context.Context, a *StardewAgent, params CheatPlantSeedsParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_plant_seeds", map[string]interface{}{"seedId": params.SeedID})
+		}),
+
+	defineTool("cheat_fertilize_all", "Apply fertilizer to ALL hoed tiles",
+		func(ctx context.Context, a *StardewAgent, params CheatFertilizeAllParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.FertilizerID != "" {
+				p["fertilizerId"] = params.FertilizerID
+			}
+			return sendGameCommand(ctx, "cheat_fertilize_all", p)
+		}),
+
+	// ========== INVENTORY & UPGRADE CHEAT TOOLS ==========
+
+	defineTool("cheat_upgrade_backpack", "Upgrade backpack to larger size (12, 24, or 36 slots). Default: 36 (max)",
+		func(ctx context.Context, a *StardewAgent, params CheatUpgradeBackpackParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.Size > 0 {
+				p["size"] = params.Size
+			}
+			return sendGameCommand(ctx, "cheat_upgrade_backpack", p)
+		}),
+
+	defineTool("cheat_upgrade_tool", "Upgrade a specific tool to higher level. Levels: 0=Basic, 1=Copper, 2=Steel, 3=Gold, 4=Iridium",
+		func(ctx context.Context, a *StardewAgent, params CheatUpgradeToolParams) (interface{}, error) {
+			p := map[string]interface{}{"tool": params.Tool}
+			if params.Level >= 0 {
+				p["level"] = params.Level
+			}
+			return sendGameCommand(ctx, "cheat_upgrade_tool", p)
+		}),
+
+	defineTool("cheat_upgrade_all_tools", "Upgrade ALL tools to specified level. Default: 4 (Iridium)",
+		func(ctx context.Context, a *StardewAgent, params CheatUpgradeAllToolsParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.Level >= 0 {
+				p["level"] = params.Level
+			}
+			return sendGameCommand(ctx, "cheat_upgrade_all_tools", p)
+		}),
+
+	defineTool("cheat_unlock_all", "UNLOCK EVERYTHING: Max backpack, all tools to iridium, all recipes, all skills to level 10, all special items",
+		func(ctx context.Context, a *StardewAgent, params NoParams) (interface{}, error) {
+			return sendGameCommand(ctx, "cheat_unlock_all", map[string]interface{}{})
+		}),
+
+	// ========== TARGETED/SELECTIVE CHEAT TOOLS (for precise control like drawing shapes) ==========
+
+	defineTool("cheat_hoe_tiles", "Hoe SPECIFIC tiles by coordinates. Perfect for drawing shapes/patterns. Use tiles='x,y;x,y' format or single x,y params.",
+		func(ctx context.Context, a *StardewAgent, params CheatHoeTilesParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.Tiles != "" {
+				p["tiles"] = params.Tiles
+			}
+			if params.X != 0 || params.Y != 0 {
+				p["x"] = params.X
+				p["y"] = params.Y
+			}
+			return sendGameCommand(ctx, "cheat_hoe_tiles", p)
+		}),
+
+	defineTool("cheat_clear_tiles", "Clear SPECIFIC tiles (objects, terrain, hoed dirt). Use tiles='x,y;x,y' format or single x,y params. Use dryRun first to preview.",
+		func(ctx context.Context, a *StardewAgent, params CheatClearTilesParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.Tiles != "" {
+				p["tiles"] = params.Tiles
+			}
+			if params.X != 0 || params.Y != 0 {
+				p["x"] = params.X
+				p["y"] = params.Y
+			}
+			// Only include these if explicitly set to false
+			if !params.ClearObjects {
+				p["clearObjects"] = "false"
+			}
+			if !params.ClearFeatures {
+				p["clearFeatures"] = "false"
+			}
+			if !params.ClearDirt {
+				p["clearDirt"] = "false"
+			}
+			if params.DryRun {
+				p["dryRun"] = true
+			}
+			return sendDryRunCommand(ctx, "cheat_clear_tiles", p)
+		}),
+
+	// cheat_till_pattern is intentionally not registered - AI should design its own patterns using
+	// cheat_hoe_custom_pattern. The preset patterns were too rigid; letting the AI think about tiles
+	// produces better results
+
+	defineTool("cheat_hoe_custom_pattern",
+		`Draw ANY shape/pattern by hoeing specific tiles. YOU must design the pattern!
+
+HOW TO USE:
+1. Think about what shape you want (heart, star, letter, etc.)
+2. Design it as an ASCII grid where '#' = hoe this tile, '.' = skip
+3. Pass the grid string with \n for newlines
+
+EXAMPLE - Heart shape:
+grid=".##.##.\n#######\n#######\n.#####.\n..###..\n...#..."
+
+EXAMPLE - Letter A:
+grid="..#..\n.#.#.\n#####\n#...#\n#...#"
+
+EXAMPLE - Star:
+grid="..#..\n..#..\n#####\n.#.#.\n#...#"
+
+The pattern will be centered at your position (or x,y if specified).
+Surrounding area is auto-cleared so pattern is visible.
+Pass dryRun=true to preview the hoed and cleared tiles on your map first.`,
+		func(ctx context.Context, a *StardewAgent, params CheatHoeCustomPatternParams) (interface{}, error) {
+			p := map[string]interface{}{}
+			if params.X != 0 {
+				p["x"] = params.X
+			}
+			if params.Y != 0 {
+				p["y"] = params.Y
+			}
+			if params.Grid != "" {
+				p["grid"] = params.Grid
+			}
+			if params.OffsetString != "" {
+				p["offsetString"] = params.OffsetString
+			}
+			if params.ClearRadius > 0 {
+				p["clearRadius"] = params.ClearRadius
+			}
+			// clearArea defaults to true, only send if explicitly false
+			if !params.ClearArea {
+				p["clearArea"] = "false"
+			}
+			if params.DryRun {
+				p["dryRun"] = true
+			}
+			return sendDryRunCommand(ctx, "cheat_hoe_custom_pattern", p)
+		}),
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Tool parameter validation
+//
+// Parameter structs declare constraints with a `validate` tag, for example
+// `validate:"min=1,max=100"` or `validate:"enum=up|down|left|right"`.
+// Fields without omitempty in their json tag are required.
+// ============================================================================
+
+// ValidationError reports a tool argument that failed validation
+type ValidationError struct {
+	Tool    string
+	Field   string
+	Reason  string // required, type, range or enum
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid argument %q for %s: %s", e.Field, e.Tool, e.Message)
+}
+
+// fieldRule holds the constraints for one parameter field
+type fieldRule struct {
+	name     string
+	kind     reflect.Kind
+	required bool
+	min      *float64
+	max      *float64
+	enum     []string
+}
+
+// parseFieldRules reads json and validate tags from a parameter struct
+func parseFieldRules(t reflect.Type) []fieldRule {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var rules []fieldRule
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		jsonTag := f.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		rule := fieldRule{
+			name:     name,
+			kind:     f.Type.Kind(),
+			required: !strings.Contains(opts, "omitempty"),
+		}
+		for _, part := range strings.Split(f.Tag.Get("validate"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "min", "max":
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					panic(fmt.Sprintf("bad %s tag on %s.%s: %v", key, t.Name(), f.Name, err))
+				}
+				if key == "min" {
+					rule.min = &n
+				} else {
+					rule.max = &n
+				}
+			case "enum":
+				rule.enum = strings.Split(value, "|")
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// validateArgs checks presence, ranges and enums of raw tool arguments.
+// Enum values match case-insensitively and are rewritten in args to the
+// spelling from the validate tag.
+func validateArgs(tool string, rules []fieldRule, args map[string]interface{}) error {
+	for _, rule := range rules {
+		value, ok := args[rule.name]
+		if !ok || value == nil {
+			if rule.required {
+				return &ValidationError{Tool: tool, Field: rule.name, Reason: "required", Message: "missing required field"}
+			}
+			continue
+		}
+
+		if rule.min != nil || rule.max != nil {
+			n, ok := value.(float64)
+			if !ok {
+				// Type mismatches are reported by decodeArgs with the expected type
+				continue
+			}
+			if rule.min != nil && n < *rule.min {
+				return &ValidationError{Tool: tool, Field: rule.name, Reason: "range",
+					Message: fmt.Sprintf("must be >= %v, got %v", *rule.min, n)}
+			}
+			if rule.max != nil && n > *rule.max {
+				return &ValidationError{Tool: tool, Field: rule.name, Reason: "range",
+					Message: fmt.Sprintf("must be <= %v, got %v", *rule.max, n)}
+			}
+		}
+
+		if len(rule.enum) > 0 {
+			got := fmt.Sprintf("%v", value)
+			allowed := false
+			for _, e := range rule.enum {
+				if strings.EqualFold(e, got) {
+					allowed = true
+					// The mod only knows the canonical spelling
+					if _, isString := value.(string); isString {
+						args[rule.name] = e
+					}
+					break
+				}
+			}
+			if !allowed {
+				return &ValidationError{Tool: tool, Field: rule.name, Reason: "enum",
+					Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(rule.enum, ", "), got)}
+			}
+		}
+	}
+	return nil
+}
+
+// decodeArgs converts validated arguments into the typed parameter struct
+func decodeArgs(tool string, args map[string]interface{}, dst interface{}) error {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode parameters for %s: %w", tool, err)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &ValidationError{Tool: tool, Field: typeErr.Field, Reason: "type",
+				Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)}
+		}
+		return fmt.Errorf("invalid parameters for %s: %w", tool, err)
+	}
+	return nil
+}
+
+// applyRulesToSchema publishes validate tag constraints in the JSON Schema
+func applyRulesToSchema(schema map[string]interface{}, rules []fieldRule) {
+	props, _ := schema["properties"].(map[string]interface{})
+	for _, rule := range rules {
+		prop, _ := props[rule.name].(map[string]interface{})
+		if prop == nil {
+			continue
+		}
+		if rule.min != nil {
+			prop["minimum"] = *rule.min
+		}
+		if rule.max != nil {
+			prop["maximum"] = *rule.max
+		}
+		if len(rule.enum) > 0 {
+			values := make([]interface{}, 0, len(rule.enum))
+			for _, e := range rule.enum {
+				if rule.kind >= reflect.Int && rule.kind <= reflect.Float64 {
+					if n, err := strconv.ParseFloat(e, 64); err == nil {
+						values = append(values, n)
+						continue
+					}
+				}
+				values = append(values, e)
+			}
+			prop["enum"] = values
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type validateTestParams struct {
+	X         int     `json:"x" validate:"min=0,max=200"`
+	Direction string  `json:"direction" validate:"enum=up|down|left|right"`
+	Count     int     `json:"count,omitempty" validate:"min=1"`
+	Level     float64 `json:"level,omitempty" validate:"enum=1|2|3"`
+}
+
+func TestValidateArgs(t *testing.T) {
+	rules := parseFieldRules(reflect.TypeOf(validateTestParams{}))
+
+	tests := []struct {
+		name   string
+		args   map[string]interface{}
+		field  string // empty when the arguments are valid
+		reason string
+	}{
+		{"valid", map[string]interface{}{"x": 5.0, "direction": "up"}, "", ""},
+		{"optional field may be missing", map[string]interface{}{"x": 0.0, "direction": "left", "level": 2.0}, "", ""},
+		{"missing required", map[string]interface{}{"direction": "up"}, "x", "required"},
+		{"null required", map[string]interface{}{"x": nil, "direction": "up"}, "x", "required"},
+		{"below min", map[string]interface{}{"x": -1.0, "direction": "up"}, "x", "range"},
+		{"above max", map[string]interface{}{"x": 201.0, "direction": "up"}, "x", "range"},
+		{"optional below min", map[string]interface{}{"x": 1.0, "direction": "up", "count": 0.0}, "count", "range"},
+		{"not in enum", map[string]interface{}{"x": 1.0, "direction": "sideways"}, "direction", "enum"},
+		{"numeric enum", map[string]interface{}{"x": 1.0, "direction": "up", "level": 4.0}, "level", "enum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArgs("test_tool", rules, tt.args)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("validateArgs: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("validateArgs = %v, want *ValidationError", err)
+			}
+			if verr.Tool != "test_tool" || verr.Field != tt.field || verr.Reason != tt.reason {
+				t.Errorf("got tool %q field %q reason %q, want field %q reason %q", verr.Tool, verr.Field, verr.Reason, tt.field, tt.reason)
+			}
+		})
+	}
+}
+
+func TestValidateArgsCanonicalEnum(t *testing.T) {
+	rules := parseFieldRules(reflect.TypeOf(validateTestParams{}))
+	args := map[string]interface{}{"x": 1.0, "direction": "UP"}
+	if err := validateArgs("test_tool", rules, args); err != nil {
+		t.Fatalf("validateArgs: %v", err)
+	}
+	if args["direction"] != "up" {
+		t.Errorf("direction = %v, want the canonical \"up\"", args["direction"])
+	}
+}
+
+func TestDecodeArgsTypeError(t *testing.T) {
+	var params validateTestParams
+	err := decodeArgs("test_tool", map[string]interface{}{"x": "five", "direction": "up"}, &params)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("decodeArgs = %v, want *ValidationError", err)
+	}
+	if verr.Field != "x" || verr.Reason != "type" {
+		t.Errorf("got field %q reason %q, want x type", verr.Field, verr.Reason)
+	}
+}
+
+func TestApplyRulesToSchema(t *testing.T) {
+	rules := parseFieldRules(reflect.TypeOf(validateTestParams{}))
+	schema := map[string]interface{}{"properties": map[string]interface{}{
+		"x":         map[string]interface{}{"type": "integer"},
+		"direction": map[string]interface{}{"type": "string"},
+		"level":     map[string]interface{}{"type": "number"},
+	}}
+	applyRulesToSchema(schema, rules)
+
+	props := schema["properties"].(map[string]interface{})
+	x := props["x"].(map[string]interface{})
+	if x["minimum"] != 0.0 || x["maximum"] != 200.0 {
+		t.Errorf("x = %v", x)
+	}
+	if got := props["direction"].(map[string]interface{})["enum"]; !reflect.DeepEqual(got, []interface{}{"up", "down", "left", "right"}) {
+		t.Errorf("direction enum = %v", got)
+	}
+	if got := props["level"].(map[string]interface{})["enum"]; !reflect.DeepEqual(got, []interface{}{1.0, 2.0, 3.0}) {
+		t.Errorf("level enum = %v", got)
+	}
+}
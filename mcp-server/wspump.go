@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ============================================================================
+// WebSocket write pump - gorilla/websocket allows one concurrent writer per
+// connection, so every outbound message goes through a single goroutine.
+// ============================================================================
+
+const (
+	wsWriteQueueSize = 64
+	wsWriteTimeout   = 10 * time.Second
+)
+
+// errWriterClosed is returned for messages sent after the connection's writer stopped
+var errWriterClosed = errors.New("websocket writer closed")
+
+type outboundMessage struct {
+	data   []byte
+	result chan error
+}
+
+// writePump serializes writes to one connection through a bounded queue
+type writePump struct {
+	conn  *websocket.Conn
+	queue chan outboundMessage
+	done  chan struct{}
+
+	closeOnce sync.Once
+	mu        sync.Mutex
+	err       error
+}
+
+// newWritePump starts the writer goroutine for conn
+func newWritePump(conn *websocket.Conn) *writePump {
+	p := &writePump{
+		conn:  conn,
+		queue: make(chan outboundMessage, wsWriteQueueSize),
+		done:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *writePump) run() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case msg := <-p.queue:
+			p.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err := p.conn.WriteMessage(websocket.TextMessage, msg.data)
+			msg.result <- err
+			if err != nil {
+				// A failed or timed-out write leaves the connection unusable
+				p.fail(err)
+				p.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// Send queues data and waits until it is written. When the queue is full the
+// caller blocks until there is room, ctx is done or the writer stops.
+func (p *writePump) Send(ctx context.Context, data []byte) error {
+	msg := outboundMessage{data: data, result: make(chan error, 1)}
+
+	select {
+	case p.queue <- msg:
+	case <-p.done:
+		return p.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-msg.result:
+		return err
+	case <-p.done:
+		// The write may still have happened; its result wins if it is already there
+		select {
+		case err := <-msg.result:
+			return err
+		default:
+			return p.closedErr()
+		}
+	}
+}
+
+// SendJSON marshals v and sends it
+func (p *writePump) SendJSON(ctx context.Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return p.Send(ctx, data)
+}
+
+// Close stops the writer; queued messages are dropped
+func (p *writePump) Close() {
+	p.fail(errWriterClosed)
+}
+
+func (p *writePump) fail(err error) {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+		close(p.done)
+	})
+}
+
+func (p *writePump) closedErr() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
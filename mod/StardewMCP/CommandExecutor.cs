@@ -57,12 +57,130 @@ public class CommandExecutor
     private bool _timeFreezeEnabled = false;
     private int _frozenTime = -1;
 
+    // Batch state - a running batch holds the command queue until its last step finishes
+    private GameCommand? _batch;
+    private int _batchStep;
+    private bool _batchStepRunning;
+    private bool _batchStopped;
+    private List<Dictionary<string, object>>? _batchResults;
+
+    // Action name -> handler; also the action list advertised in the hello handshake
+    private readonly Dictionary<string, Func<GameCommand, CommandResponse>> _handlers;
+
     public CommandExecutor(IModHelper helper, IMonitor monitor)
     {
         _helper = helper;
         _monitor = monitor;
+
+        _handlers = new Dictionary<string, Func<GameCommand, CommandResponse>>
+        {
+            // Movement & Basic Actions
+            ["move_to"] = ExecuteMoveTo,
+            ["stop"] = ExecuteStop,
+            ["interact"] = ExecuteInteract,
+            ["face_direction"] = ExecuteFaceDirection,
+
+            // Tool Usage
+            ["use_tool"] = ExecuteUseTool,
+            ["use_tool_repeat"] = ExecuteUseToolRepeat,
+            ["hold_tool"] = ExecuteHoldTool,
+            ["switch_tool"] = ExecuteSwitchTool,
+            ["select_item"] = ExecuteSelectItem,
+
+            // Inventory Management
+            ["place_item"] = ExecutePlaceItem,
+            ["eat_item"] = ExecuteEatItem,
+            ["trash_item"] = ExecuteTrashItem,
+            ["ship_item"] = ExecuteShipItem,
+
+            // Fishing
+            ["cast_fishing_rod"] = ExecuteCastFishingRod,
+            ["reel_fish"] = ExecuteReelFish,
+
+            // Shopping
+            ["open_shop_menu"] = ExecuteOpenShopMenu,
+            ["buy_item"] = ExecuteBuyItem,
+            ["sell_item"] = ExecuteSellItem,
+
+            // Social
+            ["give_gift"] = ExecuteGiveGift,
+            ["check_mail"] = ExecuteCheckMail,
+
+            // Crafting
+            ["craft_item"] = ExecuteCraftItem,
+
+            // World Navigation
+            ["warp_to_location"] = ExecuteWarpToLocation,
+            ["enter_door"] = ExecuteEnterDoor,
+            ["sleep"] = ExecuteSleep,
+
+            // Combat
+            ["attack"] = ExecuteAttack,
+            ["equip_weapon"] = ExecuteEquipWeapon,
+
+            // Animal Care
+            ["pet_animal"] = ExecutePetAnimal,
+            ["milk_animal"] = ExecuteMilkAnimal,
+            ["shear_animal"] = ExecuteShearAnimal,
+            ["collect_product"] = ExecuteCollectProduct,
+
+            // Mining
+            ["use_bomb"] = ExecuteUseBomb,
+
+            // State Query
+            ["get_state"] = ExecuteGetState,
+
+            // Cheat Mode Commands
+            ["cheat_mode_enable"] = ExecuteCheatModeEnable,
+            ["cheat_mode_disable"] = ExecuteCheatModeDisable,
+            ["cheat_warp"] = ExecuteCheatWarp,
+            ["cheat_set_money"] = ExecuteCheatSetMoney,
+            ["cheat_add_item"] = ExecuteCheatAddItem,
+            ["cheat_set_energy"] = ExecuteCheatSetEnergy,
+            ["cheat_set_health"] = ExecuteCheatSetHealth,
+            ["cheat_add_experience"] = ExecuteCheatAddExperience,
+            ["cheat_harvest_all"] = ExecuteCheatHarvestAll,
+            ["cheat_water_all"] = ExecuteCheatWaterAll,
+            ["cheat_grow_crops"] = ExecuteCheatGrowCrops,
+            ["cheat_clear_debris"] = ExecuteCheatClearDebris,
+            ["cheat_mine_warp"] = ExecuteCheatMineWarp,
+            ["cheat_spawn_ores"] = ExecuteCheatSpawnOres,
+            ["cheat_set_friendship"] = ExecuteCheatSetFriendship,
+            ["cheat_max_all_friendships"] = ExecuteCheatMaxAllFriendships,
+            ["cheat_collect_all_forage"] = ExecuteCheatCollectAllForage,
+            ["cheat_instant_mine"] = ExecuteCheatInstantMine,
+            ["cheat_time_set"] = ExecuteCheatTimeSet,
+            ["cheat_time_freeze"] = ExecuteCheatTimeFreeze,
+            ["cheat_infinite_energy"] = ExecuteCheatInfiniteEnergy,
+            ["cheat_unlock_recipes"] = ExecuteCheatUnlockRecipes,
+            ["cheat_pet_all_animals"] = ExecuteCheatPetAllAnimals,
+            ["cheat_complete_quest"] = ExecuteCheatCompleteQuest,
+            ["cheat_give_gift"] = ExecuteCheatGiveGift,
+            ["cheat_hoe_all"] = ExecuteCheatHoeAll,
+            ["cheat_cut_trees"] = ExecuteCheatCutTrees,
+            ["cheat_mine_rocks"] = ExecuteCheatMineRocks,
+            ["cheat_dig_artifacts"] = ExecuteCheatDigArtifacts,
+            ["cheat_plant_seeds"] = ExecuteCheatPlantSeeds,
+            ["cheat_fertilize_all"] = ExecuteCheatFertilizeAll,
+            ["cheat_set_season"] = ExecuteCheatSetSeason,
+
+            // Inventory & upgrade cheats
+            ["cheat_upgrade_backpack"] = ExecuteCheatUpgradeBackpack,
+            ["cheat_upgrade_tool"] = ExecuteCheatUpgradeTool,
+            ["cheat_upgrade_all_tools"] = ExecuteCheatUpgradeAllTools,
+            ["cheat_unlock_all"] = ExecuteCheatUnlockAll,
+
+            // Targeted/selective cheats (for precise control like drawing shapes)
+            ["cheat_hoe_tiles"] = ExecuteCheatHoeTiles,
+            ["cheat_clear_tiles"] = ExecuteCheatClearTiles,
+            ["cheat_till_pattern"] = ExecuteCheatTillPattern,
+            ["cheat_hoe_custom_pattern"] = ExecuteCheatHoeCustomPattern,
+        };
     }
 
+    /// <summary>Every action this build can execute.</summary>
+    public IReadOnlyCollection<string> SupportedActions => _handlers.Keys;
+
     // Public properties for movement state (used by GameStateSerializer)
     public bool IsMoving => _currentPath != null && _pathIndex < _currentPath.Count;
     public Vector2? MovementTarget => _finalTarget;
@@ -89,6 +207,61 @@ public class CommandExecutor
         return obj?.ToString() ?? defaultValue;
     }
 
+    /// <summary>Extract a boolean from a parameter that may be a JSON bool or a "true"/"false" string.</summary>
+    private bool GetBoolParam(object? obj, bool defaultValue = false)
+    {
+        if (obj is JsonElement je)
+        {
+            return je.ValueKind switch
+            {
+                JsonValueKind.True => true,
+                JsonValueKind.False => false,
+                JsonValueKind.String => bool.TryParse(je.GetString(), out var parsed) ? parsed : defaultValue,
+                _ => defaultValue
+            };
+        }
+        if (obj is bool value)
+            return value;
+        return bool.TryParse(obj?.ToString(), out var result) ? result : defaultValue;
+    }
+
+    /// <summary>Whether a destructive cheat should only report what it would change.</summary>
+    private bool IsDryRun(GameCommand command) =>
+        command.Params.TryGetValue("dryRun", out var obj) && GetBoolParam(obj);
+
+    /// <summary>Describe one tile a dry run would change; effect is "remove" or "hoe".</summary>
+    private static Dictionary<string, object> AffectedTile(Vector2 tile, string name, string effect) => new()
+    {
+        ["x"] = (int)tile.X,
+        ["y"] = (int)tile.Y,
+        ["name"] = name,
+        ["effect"] = effect
+    };
+
+    /// <summary>Describe a resource clump a dry run would remove, with the tiles it covers.</summary>
+    private static Dictionary<string, object> AffectedClump(ResourceClump clump, string name)
+    {
+        var affected = AffectedTile(clump.Tile, name, "remove");
+        affected["width"] = clump.width.Value;
+        affected["height"] = clump.height.Value;
+        return affected;
+    }
+
+    /// <summary>Answer a dry run with the tiles the command would change, leaving the save untouched.</summary>
+    private static CommandResponse DryRunResponse(GameCommand command, string summary, List<Dictionary<string, object>> affected) => new()
+    {
+        Id = command.Id,
+        Success = true,
+        Message = $"Dry run: {summary}. Nothing was changed.",
+        Data = new Dictionary<string, object>
+        {
+            ["dryRun"] = true,
+            ["location"] = Game1.currentLocation?.Name ?? "Unknown",
+            ["affected"] = affected,
+            ["affectedCount"] = affected.Count
+        }
+    };
+
     /// <summary>Queue a command for execution on the game thread.</summary>
     public CommandResponse QueueCommand(GameCommand command)
     {
@@ -109,10 +282,17 @@ public class CommandExecutor
         // Enforce cheat mode effects
         EnforceCheatModeEffects();
 
-        // Process new commands (only if not busy with tool use)
-        if (_toolUseRemaining == 0 && _commandQueue.TryDequeue(out var command))
+        // Process new commands (only if not busy with tool use or a batch)
+        if (_batch != null)
         {
-            ExecuteCommand(command);
+            AdvanceBatch();
+        }
+        else if (_toolUseRemaining == 0 && _commandQueue.TryDequeue(out var command))
+        {
+            if (command.Steps != null)
+                StartBatch(command);
+            else
+                ExecuteCommand(command);
         }
 
         // Continue movement if we have a target
@@ -461,116 +641,14 @@ public class CommandExecutor
 
         try
         {
-            var result = command.Action.ToLower() switch
-            {
-                // Movement & Basic Actions
-                "move_to" => ExecuteMoveTo(command),
-                "stop" => ExecuteStop(command),
-                "interact" => ExecuteInteract(command),
-                "face_direction" => ExecuteFaceDirection(command),
-
-                // Tool Usage
-                "use_tool" => ExecuteUseTool(command),
-                "use_tool_repeat" => ExecuteUseToolRepeat(command),
-                "hold_tool" => ExecuteHoldTool(command),
-                "switch_tool" => ExecuteSwitchTool(command),
-                "select_item" => ExecuteSelectItem(command),
-
-                // Inventory Management
-                "place_item" => ExecutePlaceItem(command),
-                "eat_item" => ExecuteEatItem(command),
-                "trash_item" => ExecuteTrashItem(command),
-                "ship_item" => ExecuteShipItem(command),
-
-                // Fishing
-                "cast_fishing_rod" => ExecuteCastFishingRod(command),
-                "reel_fish" => ExecuteReelFish(command),
-
-                // Shopping
-                "open_shop_menu" => ExecuteOpenShopMenu(command),
-                "buy_item" => ExecuteBuyItem(command),
-                "sell_item" => ExecuteSellItem(command),
-
-                // Social
-                "give_gift" => ExecuteGiveGift(command),
-                "check_mail" => ExecuteCheckMail(command),
-
-                // Crafting
-                "craft_item" => ExecuteCraftItem(command),
-
-                // World Navigation
-                "warp_to_location" => ExecuteWarpToLocation(command),
-                "enter_door" => ExecuteEnterDoor(command),
-
-                // Combat
-                "attack" => ExecuteAttack(command),
-                "equip_weapon" => ExecuteEquipWeapon(command),
-
-                // Animal Care
-                "pet_animal" => ExecutePetAnimal(command),
-                "milk_animal" => ExecuteMilkAnimal(command),
-                "shear_animal" => ExecuteShearAnimal(command),
-                "collect_product" => ExecuteCollectProduct(command),
-
-                // Mining
-                "use_bomb" => ExecuteUseBomb(command),
-
-                // State Query
-                "get_state" => ExecuteGetState(command),
-
-                // Cheat Mode Commands
-                "cheat_mode_enable" => ExecuteCheatModeEnable(command),
-                "cheat_mode_disable" => ExecuteCheatModeDisable(command),
-                "cheat_warp" => ExecuteCheatWarp(command),
-                "cheat_set_money" => ExecuteCheatSetMoney(command),
-                "cheat_add_item" => ExecuteCheatAddItem(command),
-                "cheat_set_energy" => ExecuteCheatSetEnergy(command),
-                "cheat_set_health" => ExecuteCheatSetHealth(command),
-                "cheat_add_experience" => ExecuteCheatAddExperience(command),
-                "cheat_harvest_all" => ExecuteCheatHarvestAll(command),
-                "cheat_water_all" => ExecuteCheatWaterAll(command),
-                "cheat_grow_crops" => ExecuteCheatGrowCrops(command),
-                "cheat_clear_debris" => ExecuteCheatClearDebris(command),
-                "cheat_mine_warp" => ExecuteCheatMineWarp(command),
-                "cheat_spawn_ores" => ExecuteCheatSpawnOres(command),
-                "cheat_set_friendship" => ExecuteCheatSetFriendship(command),
-                "cheat_max_all_friendships" => ExecuteCheatMaxAllFriendships(command),
-                "cheat_collect_all_forage" => ExecuteCheatCollectAllForage(command),
-                "cheat_instant_mine" => ExecuteCheatInstantMine(command),
-                "cheat_time_set" => ExecuteCheatTimeSet(command),
-                "cheat_time_freeze" => ExecuteCheatTimeFreeze(command),
-                "cheat_infinite_energy" => ExecuteCheatInfiniteEnergy(command),
-                "cheat_unlock_recipes" => ExecuteCheatUnlockRecipes(command),
-                "cheat_pet_all_animals" => ExecuteCheatPetAllAnimals(command),
-                "cheat_complete_quest" => ExecuteCheatCompleteQuest(command),
-                "cheat_give_gift" => ExecuteCheatGiveGift(command),
-                "cheat_hoe_all" => ExecuteCheatHoeAll(command),
-                "cheat_cut_trees" => ExecuteCheatCutTrees(command),
-                "cheat_mine_rocks" => ExecuteCheatMineRocks(command),
-                "cheat_dig_artifacts" => ExecuteCheatDigArtifacts(command),
-                "cheat_plant_seeds" => ExecuteCheatPlantSeeds(command),
-                "cheat_fertilize_all" => ExecuteCheatFertilizeAll(command),
-                "cheat_set_season" => ExecuteCheatSetSeason(command),
-                
-                // Inventory & upgrade cheats
-                "cheat_upgrade_backpack" => ExecuteCheatUpgradeBackpack(command),
-                "cheat_upgrade_tool" => ExecuteCheatUpgradeTool(command),
-                "cheat_upgrade_all_tools" => ExecuteCheatUpgradeAllTools(command),
-                "cheat_unlock_all" => ExecuteCheatUnlockAll(command),
-                
-                // Targeted/selective cheats (for precise control like drawing shapes)
-                "cheat_hoe_tiles" => ExecuteCheatHoeTiles(command),
-                "cheat_clear_tiles" => ExecuteCheatClearTiles(command),
-                "cheat_till_pattern" => ExecuteCheatTillPattern(command),
-                "cheat_hoe_custom_pattern" => ExecuteCheatHoeCustomPattern(command),
-
-                _ => new CommandResponse
+            var result = _handlers.TryGetValue(command.Action.ToLower(), out var handler)
+                ? handler(command)
+                : new CommandResponse
                 {
                     Id = command.Id,
                     Success = false,
                     Message = $"Unknown action: {command.Action}"
-                }
-            };
+                };
 
             // For async actions (move_to, use_tool_repeat, hold_tool) - don't invoke callback immediately
             // These actions will invoke the callback when they complete or fail
@@ -592,6 +670,139 @@ public class CommandExecutor
         }
     }
 
+    /// <summary>Start a batch; its steps run one per tick, each after the previous one completed.</summary>
+    private void StartBatch(GameCommand batch)
+    {
+        _monitor.Log($"Starting batch of {batch.Steps!.Count} steps", LogLevel.Debug);
+        _batch = batch;
+        _batchStep = 0;
+        _batchStepRunning = false;
+        _batchStopped = false;
+        _batchResults = new List<Dictionary<string, object>>();
+        AdvanceBatch();
+    }
+
+    /// <summary>Run the next batch step once the current one has completed, or finish the batch.</summary>
+    private void AdvanceBatch()
+    {
+        if (_batch == null || _batchStepRunning)
+            return;
+
+        var steps = _batch.Steps!;
+        if (_batchStopped || _batchStep >= steps.Count)
+        {
+            FinishBatch();
+            return;
+        }
+
+        var index = _batchStep;
+        var step = steps[index];
+        _batchStepRunning = true;
+        ExecuteCommand(new GameCommand
+        {
+            Id = $"{_batch.Id}/{index}",
+            Action = step.Action,
+            Params = step.Params ?? new Dictionary<string, object>(),
+            OnComplete = response => CompleteBatchStep(index, response)
+        });
+    }
+
+    /// <summary>Record a step's result, checking its expectations against the response data.</summary>
+    private void CompleteBatchStep(int index, CommandResponse response)
+    {
+        if (_batch == null || index != _batchStep || !_batchStepRunning)
+            return;
+
+        var step = _batch.Steps![index];
+        var success = response.Success;
+        var message = response.Message;
+        if (success && !ExpectationsMet(step.Expect, response.Data, out var reason))
+        {
+            success = false;
+            message = $"{response.Message} ({reason})";
+        }
+
+        var result = new Dictionary<string, object>
+        {
+            ["action"] = step.Action,
+            ["success"] = success,
+            ["message"] = message
+        };
+        if (response.Data != null)
+            result["data"] = response.Data;
+        _batchResults!.Add(result);
+
+        if (!success && _batch.StopOnFailure)
+            _batchStopped = true;
+        _batchStep++;
+        _batchStepRunning = false;
+    }
+
+    /// <summary>Check that every expected key is in the response data with the expected JSON value.</summary>
+    private static bool ExpectationsMet(Dictionary<string, JsonElement>? expect, Dictionary<string, object>? data, out string reason)
+    {
+        reason = "";
+        if (expect == null)
+            return true;
+
+        foreach (var (key, expected) in expect)
+        {
+            if (data == null || !data.TryGetValue(key, out var actual))
+            {
+                reason = $"expected {key}={expected.GetRawText()}, got no {key}";
+                return false;
+            }
+
+            var actualJson = JsonSerializer.Serialize(actual);
+            if (actualJson != expected.GetRawText())
+            {
+                reason = $"expected {key}={expected.GetRawText()}, got {actualJson}";
+                return false;
+            }
+        }
+        return true;
+    }
+
+    /// <summary>Answer the batch with one result per step, marking steps skipped after a failure.</summary>
+    private void FinishBatch()
+    {
+        var batch = _batch!;
+        var results = _batchResults!;
+        var steps = batch.Steps!;
+        var executed = results.Count;
+        var failed = results.Count(r => !(bool)r["success"]);
+
+        for (var i = executed; i < steps.Count; i++)
+        {
+            results.Add(new Dictionary<string, object>
+            {
+                ["action"] = steps[i].Action,
+                ["success"] = false,
+                ["skipped"] = true,
+                ["message"] = "Skipped after a failed step"
+            });
+        }
+
+        _batch = null;
+        _batchResults = null;
+
+        var message = _batchStopped
+            ? $"Batch stopped at step {executed} of {steps.Count}: {steps[executed - 1].Action} failed"
+            : $"Batch completed: {steps.Count - failed} of {steps.Count} steps succeeded";
+        _monitor.Log(message, LogLevel.Debug);
+
+        batch.OnComplete?.Invoke(new CommandResponse
+        {
+            Id = batch.Id,
+            Success = failed == 0,
+            Message = message,
+            Data = new Dictionary<string, object>
+            {
+                ["results"] = results
+            }
+        });
+    }
+
     private CommandResponse ExecuteMoveTo(GameCommand command)
     {
         if (!command.Params.TryGetValue("x", out var xObj) ||
@@ -1824,6 +2035,55 @@ public class CommandExecutor
         };
     }
 
+    /// <summary>
+    /// Go to sleep for the night, as if the player walked onto their bed and answered yes.
+    /// The player must stand on or next to the bed in their house.
+    /// </summary>
+    private CommandResponse ExecuteSleep(GameCommand command)
+    {
+        if (Game1.currentLocation is not FarmHouse house)
+        {
+            return new CommandResponse
+            {
+                Id = command.Id,
+                Success = false,
+                Message = "You can only sleep in your house. Go to the FarmHouse first."
+            };
+        }
+
+        var bed = house.GetPlayerBedSpot();
+        var tile = Game1.player.Tile;
+        if (Math.Abs((int)tile.X - bed.X) > 1 || Math.Abs((int)tile.Y - bed.Y) > 1)
+        {
+            return new CommandResponse
+            {
+                Id = command.Id,
+                Success = false,
+                Message = $"Too far from the bed at ({bed.X}, {bed.Y}). move_to it first.",
+                Data = new Dictionary<string, object>
+                {
+                    ["bedX"] = bed.X,
+                    ["bedY"] = bed.Y
+                }
+            };
+        }
+
+        ClearMovementState();
+        house.answerDialogueAction("Sleep_Yes", null);
+
+        return new CommandResponse
+        {
+            Id = command.Id,
+            Success = true,
+            Message = $"Going to sleep at {Game1.getTimeOfDayString(Game1.timeOfDay)}",
+            Data = new Dictionary<string, object>
+            {
+                ["bedX"] = bed.X,
+                ["bedY"] = bed.Y
+            }
+        };
+    }
+
     #endregion
 
     #region Combat Commands
@@ -3691,6 +3951,22 @@ public class CommandExecutor
             includeStumps = GetStringParam(stumpsObj, "true").ToLower() == "true";
         }
 
+        if (IsDryRun(command))
+        {
+            var affected = new List<Dictionary<string, object>>();
+            foreach (var pair in location.terrainFeatures.Pairs)
+            {
+                if (pair.Value is Tree tree && (includeStumps || !tree.stump.Value))
+                    affected.Add(AffectedTile(pair.Key, tree.stump.Value ? "Stump" : "Tree", "remove"));
+            }
+            foreach (var clump in location.resourceClumps)
+            {
+                if (IsWoodClump(clump))
+                    affected.Add(AffectedClump(clump, clump.parentSheetIndex.Value == 600 ? "Large Stump" : "Hollow Log"));
+            }
+            return DryRunResponse(command, $"would cut {affected.Count} trees/stumps in {location.Name}", affected);
+        }
+
         var random = new Random();
         var featuresToRemove = new List<Vector2>();
 
@@ -3775,8 +4051,7 @@ public class CommandExecutor
         var clumpsToRemove = new List<ResourceClump>();
         foreach (var clump in location.resourceClumps.ToList())
         {
-            // 600 = Large Stump, 602 = Hollow Log
-            if (clump.parentSheetIndex.Value == 600 || clump.parentSheetIndex.Value == 602)
+            if (IsWoodClump(clump))
             {
                 int hardwoodCount = clump.parentSheetIndex.Value == 600 ? random.Next(2, 5) : random.Next(6, 10);
                 var hardwood = ItemRegistry.Create("(O)709", hardwoodCount);
@@ -3813,6 +4088,38 @@ public class CommandExecutor
         };
     }
 
+    /// <summary>Large stumps (600) and hollow logs (602), which cheat_cut_trees removes.</summary>
+    private static bool IsWoodClump(ResourceClump clump) =>
+        clump.parentSheetIndex.Value == 600 || clump.parentSheetIndex.Value == 602;
+
+    /// <summary>Large boulders (672) and ore boulders (752-758), which cheat_mine_rocks removes.</summary>
+    private static bool IsBoulderClump(ResourceClump clump) =>
+        clump.parentSheetIndex.Value is 672 or 752 or 754 or 756 or 758;
+
+    /// <summary>Stones and ore nodes, which cheat_mine_rocks removes.</summary>
+    private static bool IsStoneObject(SObject obj)
+    {
+        var name = obj.Name?.ToLower() ?? "";
+        var qualifiedId = obj.QualifiedItemId ?? "";
+
+        return obj.Name == "Stone" ||
+               name.Contains("stone") ||
+               name.Contains("rock") ||
+               name.Contains("ore") ||
+               name.Contains("node") ||
+               qualifiedId == "(O)343" || // Stone
+               qualifiedId == "(O)450" || // Stone
+               qualifiedId == "(O)668" || // Stone
+               qualifiedId == "(O)670" || // Stone
+               qualifiedId == "(O)751" || // Copper Node
+               qualifiedId == "(O)290" || // Iron Node
+               qualifiedId == "(O)764" || // Gold Node
+               qualifiedId == "(O)765" || // Iridium Node
+               qualifiedId.StartsWith("(O)75") ||
+               qualifiedId.StartsWith("(O)76") ||
+               qualifiedId.StartsWith("(O)77");
+    }
+
     private CommandResponse ExecuteCheatMineRocks(GameCommand command)
     {
         if (!_cheatModeEnabled) return CheatModeDisabledResponse(command);
@@ -3822,6 +4129,22 @@ public class CommandExecutor
         var collectedOres = new Dictionary<string, int>();
         var random = new Random();
 
+        if (IsDryRun(command))
+        {
+            var affected = new List<Dictionary<string, object>>();
+            foreach (var pair in location.Objects.Pairs)
+            {
+                if (IsStoneObject(pair.Value))
+                    affected.Add(AffectedTile(pair.Key, pair.Value.DisplayName ?? pair.Value.Name ?? "Stone", "remove"));
+            }
+            foreach (var clump in location.resourceClumps)
+            {
+                if (IsBoulderClump(clump))
+                    affected.Add(AffectedClump(clump, "Boulder"));
+            }
+            return DryRunResponse(command, $"would mine {affected.Count} rocks/boulders in {location.Name}", affected);
+        }
+
         // Mine all stone/rock objects
         var objectsToRemove = new List<Vector2>();
         foreach (var pair in location.Objects.Pairs.ToList())
@@ -3830,25 +4153,7 @@ public class CommandExecutor
             var name = obj.Name?.ToLower() ?? "";
             var qualifiedId = obj.QualifiedItemId ?? "";
 
-            // Check if it's a stone/rock
-            bool isStone = obj.Name == "Stone" ||
-                          name.Contains("stone") ||
-                          name.Contains("rock") ||
-                          name.Contains("ore") ||
-                          name.Contains("node") ||
-                          qualifiedId == "(O)343" || // Stone
-                          qualifiedId == "(O)450" || // Stone
-                          qualifiedId == "(O)668" || // Stone
-                          qualifiedId == "(O)670" || // Stone
-                          qualifiedId == "(O)751" || // Copper Node
-                          qualifiedId == "(O)290" || // Iron Node
-                          qualifiedId == "(O)764" || // Gold Node
-                          qualifiedId == "(O)765" || // Iridium Node
-                          qualifiedId.StartsWith("(O)75") ||
-                          qualifiedId.StartsWith("(O)76") ||
-                          qualifiedId.StartsWith("(O)77");
-
-            if (isStone)
+            if (IsStoneObject(obj))
             {
                 objectsToRemove.Add(pair.Key);
 
@@ -3948,12 +4253,7 @@ public class CommandExecutor
         var clumpsToRemove = new List<ResourceClump>();
         foreach (var clump in location.resourceClumps.ToList())
         {
-            // 672 = Large boulder, 752/754/756/758 = Ore boulders
-            if (clump.parentSheetIndex.Value == 672 ||
-                clump.parentSheetIndex.Value == 752 ||
-                clump.parentSheetIndex.Value == 754 ||
-                clump.parentSheetIndex.Value == 756 ||
-                clump.parentSheetIndex.Value == 758)
+            if (IsBoulderClump(clump))
             {
                 var clumpDrops = GetResourceClumpDrops(clump);
                 foreach (var drop in clumpDrops)
@@ -4723,6 +5023,20 @@ public class CommandExecutor
         if (command.Params.TryGetValue("clearDirt", out var dirtVal))
             clearDirt = GetStringParam(dirtVal, "true").ToLower() == "true";
 
+        if (IsDryRun(command))
+        {
+            var affected = new List<Dictionary<string, object>>();
+            foreach (var tile in tiles)
+            {
+                if (clearObjects && location.Objects.TryGetValue(tile, out var obj))
+                    affected.Add(AffectedTile(tile, obj.DisplayName ?? obj.Name ?? "Object", "remove"));
+                if (location.terrainFeatures.TryGetValue(tile, out var feature) &&
+                    (feature is HoeDirt ? clearDirt : clearFeatures))
+                    affected.Add(AffectedTile(tile, feature.GetType().Name, "remove"));
+            }
+            return DryRunResponse(command, $"would clear {affected.Count} items from {tiles.Count} tiles in {location.Name}", affected);
+        }
+
         foreach (var tile in tiles)
         {
             // Clear objects (debris, stones, items, etc.)
@@ -4984,6 +5298,9 @@ public class CommandExecutor
         if (command.Params.TryGetValue("clearRadius", out var clearRadiusObj))
             clearRadius = GetIntParam(clearRadiusObj, defaultRadius);
 
+        var dryRun = IsDryRun(command);
+        var affected = new List<Dictionary<string, object>>();
+
         // If clearArea is true, clear all hoed dirt in radius around center (except pattern tiles)
         if (clearArea && tiles.Count > 0)
         {
@@ -5004,7 +5321,10 @@ public class CommandExecutor
                     // Clear hoed dirt that's NOT part of the pattern
                     if (location.terrainFeatures.TryGetValue(tile, out var feature) && feature is HoeDirt)
                     {
-                        location.terrainFeatures.Remove(tile);
+                        if (dryRun)
+                            affected.Add(AffectedTile(tile, "HoeDirt", "remove"));
+                        else
+                            location.terrainFeatures.Remove(tile);
                         clearedCount++;
                     }
                 }
@@ -5026,9 +5346,11 @@ public class CommandExecutor
                 continue;
             }
 
-            var result = TryHoeTile(location, tile);
+            var result = TryHoeTile(location, tile, dryRun);
             if (result == null)
             {
+                if (dryRun)
+                    affected.Add(AffectedTile(tile, "HoeDirt", "hoe"));
                 hoedCount++;
             }
             else
@@ -5040,6 +5362,15 @@ public class CommandExecutor
             }
         }
 
+        if (dryRun)
+        {
+            return DryRunResponse(command,
+                $"would hoe {hoedCount}/{tiles.Count} tiles centered at ({centerX}, {centerY})" +
+                (clearArea ? $" and clear {clearedCount} surrounding hoed tiles" : "") +
+                (failedCount > 0 ? $" ({failedCount} cannot be hoed: {string.Join(", ", failedReasons.Select(kv => $"{kv.Key}={kv.Value}"))})" : ""),
+                affected);
+        }
+
         var totalSuccess = hoedCount + alreadyHoedCount;
 
         return new CommandResponse
@@ -5219,8 +5550,8 @@ public class CommandExecutor
         return tiles.Count > 0 ? tiles : null;
     }
 
-    /// <summary>Try to hoe a single tile, returns null on success or error reason string</summary>
-    private string? TryHoeTile(GameLocation location, Vector2 tile)
+    /// <summary>Try to hoe a single tile, returns null on success or error reason string. A dry run only checks.</summary>
+    private string? TryHoeTile(GameLocation location, Vector2 tile, bool dryRun = false)
     {
         int x = (int)tile.X;
         int y = (int)tile.Y;
@@ -5252,7 +5583,8 @@ public class CommandExecutor
         if (!isTillable)
             return "not_tillable";
 
-        location.terrainFeatures.Add(tile, new HoeDirt(0, location));
+        if (!dryRun)
+            location.terrainFeatures.Add(tile, new HoeDirt(0, location));
         return null; // Success
     }
 
@@ -5725,6 +6057,20 @@ public class GameCommand
     public string Action { get; set; } = "";
     public Dictionary<string, object> Params { get; set; } = new();
     public Action<CommandResponse>? OnComplete { get; set; }
+
+    /// <summary>Steps of a batch command; null for a single action.</summary>
+    public List<BatchStep>? Steps { get; set; }
+
+    /// <summary>Whether a batch skips its remaining steps after a failed one.</summary>
+    public bool StopOnFailure { get; set; } = true;
+}
+
+/// <summary>One action of a batch and the response data it must produce to count as a success.</summary>
+public class BatchStep
+{
+    public string Action { get; set; } = "";
+    public Dictionary<string, object>? Params { get; set; }
+    public Dictionary<string, JsonElement>? Expect { get; set; }
 }
 
 public class CommandResponse
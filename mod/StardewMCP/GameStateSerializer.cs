@@ -406,10 +406,21 @@ public class GameStateSerializer
             Height = layer?.LayerHeight ?? 0,
             IsMineLevel = location is MineShaft,
             MineLevel = location is MineShaft mine ? mine.mineLevel : 0,
-            UniqueId = location.NameOrUniqueName
+            UniqueId = location.NameOrUniqueName,
+            Bed = GetBedSpot(location)
         };
     }
 
+    /// <summary>The tile the player sleeps on, when the location is their house.</summary>
+    private TilePosition? GetBedSpot(GameLocation location)
+    {
+        if (location is not FarmHouse house)
+            return null;
+
+        var bed = house.GetPlayerBedSpot();
+        return new TilePosition { X = bed.X, Y = bed.Y };
+    }
+
     private List<TileInfo> GetNearbyTiles(GameLocation location, int centerX, int centerY)
     {
         var tiles = new List<TileInfo>();
@@ -548,7 +559,8 @@ public class GameStateSerializer
                         MinutesUntilReady = obj.MinutesUntilReady,
                         HeldItemName = obj.heldObject.Value?.Name,
                         RequiredTool = GetRequiredToolForObject(obj),
-                        HitsRequired = GetHitsRequiredForObject(obj)
+                        HitsRequired = GetHitsRequiredForObject(obj),
+                        ItemId = obj.QualifiedItemId
                     });
                 }
             }
@@ -1287,6 +1299,7 @@ public class GameStateSerializer
             var item = player.Items[i];
             if (item != null)
             {
+                var edible = item is SObject food && food.Edibility > -300 ? food : null;
                 items.Add(new InventoryItem
                 {
                     Slot = i,
@@ -1295,7 +1308,10 @@ public class GameStateSerializer
                     Stack = item.Stack,
                     Category = item.getCategoryName(),
                     IsTool = item is Tool,
-                    IsWeapon = item is StardewValley.Tools.MeleeWeapon
+                    IsWeapon = item is StardewValley.Tools.MeleeWeapon,
+                    IsEdible = edible != null,
+                    EnergyRestored = edible?.staminaRecoveredOnConsumption() ?? 0,
+                    HealthRestored = edible?.healthRecoveredOnConsumption() ?? 0
                 });
             }
         }
@@ -1502,6 +1518,13 @@ public class MapInfo
     public bool IsMineLevel { get; set; }
     public int MineLevel { get; set; }
     public string UniqueId { get; set; } = "";
+    public TilePosition? Bed { get; set; } // Player's bed spot, in the farmhouse only
+}
+
+public class TilePosition
+{
+    public int X { get; set; }
+    public int Y { get; set; }
 }
 
 public class TileInfo
@@ -1530,6 +1553,7 @@ public class NearbyObject
     public string? HeldItemName { get; set; }
     public string? RequiredTool { get; set; }
     public int HitsRequired { get; set; } = 1; // Number of tool hits to destroy
+    public string? ItemId { get; set; } // Qualified item ID, e.g. "(BC)130" for a chest
 }
 
 public class NearbyTerrainFeature
@@ -1658,6 +1682,9 @@ public class InventoryItem
     public string Category { get; set; } = "";
     public bool IsTool { get; set; }
     public bool IsWeapon { get; set; }
+    public bool IsEdible { get; set; }
+    public int EnergyRestored { get; set; } // Energy eating one restores (edible items only)
+    public int HealthRestored { get; set; } // Health eating one restores (edible items only)
 }
 
 #endregion
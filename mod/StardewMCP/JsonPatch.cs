@@ -0,0 +1,63 @@
+using System.Collections.Generic;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace StardewMCP;
+
+/// <summary>Builds RFC 6902 JSON patches between two state snapshots.</summary>
+public static class JsonPatch
+{
+    /// <summary>Append the operations that turn <paramref name="from"/> into <paramref name="to"/> at JSON pointer <paramref name="path"/>.</summary>
+    /// <remarks>
+    /// Objects are diffed member by member and arrays of equal length element by element;
+    /// anything else is replaced.
+    /// </remarks>
+    public static void Diff(JsonElement from, JsonElement to, string path, List<JsonPatchOperation> ops)
+    {
+        if (from.ValueKind == JsonValueKind.Object && to.ValueKind == JsonValueKind.Object)
+        {
+            foreach (var property in from.EnumerateObject())
+            {
+                var childPath = path + "/" + Escape(property.Name);
+                if (to.TryGetProperty(property.Name, out var toValue))
+                    Diff(property.Value, toValue, childPath, ops);
+                else
+                    ops.Add(new JsonPatchOperation { Op = "remove", Path = childPath });
+            }
+
+            foreach (var property in to.EnumerateObject())
+            {
+                if (!from.TryGetProperty(property.Name, out _))
+                    ops.Add(new JsonPatchOperation { Op = "add", Path = path + "/" + Escape(property.Name), Value = property.Value });
+            }
+            return;
+        }
+
+        if (from.ValueKind == JsonValueKind.Array && to.ValueKind == JsonValueKind.Array
+            && from.GetArrayLength() == to.GetArrayLength())
+        {
+            for (int i = 0; i < from.GetArrayLength(); i++)
+                Diff(from[i], to[i], $"{path}/{i}", ops);
+            return;
+        }
+
+        if (from.ValueKind != to.ValueKind || from.GetRawText() != to.GetRawText())
+            ops.Add(new JsonPatchOperation { Op = "replace", Path = path, Value = to });
+    }
+
+    /// <summary>Escape an object key for use in a JSON pointer.</summary>
+    private static string Escape(string key)
+    {
+        return key.Replace("~", "~0").Replace("/", "~1");
+    }
+}
+
+/// <summary>A single RFC 6902 patch operation.</summary>
+public class JsonPatchOperation
+{
+    public string Op { get; set; } = "";
+    public string Path { get; set; } = "";
+
+    [JsonIgnore(Condition = JsonIgnoreCondition.WhenWritingNull)]
+    public JsonElement? Value { get; set; }
+}
@@ -0,0 +1,8 @@
+namespace StardewMCP;
+
+/// <summary>The mod settings, read from config.json in the mod folder.</summary>
+public class ModConfig
+{
+    /// <summary>Port of the WebSocket server. Give each game instance on one machine its own port.</summary>
+    public int Port { get; set; } = 8765;
+}
@@ -10,17 +10,19 @@ public class ModEntry : Mod
     private WebSocketServer? _wsServer;
     private GameStateSerializer? _stateSerializer;
     private CommandExecutor? _commandExecutor;
+    private ModConfig _config = new();
 
     /// <summary>The mod entry point.</summary>
     public override void Entry(IModHelper helper)
     {
         Monitor.Log("Stardew MCP Bridge loading...", LogLevel.Info);
+        _config = helper.ReadConfig<ModConfig>();
 
         // Initialize components
         _stateSerializer = new GameStateSerializer();
         _commandExecutor = new CommandExecutor(helper, Monitor);
         _stateSerializer.SetCommandExecutor(_commandExecutor); // Wire up for movement state
-        _wsServer = new WebSocketServer(Monitor, _stateSerializer, _commandExecutor);
+        _wsServer = new WebSocketServer(Monitor, _stateSerializer, _commandExecutor, ModManifest.Version.ToString());
 
         // Register events
         helper.Events.GameLoop.GameLaunched += OnGameLaunched;
@@ -34,8 +36,8 @@ public class ModEntry : Mod
 
     private void OnGameLaunched(object? sender, GameLaunchedEventArgs e)
     {
-        Monitor.Log("Game launched, starting WebSocket server on port 8765...", LogLevel.Info);
-        _wsServer?.Start(8765);
+        Monitor.Log($"Game launched, starting WebSocket server on port {_config.Port}...", LogLevel.Info);
+        _wsServer?.Start(_config.Port);
     }
 
     private void OnSaveLoaded(object? sender, SaveLoadedEventArgs e)
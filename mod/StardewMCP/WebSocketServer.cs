@@ -1,6 +1,7 @@
 using System;
 using System.Collections.Generic;
 using System.Text.Json;
+using System.Text.Json.Serialization;
 using StardewModdingAPI;
 using WebSocketSharp;
 using WebSocketSharp.Server;
@@ -10,9 +11,14 @@ namespace StardewMCP;
 /// <summary>WebSocket server for communication with the MCP server.</summary>
 public class WebSocketServer
 {
+    /// <summary>Version of the WebSocket protocol, reported in the hello handshake.</summary>
+    /// <remarks>1 = hello handshake and state deltas. Builds without hello are protocol 0.</remarks>
+    public const int ProtocolVersion = 1;
+
     private readonly IMonitor _monitor;
     private readonly GameStateSerializer _stateSerializer;
     private readonly CommandExecutor _commandExecutor;
+    private readonly string _modVersion;
     private WebSocketSharp.Server.WebSocketServer? _server;
     private GameBridge? _currentBridge;
 
@@ -22,11 +28,12 @@ public class WebSocketServer
         PropertyNameCaseInsensitive = true
     };
 
-    public WebSocketServer(IMonitor monitor, GameStateSerializer stateSerializer, CommandExecutor commandExecutor)
+    public WebSocketServer(IMonitor monitor, GameStateSerializer stateSerializer, CommandExecutor commandExecutor, string modVersion)
     {
         _monitor = monitor;
         _stateSerializer = stateSerializer;
         _commandExecutor = commandExecutor;
+        _modVersion = modVersion;
     }
 
     public void Start(int port)
@@ -36,7 +43,7 @@ public class WebSocketServer
             _server = new WebSocketSharp.Server.WebSocketServer(port);
             _server.AddWebSocketService<GameBridge>("/game", () =>
             {
-                var bridge = new GameBridge(_monitor, _stateSerializer, _commandExecutor);
+                var bridge = new GameBridge(_monitor, _stateSerializer, _commandExecutor, _modVersion);
                 _currentBridge = bridge;
                 return bridge;
             });
@@ -68,6 +75,14 @@ public class GameBridge : WebSocketBehavior
     private readonly IMonitor _monitor;
     private readonly GameStateSerializer _stateSerializer;
     private readonly CommandExecutor _commandExecutor;
+    private readonly string _modVersion;
+
+    // Delta state: the last snapshot sent and its sequence number.
+    // SendState runs on both the game thread and the socket thread.
+    private readonly object _stateLock = new();
+    private bool _deltasEnabled;
+    private JsonDocument? _lastState;
+    private long _seq;
 
     private static readonly JsonSerializerOptions JsonOptions = new()
     {
@@ -75,11 +90,12 @@ public class GameBridge : WebSocketBehavior
         PropertyNameCaseInsensitive = true
     };
 
-    public GameBridge(IMonitor monitor, GameStateSerializer stateSerializer, CommandExecutor commandExecutor)
+    public GameBridge(IMonitor monitor, GameStateSerializer stateSerializer, CommandExecutor commandExecutor, string modVersion)
     {
         _monitor = monitor;
         _stateSerializer = stateSerializer;
         _commandExecutor = commandExecutor;
+        _modVersion = modVersion;
     }
 
     protected override void OnOpen()
@@ -91,6 +107,12 @@ public class GameBridge : WebSocketBehavior
     protected override void OnClose(CloseEventArgs e)
     {
         _monitor.Log($"Client disconnected: {e.Reason}", StardewModdingAPI.LogLevel.Info);
+
+        lock (_stateLock)
+        {
+            _lastState?.Dispose();
+            _lastState = null;
+        }
     }
 
     protected override void OnMessage(MessageEventArgs e)
@@ -128,8 +150,21 @@ public class GameBridge : WebSocketBehavior
                 HandleCommand(message);
                 break;
 
+            case "batch":
+                HandleBatch(message);
+                break;
+
             case "get_state":
-                SendState();
+                // Always a full snapshot, which is how clients resync after a patch gap
+                SendState(full: true);
+                break;
+
+            case "hello":
+                SendHello(message.Id);
+                break;
+
+            case "enable_deltas":
+                EnableDeltas();
                 break;
 
             case "ping":
@@ -159,21 +194,90 @@ public class GameBridge : WebSocketBehavior
         // Initial acknowledgment is sent, actual result comes via OnComplete callback
     }
 
-    public void SendState()
+    /// <summary>Queue an ordered list of actions that runs without other commands in between and answers once with every step's result.</summary>
+    private void HandleBatch(WebSocketMessage message)
+    {
+        var id = message.Id ?? Guid.NewGuid().ToString();
+        var parameters = message.Params ?? new Dictionary<string, object>();
+
+        List<BatchStep>? steps = null;
+        if (parameters.TryGetValue("steps", out var stepsObj) && stepsObj is JsonElement stepsJson && stepsJson.ValueKind == JsonValueKind.Array)
+        {
+            steps = JsonSerializer.Deserialize<List<BatchStep>>(stepsJson.GetRawText(), JsonOptions);
+        }
+        if (steps == null || steps.Count == 0)
+        {
+            SendResponse(new CommandResponse { Id = id, Success = false, Message = "Batch needs a non-empty steps array" });
+            return;
+        }
+
+        // Steps stop at the first failure unless stopOnFailure is false
+        var stopOnFailure = !(parameters.TryGetValue("stopOnFailure", out var stopObj) &&
+                              stopObj is JsonElement stopJson && stopJson.ValueKind == JsonValueKind.False);
+
+        _commandExecutor.QueueCommand(new GameCommand
+        {
+            Id = id,
+            Action = "batch",
+            Steps = steps,
+            StopOnFailure = stopOnFailure,
+            OnComplete = SendResponse
+        });
+    }
+
+    /// <summary>Send the game state, as a JSON patch against the last state sent once the client enabled deltas.</summary>
+    /// <param name="full">Send a full snapshot even when deltas are enabled.</param>
+    public void SendState(bool full = false)
     {
         if (State != WebSocketState.Open)
             return;
 
         try
         {
-            var stateJson = _stateSerializer.GetGameStateJson();
-            var response = new WebSocketResponse
+            // Serialize under the lock so snapshots and patches go out in sequence order
+            lock (_stateLock)
             {
-                Type = "state",
-                Success = true,
-                Data = JsonSerializer.Deserialize<object>(stateJson)
-            };
-            Send(JsonSerializer.Serialize(response, JsonOptions));
+                var stateJson = _stateSerializer.GetGameStateJson();
+                if (!_deltasEnabled)
+                {
+                    var response = new WebSocketResponse
+                    {
+                        Type = "state",
+                        Success = true,
+                        Data = JsonSerializer.Deserialize<object>(stateJson)
+                    };
+                    Send(JsonSerializer.Serialize(response, JsonOptions));
+                    return;
+                }
+
+                var current = JsonDocument.Parse(stateJson);
+                _seq++;
+                var message = new WebSocketResponse
+                {
+                    Type = "state",
+                    Success = true,
+                    Seq = _seq,
+                    Data = current.RootElement
+                };
+
+                if (!full && _lastState != null)
+                {
+                    var ops = new List<JsonPatchOperation>();
+                    JsonPatch.Diff(_lastState.RootElement, current.RootElement, "", ops);
+                    var patchJson = JsonSerializer.Serialize(ops, JsonOptions);
+
+                    // A patch bigger than the snapshot (e.g. after a bulk cheat) is sent as a snapshot
+                    if (patchJson.Length < stateJson.Length)
+                    {
+                        message.Type = "state_patch";
+                        message.Data = JsonSerializer.Deserialize<JsonElement>(patchJson);
+                    }
+                }
+
+                Send(JsonSerializer.Serialize(message, JsonOptions));
+                _lastState?.Dispose();
+                _lastState = current;
+            }
         }
         catch (Exception ex)
         {
@@ -181,6 +285,20 @@ public class GameBridge : WebSocketBehavior
         }
     }
 
+    /// <summary>Switch this client to sequence-numbered JSON patch deltas, starting from a full snapshot.</summary>
+    private void EnableDeltas()
+    {
+        lock (_stateLock)
+        {
+            _deltasEnabled = true;
+            _lastState?.Dispose();
+            _lastState = null;
+        }
+
+        _monitor.Log("Client enabled state deltas", StardewModdingAPI.LogLevel.Debug);
+        SendState(full: true);
+    }
+
     private void SendResponse(CommandResponse response)
     {
         try
@@ -201,6 +319,27 @@ public class GameBridge : WebSocketBehavior
         }
     }
 
+    /// <summary>Answer a client's hello with the protocol version, mod version and supported actions.</summary>
+    private void SendHello(string? id)
+    {
+        _monitor.Log($"Client hello, answering with protocol {WebSocketServer.ProtocolVersion}", StardewModdingAPI.LogLevel.Debug);
+
+        var response = new WebSocketResponse
+        {
+            Id = id ?? "",
+            Type = "hello",
+            Success = true,
+            Data = new Dictionary<string, object>
+            {
+                ["protocolVersion"] = WebSocketServer.ProtocolVersion,
+                ["modVersion"] = _modVersion,
+                ["actions"] = _commandExecutor.SupportedActions,
+                ["features"] = new[] { "state_deltas", "batch", "dry_run" }
+            }
+        };
+        Send(JsonSerializer.Serialize(response, JsonOptions));
+    }
+
     private void SendError(string message)
     {
         var response = new WebSocketResponse
@@ -240,6 +379,11 @@ public class WebSocketResponse
     public string Type { get; set; } = "";
     public bool Success { get; set; }
     public string? Message { get; set; }
+
+    /// <summary>Sequence number of state and state_patch messages once deltas are enabled.</summary>
+    [JsonIgnore(Condition = JsonIgnoreCondition.WhenWritingNull)]
+    public long? Seq { get; set; }
+
     public object? Data { get; set; }
 }
 
@@ -75,6 +75,7 @@ if %errorlevel% neq 0 (
     echo   1. Make sure Stardew Valley is running with SMAPI
     echo   2. Make sure StardewMCP mod is installed
     echo   3. Check that port %PORT% is not already in use
+echo   4. Add bearer tokens under remote.auth in config.yaml
     echo.
     pause
     exit /b 1
@@ -29,6 +29,8 @@ echo "To connect from another computer, use:"
 echo "  ws://YOUR_IP_ADDRESS:8765/mcp"
 echo ""
 echo "IMPORTANT: Make sure port 8765 is open in your firewall!"
+echo "IMPORTANT: Add bearer tokens under remote.auth in config.yaml -"
+echo "           the server refuses to listen on the network without them."
 echo ""
 echo "Waiting for remote connections..."
 echo "(Press Ctrl+C to stop)"